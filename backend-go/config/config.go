@@ -2,27 +2,41 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration values
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	OSS      OSSConfig
-	JWT      JWTConfig
-	Email    EmailConfig
-	Frontend FrontendConfig
-	Admin    AdminConfig
-	Upload   UploadConfig
+	Server            ServerConfig
+	Database          DatabaseConfig
+	OSS               OSSConfig
+	JWT               JWTConfig
+	Email             EmailConfig
+	Frontend          FrontendConfig
+	Admin             AdminConfig
+	Upload            UploadConfig
+	Reminder          ReminderConfig
+	TwoFactor         TwoFactorConfig
+	WebAuthn          WebAuthnConfig
+	OIDC              OIDCConfig
+	LoginGuard        LoginGuardConfig
+	EmailVerification EmailVerificationConfig
+	Account           AccountConfig
+	Moderation        ModerationConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// Reverse proxies whose X-Forwarded-For / X-Real-IP headers are believed
+	// (IPs or CIDRs); the client IP of any other request is its peer address
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -39,10 +53,18 @@ type OSSConfig struct {
 	AccessKeySecret string
 	Bucket          string
 	Endpoint        string
+	// Storage class used for originals of archived albums (Standard or IA).
+	// Archive and ColdArchive objects must be restored before they can be
+	// read or copied, so reactivating an album could not be done in one step.
+	ArchiveStorageClass string
 }
 
 type JWTConfig struct {
 	Secret string
+	// Lifetime of access tokens; refresh tokens are rotated to get new ones
+	AccessTokenTTL time.Duration
+	// A session ends if its refresh token is not used within this period
+	RefreshTokenTTL time.Duration
 }
 
 type EmailConfig struct {
@@ -61,6 +83,8 @@ type AdminConfig struct {
 	Email    string
 	Password string
 	Name     string
+	// How long an admin may act as a user from one impersonation
+	ImpersonationTTL time.Duration
 }
 
 type UploadConfig struct {
@@ -68,8 +92,96 @@ type UploadConfig struct {
 	MaxFilesPerUpload int
 	MaxPhotosPerAlbum int
 	MaxAlbumsPerUser int
+	// Total size of the photos in a user's albums; 0 for unlimited
+	MaxStoragePerUser int64
 	ThumbnailWidth   int
 	ThumbnailQuality int
+	// Bounds for album expiry: at least MinAlbumLifetime and at most
+	// MaxAlbumLifetime from now
+	MinAlbumLifetime time.Duration
+	MaxAlbumLifetime time.Duration
+}
+
+type ReminderConfig struct {
+	// How often due reminders are sent; must be shorter than every offset
+	// so a reminder cannot go out after the moment it warns about
+	Interval time.Duration
+	// Offsets before expires_at at which owners are reminded, e.g. 24h and 1h
+	Offsets []time.Duration
+	// How long before deletion the final notice is sent
+	BeforeDeletion time.Duration
+	// Hours added by the one-click extend link
+	ExtendHours int
+}
+
+type TwoFactorConfig struct {
+	// Issuer shown in authenticator apps
+	Issuer string
+	// When set, admin routes are refused until the admin has enabled 2FA
+	RequireForAdmins bool
+}
+
+type WebAuthnConfig struct {
+	// Relying party ID, the site's registrable domain (e.g. picshare.com.cn)
+	RPID          string
+	RPDisplayName string
+	// Origins passkey ceremonies may come from, e.g. https://www.picshare.com.cn
+	RPOrigins []string
+}
+
+type OIDCConfig struct {
+	// Issuer URL of the identity provider; OIDC login is disabled when empty
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// Label of the login button
+	ProviderName string
+	// ID token claim listing the user's groups
+	GroupsClaim string
+	// Members of any of these groups are made admins
+	AdminGroups []string
+}
+
+// Enabled reports whether OIDC login is configured
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != "" && c.ClientID != ""
+}
+
+type LoginGuardConfig struct {
+	// Failed sign-ins are counted within this rolling window
+	FailureWindow time.Duration
+	// Failures on an account before each further attempt must wait,
+	// with the wait doubling after every failure
+	DelayAfter int
+	// Failures on an account before it is temporarily locked
+	AccountLockAfter int
+	// Failures from one IP, across all accounts, before it is locked out
+	IPLockAfter  int
+	LockDuration time.Duration
+}
+
+type EmailVerificationConfig struct {
+	// When set, unverified accounts cannot create albums, upload or share
+	// once the grace period after sign-up has passed
+	Required    bool
+	GracePeriod time.Duration
+}
+
+type AccountConfig struct {
+	// Time between a deletion request and the purge, during which the
+	// owner can still cancel it
+	DeletionGracePeriod time.Duration
+	// How long a finished data export stays downloadable
+	ExportRetention time.Duration
+}
+
+type ModerationConfig struct {
+	// Open reports from this many different signed-in users with a verified
+	// email take an album (or photo) out of public view until an admin
+	// reviews it; anonymous reports never do. 0 disables this
+	AutoHideThreshold int
+	// Images attached to feedback are deleted this long after it is resolved
+	FeedbackImageRetention time.Duration
 }
 
 var cfg *Config
@@ -81,8 +193,9 @@ func Load() (*Config, error) {
 
 	cfg = &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "3000"),
-			Env:  getEnv("NODE_ENV", "development"),
+			Port:           getEnv("PORT", "3000"),
+			Env:            getEnv("NODE_ENV", "development"),
+			TrustedProxies: getEnvList("TRUSTED_PROXIES", []string{"127.0.0.1", "::1"}),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -92,14 +205,17 @@ func Load() (*Config, error) {
 			Database: getEnv("DB_NAME", "picshare"),
 		},
 		OSS: OSSConfig{
-			Region:          getEnv("OSS_REGION", "oss-cn-hangzhou"),
-			AccessKeyID:     getEnv("OSS_ACCESS_KEY_ID", getEnv("ALIYUN_AK", "")),
-			AccessKeySecret: getEnv("OSS_ACCESS_KEY_SECRET", getEnv("ALIYUN_SK", "")),
-			Bucket:          getEnv("OSS_BUCKET", ""),
-			Endpoint:        getEnv("OSS_ENDPOINT", "oss-cn-hangzhou.aliyuncs.com"),
+			Region:              getEnv("OSS_REGION", "oss-cn-hangzhou"),
+			AccessKeyID:         getEnv("OSS_ACCESS_KEY_ID", getEnv("ALIYUN_AK", "")),
+			AccessKeySecret:     getEnv("OSS_ACCESS_KEY_SECRET", getEnv("ALIYUN_SK", "")),
+			Bucket:              getEnv("OSS_BUCKET", ""),
+			Endpoint:            getEnv("OSS_ENDPOINT", "oss-cn-hangzhou.aliyuncs.com"),
+			ArchiveStorageClass: getEnv("OSS_ARCHIVE_STORAGE_CLASS", "IA"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "dev-secret"),
+			Secret:          getEnv("JWT_SECRET", "dev-secret"),
+			AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+			RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
 		},
 		Email: EmailConfig{
 			SMTPHost:     getEnv("SMTP_HOST", ""),
@@ -112,24 +228,89 @@ func Load() (*Config, error) {
 			URL: getEnv("FRONTEND_URL", "http://localhost:5173"),
 		},
 		Admin: AdminConfig{
-			Email:    getEnv("ADMIN_EMAIL", "admin@picshare.com.cn"),
-			Password: getEnv("ADMIN_PASSWORD", "Admin123456!"),
-			Name:     getEnv("ADMIN_NAME", "管理员"),
+			Email:            getEnv("ADMIN_EMAIL", "admin@picshare.com.cn"),
+			Password:         getEnv("ADMIN_PASSWORD", "Admin123456!"),
+			Name:             getEnv("ADMIN_NAME", "管理员"),
+			ImpersonationTTL: getEnvDuration("ADMIN_IMPERSONATION_TTL", 30*time.Minute),
 		},
 		Upload: UploadConfig{
 			MaxFileSize:       50 * 1024 * 1024, // 50MB
 			MaxFilesPerUpload: 20,
 			MaxPhotosPerAlbum: 50,
 			MaxAlbumsPerUser:  10,
+			MaxStoragePerUser: int64(getEnvInt("STORAGE_QUOTA_MB", 0)) * 1024 * 1024,
 			ThumbnailWidth:    800,
 			ThumbnailQuality:  75,
+			MinAlbumLifetime:  getEnvDuration("ALBUM_MIN_LIFETIME", 1*time.Hour),
+			MaxAlbumLifetime:  getEnvDuration("ALBUM_MAX_LIFETIME", 90*24*time.Hour),
+		},
+		Reminder: ReminderConfig{
+			Interval:       getEnvDuration("EXPIRY_REMINDER_INTERVAL", 5*time.Minute),
+			Offsets:        getEnvDurations("EXPIRY_REMINDER_OFFSETS", []time.Duration{24 * time.Hour, 1 * time.Hour}),
+			BeforeDeletion: getEnvDuration("EXPIRY_REMINDER_BEFORE_DELETION", 2*time.Hour),
+			ExtendHours:    getEnvInt("EXPIRY_REMINDER_EXTEND_HOURS", 7*24),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			ProviderName: getEnv("OIDC_PROVIDER_NAME", "企业账号"),
+			GroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			AdminGroups:  getEnvList("OIDC_ADMIN_GROUPS", nil),
+		},
+		LoginGuard: LoginGuardConfig{
+			FailureWindow:    getEnvDuration("LOGIN_FAILURE_WINDOW", 1*time.Hour),
+			DelayAfter:       getEnvInt("LOGIN_DELAY_AFTER", 3),
+			AccountLockAfter: getEnvInt("LOGIN_LOCK_AFTER", 10),
+			IPLockAfter:      getEnvInt("LOGIN_IP_LOCK_AFTER", 50),
+			LockDuration:     getEnvDuration("LOGIN_LOCK_DURATION", 15*time.Minute),
 		},
+		EmailVerification: EmailVerificationConfig{
+			Required:    getEnvBool("REQUIRE_EMAIL_VERIFICATION", true),
+			GracePeriod: getEnvDuration("EMAIL_VERIFICATION_GRACE", 72*time.Hour),
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: getEnvDuration("ACCOUNT_DELETION_GRACE", 7*24*time.Hour),
+			ExportRetention:     getEnvDuration("DATA_EXPORT_RETENTION", 48*time.Hour),
+		},
+		Moderation: ModerationConfig{
+			AutoHideThreshold:      getEnvInt("REPORT_AUTO_HIDE_THRESHOLD", 0),
+			FeedbackImageRetention: getEnvDuration("FEEDBACK_IMAGE_RETENTION", 90*24*time.Hour),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:           getEnv("TOTP_ISSUER", "PicShare"),
+			RequireForAdmins: getEnvBool("REQUIRE_ADMIN_2FA", false),
+		},
+	}
+
+	// Passkeys are bound to the frontend's host unless configured otherwise
+	frontendHost := "localhost"
+	if u, err := url.Parse(cfg.Frontend.URL); err == nil && u.Hostname() != "" {
+		frontendHost = u.Hostname()
+	}
+	cfg.WebAuthn = WebAuthnConfig{
+		RPID:          getEnv("WEBAUTHN_RP_ID", frontendHost),
+		RPDisplayName: getEnv("WEBAUTHN_RP_NAME", "PicShare"),
+		RPOrigins:     getEnvList("WEBAUTHN_RP_ORIGINS", []string{cfg.Frontend.URL}),
 	}
 
 	// Validate required fields
 	if cfg.Database.Password == "" {
 		return nil, fmt.Errorf("DB_PASSWORD is required")
 	}
+	if cfg.Reminder.Interval <= 0 {
+		return nil, fmt.Errorf("EXPIRY_REMINDER_INTERVAL must be positive")
+	}
+	for _, offset := range append([]time.Duration{cfg.Reminder.BeforeDeletion}, cfg.Reminder.Offsets...) {
+		if offset <= cfg.Reminder.Interval {
+			return nil, fmt.Errorf("expiry reminder offset %s must be longer than EXPIRY_REMINDER_INTERVAL (%s)", offset, cfg.Reminder.Interval)
+		}
+	}
+	switch cfg.OSS.ArchiveStorageClass {
+	case "Standard", "IA":
+	default:
+		return nil, fmt.Errorf("OSS_ARCHIVE_STORAGE_CLASS must be Standard or IA, got %q", cfg.OSS.ArchiveStorageClass)
+	}
 
 	return cfg, nil
 }
@@ -165,3 +346,57 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvBool retrieves an environment variable as bool (e.g. "true", "1") or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration retrieves an environment variable as duration (e.g. "2h") or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList retrieves a comma-separated list of strings or returns a default value
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// getEnvDurations retrieves a comma-separated list of durations or returns a default value
+func getEnvDurations(key string, defaultValue []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			return defaultValue
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
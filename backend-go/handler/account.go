@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type deleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ExportData - GET /api/auth/export
+// Starts a data export, or returns the one being built or still downloadable
+func ExportData(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	export, err := service.RequestDataExport(ctx, user, c.ClientIP(), util.GetUserAgent(c.Request))
+	if err != nil {
+		util.Log("Failed to request data export for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出数据失败"})
+		return
+	}
+
+	if export.Status == model.DataExportReady {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "数据导出已完成",
+			"export":  export,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "正在打包您的数据，完成后会将下载链接发送到您的邮箱",
+		"export":  export,
+	})
+}
+
+// DeleteAccount - DELETE /api/auth/account
+// Schedules the account for deletion after the grace period
+func DeleteAccount(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req deleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入密码以确认注销"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if !util.ComparePassword(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "密码错误"})
+		return
+	}
+
+	sessionID, _ := middleware.GetSessionID(c)
+	scheduledAt, err := service.ScheduleAccountDeletion(ctx, user, sessionID,
+		c.ClientIP(), util.GetUserAgent(c.Request))
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusConflict, gin.H{"error": "账号已在注销流程中"})
+		return
+	}
+	if err != nil {
+		util.Log("Failed to schedule deletion of user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionAccountDeletion, model.AuditTargetUser, userID, map[string]any{
+		"scheduledAt": scheduledAt,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "账号将在宽限期结束后永久删除，在此之前可以随时撤销",
+		"deletionScheduledAt": scheduledAt,
+	})
+}
+
+// RestoreAccount - POST /api/auth/account/restore
+// Cancels a scheduled deletion during the grace period
+func RestoreAccount(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	err = service.CancelAccountDeletion(ctx, user, c.ClientIP(), util.GetUserAgent(c.Request))
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "账号没有待执行的注销"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销注销失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已撤销注销，账号将继续保留"})
+}
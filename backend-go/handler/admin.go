@@ -4,6 +4,7 @@ import (
 	"context"
 	"math"
 	"net/http"
+	"picshare/model"
 	"picshare/repository"
 	"picshare/util"
 	"strconv"
@@ -84,6 +85,10 @@ func GetUserAlbums(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, model.AuditActionViewUserAlbums, model.AuditTargetUser, userID, map[string]any{
+		"page": page,
+	})
+
 	now := time.Now()
 	albumsWithStatus := make([]gin.H, len(albums))
 	for i, a := range albums {
@@ -158,6 +163,8 @@ func GetAlbumLogs(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, model.AuditActionViewAlbumLogs, model.AuditTargetAlbum, albumID, nil)
+
 	// Format logs
 	formattedLogs := make([]gin.H, len(logs))
 	for i, l := range logs {
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type updateFeedbackRequest struct {
+	Status    string  `json:"status" binding:"required"`
+	AdminNote *string `json:"adminNote"`
+}
+
+type replyFeedbackRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+}
+
+func parseFeedbackIDParam(c *gin.Context) (int, bool) {
+	feedbackID, err := strconv.Atoi(c.Param("feedbackId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的反馈ID"})
+		return 0, false
+	}
+	return feedbackID, true
+}
+
+// GetFeedbackList - GET /api/admin/feedback
+func GetFeedbackList(c *gin.Context) {
+	page, limit := util.ParsePagination(c.Query("page"), c.Query("limit"))
+	status := c.Query("status")
+	if status != "" && !model.IsValidFeedbackStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的处理状态"})
+		return
+	}
+
+	ctx := context.Background()
+	feedback, total, err := repository.GetFeedbackPaginated(ctx, page, limit, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取反馈列表失败"})
+		return
+	}
+	if feedback == nil {
+		feedback = []model.Feedback{}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"feedback": feedback,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+		},
+	})
+}
+
+// GetFeedbackDetail - GET /api/admin/feedback/:feedbackId
+func GetFeedbackDetail(c *gin.Context) {
+	feedbackID, ok := parseFeedbackIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	feedback, err := repository.FindFeedback(ctx, feedbackID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "反馈不存在"})
+		return
+	}
+
+	feedback.Images, err = repository.GetFeedbackImages(ctx, feedbackID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取反馈详情失败"})
+		return
+	}
+	feedback.Replies, err = repository.GetFeedbackReplies(ctx, feedbackID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取反馈详情失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feedback": feedback})
+}
+
+// UpdateFeedback - PUT /api/admin/feedback/:feedbackId
+func UpdateFeedback(c *gin.Context) {
+	feedbackID, ok := parseFeedbackIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req updateFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择处理状态"})
+		return
+	}
+	if req.AdminNote != nil && len(*req.AdminNote) > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "备注不能超过1000个字符"})
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.UpdateFeedback(ctx, adminActor(c), feedbackID, req.Status, req.AdminNote); err != nil {
+		respondAdminError(c, err, "更新反馈失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "反馈已更新", "status": req.Status})
+}
+
+// ReplyToFeedback - POST /api/admin/feedback/:feedbackId/reply
+func ReplyToFeedback(c *gin.Context) {
+	feedbackID, ok := parseFeedbackIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req replyFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "回复内容不能为空且不超过2000个字符"})
+		return
+	}
+
+	ctx := context.Background()
+	reply, err := service.ReplyToFeedback(ctx, adminActor(c), feedbackID, req.Message)
+	if err != nil {
+		respondAdminError(c, err, "回复反馈失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "回复已发送至 " + reply.SentTo, "reply": reply})
+}
+
+// DeleteFeedback - DELETE /api/admin/feedback/:feedbackId
+func DeleteFeedback(c *gin.Context) {
+	feedbackID, ok := parseFeedbackIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.DeleteFeedback(ctx, adminActor(c), feedbackID); err != nil {
+		respondAdminError(c, err, "删除反馈失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "反馈已删除"})
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type suspendUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type changeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type impersonateUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Omitted or null fields reset to the global defaults
+type setUserQuotaRequest struct {
+	MaxAlbums         *int   `json:"maxAlbums"`
+	MaxPhotosPerAlbum *int   `json:"maxPhotosPerAlbum"`
+	StorageQuotaMB    *int64 `json:"storageQuotaMb"`
+}
+
+// adminActor identifies the admin making the request, for the audit log
+func adminActor(c *gin.Context) service.AdminActor {
+	userID, _ := middleware.GetUserID(c)
+	return service.AdminActor{
+		ID:        userID,
+		IP:        c.ClientIP(),
+		UserAgent: util.GetUserAgent(c.Request),
+	}
+}
+
+// respondAdminError writes the response for a failed admin action
+func respondAdminError(c *gin.Context, err error, fallback string) {
+	var adminErr *service.AdminError
+	if errors.As(err, &adminErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": adminErr.Message})
+		return
+	}
+	util.Log("Admin action failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+func parseUserIDParam(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// SuspendUser - POST /api/admin/users/:userId/suspend
+func SuspendUser(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req suspendUserRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+	if err := service.SuspendUser(ctx, adminActor(c), userID, req.Reason); err != nil {
+		respondAdminError(c, err, "停用用户失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户已停用"})
+}
+
+// UnsuspendUser - POST /api/admin/users/:userId/unsuspend
+func UnsuspendUser(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.UnsuspendUser(ctx, adminActor(c), userID); err != nil {
+		respondAdminError(c, err, "恢复用户失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户已恢复"})
+}
+
+// ChangeUserRole - PUT /api/admin/users/:userId/role
+func ChangeUserRole(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req changeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择角色"})
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.ChangeUserRole(ctx, adminActor(c), userID, req.Role); err != nil {
+		respondAdminError(c, err, "修改角色失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "角色已更新", "role": req.Role})
+}
+
+// SetUserQuota - PUT /api/admin/users/:userId/quota
+func SetUserQuota(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req setUserQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的配额"})
+		return
+	}
+
+	quota := &model.UserQuota{
+		MaxAlbums:         req.MaxAlbums,
+		MaxPhotosPerAlbum: req.MaxPhotosPerAlbum,
+	}
+	if req.StorageQuotaMB != nil {
+		bytes := *req.StorageQuotaMB * 1024 * 1024
+		quota.StorageQuotaBytes = &bytes
+	}
+
+	ctx := context.Background()
+	if err := service.SetUserQuota(ctx, adminActor(c), userID, quota); err != nil {
+		respondAdminError(c, err, "设置配额失败")
+		return
+	}
+
+	limits := service.GetUserLimits(ctx, userID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "配额已更新",
+		"quota":   quota,
+		"limits": gin.H{
+			"maxAlbums":         limits.MaxAlbums,
+			"maxPhotosPerAlbum": limits.MaxPhotosPerAlbum,
+			"maxStorage":        limits.MaxStorage,
+		},
+	})
+}
+
+// ForcePasswordReset - POST /api/admin/users/:userId/force-password-reset
+func ForcePasswordReset(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.ForcePasswordReset(ctx, adminActor(c), userID); err != nil {
+		respondAdminError(c, err, "重置密码失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已重置密码并向用户发送设置新密码的邮件"})
+}
+
+// ImpersonateUser - POST /api/admin/users/:userId/impersonate
+func ImpersonateUser(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req impersonateUserRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+	session, err := service.ImpersonateUser(ctx, adminActor(c), userID, req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "模拟登录失败")
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "已以 " + session.User.Name + " 的身份登录，删除等操作将被禁止",
+		"token":     session.AccessToken,
+		"expiresAt": session.ExpiresAt,
+		"user": gin.H{
+			"id":             session.User.ID,
+			"email":          session.User.Email,
+			"name":           session.User.Name,
+			"role":           session.User.Role,
+			"avatarUrl":      session.User.AvatarURL,
+			"emailVerified":  session.User.EmailVerified,
+			"impersonatedBy": adminID,
+		},
+	})
+}
+
+// DeleteUser - DELETE /api/admin/users/:userId
+func DeleteUser(c *gin.Context) {
+	userID, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.AdminDeleteUser(ctx, adminActor(c), userID); err != nil {
+		respondAdminError(c, err, "删除用户失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户及其全部数据已删除"})
+}
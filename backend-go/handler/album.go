@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"picshare/config"
@@ -19,15 +20,24 @@ import (
 )
 
 type createAlbumRequest struct {
-	Title          string  `json:"title"`
-	Description    *string `json:"description"`
-	ExpiresInHours *int    `json:"expiresInHours"`
+	Title          string     `json:"title"`
+	Description    *string    `json:"description"`
+	ExpiresInHours *int       `json:"expiresInHours"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	NeverExpires   bool       `json:"neverExpires"`
+	ExpiryPolicy   *string    `json:"expiryPolicy"`
+	TemplateID     *int       `json:"templateId"`
+	WorkspaceID    *int       `json:"workspaceId"`
 }
 
 type updateAlbumRequest struct {
-	Title          *string `json:"title"`
-	Description    *string `json:"description"`
-	ExpiresInHours *int    `json:"expiresInHours"`
+	Title          *string    `json:"title"`
+	Description    *string    `json:"description"`
+	ExpiresInHours *int       `json:"expiresInHours"`
+	ExtendByHours  *int       `json:"extendByHours"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	NeverExpires   bool       `json:"neverExpires"`
+	ExpiryPolicy   *string    `json:"expiryPolicy"`
 }
 
 // CreateAlbum - POST /api/albums
@@ -39,11 +49,42 @@ func CreateAlbum(c *gin.Context) {
 
 	ctx := context.Background()
 
+	// Fill in unspecified settings from the template
+	if req.TemplateID != nil {
+		template, err := repository.FindAlbumTemplateByIDWithUser(ctx, *req.TemplateID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在"})
+			return
+		}
+		if req.Title == "" && template.Title != nil {
+			req.Title = *template.Title
+		}
+		if req.Description == nil {
+			req.Description = template.Description
+		}
+		if req.ExpiresInHours == nil && req.ExpiresAt == nil && !req.NeverExpires {
+			req.ExpiresInHours = template.ExpiresInHours
+		}
+		if req.ExpiryPolicy == nil {
+			req.ExpiryPolicy = template.ExpiryPolicy
+		}
+	}
+
+	if req.ExpiryPolicy != nil && !model.IsValidExpiryPolicy(*req.ExpiryPolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的过期策略"})
+		return
+	}
+
+	if req.WorkspaceID != nil && !service.CanCreateInWorkspace(ctx, *req.WorkspaceID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权在此工作区创建影集"})
+		return
+	}
+
 	// Check album limit
 	count, _ := repository.CountActiveAlbumsByUser(ctx, userID)
-	cfg := config.Get()
-	if count >= cfg.Upload.MaxAlbumsPerUser {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("每个用户最多只能创建 %d 个未过期影集", cfg.Upload.MaxAlbumsPerUser)})
+	limits := service.GetUserLimits(ctx, userID)
+	if count >= limits.MaxAlbums {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("每个用户最多只能创建 %d 个未过期影集", limits.MaxAlbums)})
 		return
 	}
 
@@ -55,23 +96,33 @@ func CreateAlbum(c *gin.Context) {
 	shareCode := util.GenerateShareCode()
 
 	// Calculate expiry
-	var expiresAt time.Time
-	if req.ExpiresInHours != nil && *req.ExpiresInHours > 0 {
-		expiresAt = time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
-	} else {
-		expiresAt = util.GetDefaultExpiry()
+	expiresAt := util.GetDefaultExpiry()
+	expiryReq := service.AlbumExpiryRequest{
+		ExpiresInHours: req.ExpiresInHours,
+		ExpiresAt:      req.ExpiresAt,
+		NeverExpires:   req.NeverExpires,
+	}
+	if !expiryReq.IsEmpty() {
+		var err error
+		expiresAt, _, err = service.ResolveAlbumExpiry(time.Time{}, expiryReq, middleware.IsAdmin(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	album := &model.Album{
-		UserID:      userID,
-		Title:       title,
-		ShareCode:   shareCode,
-		Description: req.Description,
-		ExpiresAt:   expiresAt,
-		PhotoCount:  0,
-		ViewCount:   0,
+		UserID:        userID,
+		Title:         title,
+		ShareCode:     shareCode,
+		Description:   req.Description,
+		ExpiresAt:     expiresAt,
+		ExpiryPolicy:  req.ExpiryPolicy,
+		WorkspaceID:   req.WorkspaceID,
+		PhotoCount:    0,
+		ViewCount:     0,
 		DownloadCount: 0,
-		IsExpired:   false,
+		IsExpired:     false,
 	}
 
 	err := repository.CreateAlbum(ctx, album)
@@ -81,6 +132,17 @@ func CreateAlbum(c *gin.Context) {
 		return
 	}
 
+	_ = repository.CreateAlbumExpiryChange(ctx, &model.AlbumExpiryChange{
+		AlbumID:      album.ID,
+		ActorID:      &userID,
+		Action:       service.ExpiryActionCreate,
+		NewExpiresAt: album.ExpiresAt,
+	})
+
+	recordAudit(c, model.AuditActionAlbumCreate, model.AuditTargetAlbum, album.ID, map[string]any{
+		"title": album.Title,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "影集创建成功",
 		"album": gin.H{
@@ -89,6 +151,9 @@ func CreateAlbum(c *gin.Context) {
 			"shareCode":     album.ShareCode,
 			"description":   album.Description,
 			"expiresAt":     album.ExpiresAt,
+			"neverExpires":  util.IsNeverExpires(album.ExpiresAt),
+			"expiryPolicy":  album.ExpiryPolicy,
+			"workspaceId":   album.WorkspaceID,
 			"photoCount":    0,
 			"viewCount":     0,
 			"downloadCount": 0,
@@ -110,25 +175,9 @@ func GetMyAlbums(c *gin.Context) {
 	}
 
 	// Mark expired and add shareUrl
-	now := time.Now()
-	cfg := config.Get()
 	albumsWithStatus := make([]gin.H, len(albums))
 	for i, a := range albums {
-		isExpired := a.IsExpired || a.ExpiresAt.Before(now)
-		albumsWithStatus[i] = gin.H{
-			"id":            a.ID,
-			"title":         a.Title,
-			"shareCode":     a.ShareCode,
-			"description":   a.Description,
-			"coverUrl":      a.CoverURL,
-			"photoCount":    a.PhotoCount,
-			"viewCount":     a.ViewCount,
-			"downloadCount": a.DownloadCount,
-			"expiresAt":     a.ExpiresAt,
-			"isExpired":     isExpired,
-			"createdAt":     a.CreatedAt,
-			"shareUrl":      fmt.Sprintf("%s/s/%s", cfg.Frontend.URL, a.ShareCode),
-		}
+		albumsWithStatus[i] = formatAlbumListItem(a)
 	}
 
 	totalPages := (total + limit - 1) / limit
@@ -144,6 +193,30 @@ func GetMyAlbums(c *gin.Context) {
 	})
 }
 
+// formatAlbumListItem renders an album for album list responses
+func formatAlbumListItem(a model.Album) gin.H {
+	cfg := config.Get()
+	return gin.H{
+		"id":            a.ID,
+		"title":         a.Title,
+		"shareCode":     a.ShareCode,
+		"description":   a.Description,
+		"coverUrl":      a.CoverURL,
+		"photoCount":    a.PhotoCount,
+		"viewCount":     a.ViewCount,
+		"downloadCount": a.DownloadCount,
+		"expiresAt":     a.ExpiresAt,
+		"isExpired":     a.IsExpired || a.ExpiresAt.Before(time.Now()),
+		"neverExpires":  util.IsNeverExpires(a.ExpiresAt),
+		"expiryPolicy":  a.ExpiryPolicy,
+		"isArchived":    a.IsArchived(),
+		"archivedAt":    a.ArchivedAt,
+		"workspaceId":   a.WorkspaceID,
+		"createdAt":     a.CreatedAt,
+		"shareUrl":      fmt.Sprintf("%s/s/%s", cfg.Frontend.URL, a.ShareCode),
+	}
+}
+
 // GetAlbumDetail - GET /api/albums/:id
 func GetAlbumDetail(c *gin.Context) {
 	idStr := c.Param("id")
@@ -153,28 +226,20 @@ func GetAlbumDetail(c *gin.Context) {
 		return
 	}
 
-	userID, _ := middleware.GetUserID(c)
-	userRole, _ := middleware.GetUserRole(c)
-	isAdmin := userRole == "admin"
-
 	ctx := context.Background()
 
-	// First check album ownership
-	album, err := repository.FindAlbumByID(ctx, id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
-		return
-	}
-
-	// Check ownership or admin
-	if album.UserID != userID && !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问此影集"})
+	// Check ownership, workspace membership or admin
+	album, ok := findAuthorizedAlbum(c, id, service.AlbumActionView)
+	if !ok {
 		return
 	}
 
 	// Get photos
 	photos, _ := repository.GetPhotosByAlbum(ctx, id)
 
+	// Set when an admin has disabled the share link
+	takedown, _ := repository.FindAlbumTakedown(ctx, id)
+
 	now := time.Now()
 	isExpired := album.IsExpired || album.ExpiresAt.Before(now)
 	cfg := config.Get()
@@ -191,8 +256,14 @@ func GetAlbumDetail(c *gin.Context) {
 			"downloadCount": album.DownloadCount,
 			"expiresAt":     album.ExpiresAt,
 			"isExpired":     isExpired,
+			"neverExpires":  util.IsNeverExpires(album.ExpiresAt),
+			"expiryPolicy":  album.ExpiryPolicy,
+			"isArchived":    album.IsArchived(),
+			"archivedAt":    album.ArchivedAt,
+			"workspaceId":   album.WorkspaceID,
 			"createdAt":     album.CreatedAt,
 			"shareUrl":      fmt.Sprintf("%s/s/%s", cfg.Frontend.URL, album.ShareCode),
+			"takedown":      takedown,
 		},
 		"photos": photos,
 	})
@@ -214,45 +285,211 @@ func UpdateAlbum(c *gin.Context) {
 
 	ctx := context.Background()
 
-	// Verify ownership
-	_, err = repository.FindAlbumByIDWithUser(ctx, id, userID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+	if req.ExpiryPolicy != nil && !model.IsValidExpiryPolicy(*req.ExpiryPolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的过期策略"})
 		return
 	}
 
-	// Build update
-	var title, description *string
-	var expiresAt *time.Time
+	expiryReq := service.AlbumExpiryRequest{
+		ExpiresInHours: req.ExpiresInHours,
+		ExtendByHours:  req.ExtendByHours,
+		ExpiresAt:      req.ExpiresAt,
+		NeverExpires:   req.NeverExpires,
+	}
+	editing := req.Title != nil || req.Description != nil || req.ExpiryPolicy != nil
 
-	if req.Title != nil {
-		title = req.Title
+	// Check if there's anything to update
+	if !editing && expiryReq.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "没有需要更新的内容"})
+		return
 	}
-	if req.Description != nil {
-		description = req.Description
+
+	// Album settings need edit permission, the share link lifetime needs share permission
+	var album *model.Album
+	var ok bool
+	if editing {
+		if album, ok = findAuthorizedAlbum(c, id, service.AlbumActionEdit); !ok {
+			return
+		}
 	}
-	if req.ExpiresInHours != nil {
-		t := time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
-		expiresAt = &t
+	if !expiryReq.IsEmpty() {
+		if album, ok = findAuthorizedAlbum(c, id, service.AlbumActionShare); !ok {
+			return
+		}
 	}
 
-	// Check if there's anything to update
-	if title == nil && description == nil && expiresAt == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "没有需要更新的内容"})
+	// Archived albums are read-only until reactivated by extending the expiry
+	if album.IsArchived() && (req.Title != nil || req.Description != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "影集已归档，请先延长有效期以重新激活"})
+		return
+	}
+
+	// Validate the expiry before changing anything
+	var expiresAt time.Time
+	var expiryAction string
+	if !expiryReq.IsEmpty() {
+		expiresAt, expiryAction, err = service.ResolveAlbumExpiry(album.ExpiresAt, expiryReq, middleware.IsAdmin(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.ExpiryPolicy != nil {
+		err = repository.UpdateAlbumExpiryPolicy(ctx, id, album.UserID, req.ExpiryPolicy)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新影集失败"})
+			return
+		}
+	}
+
+	if req.Title != nil || req.Description != nil {
+		err = repository.UpdateAlbum(ctx, id, album.UserID, req.Title, req.Description)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新影集失败"})
+			return
+		}
+	}
+
+	if !expiryReq.IsEmpty() {
+		err = service.ChangeAlbumExpiry(ctx, album, expiresAt, expiryAction, &userID)
+		if err != nil {
+			var expiryErr *service.ExpiryError
+			if errors.As(err, &expiryErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": expiryErr.Message})
+				return
+			}
+			util.Log("Failed to change expiry of album %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新影集有效期失败"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "影集已更新"})
+}
+
+type duplicateAlbumRequest struct {
+	Title         *string `json:"title"`
+	IncludePhotos bool    `json:"includePhotos"`
+}
+
+// DuplicateAlbum - POST /api/albums/:id/duplicate
+func DuplicateAlbum(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
 		return
 	}
 
-	err = repository.UpdateAlbum(ctx, id, userID, title, description, expiresAt)
+	userID, _ := middleware.GetUserID(c)
+
+	var req duplicateAlbumRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+
+	source, ok := findAuthorizedAlbum(c, id, service.AlbumActionEdit)
+	if !ok {
+		return
+	}
+
+	// Archived originals are in cold storage without thumbnails
+	if req.IncludePhotos && source.IsArchived() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "已归档影集的照片无法复制，请先延长有效期以重新激活"})
+		return
+	}
+
+	// Check album limit
+	count, _ := repository.CountActiveAlbumsByUser(ctx, userID)
+	limits := service.GetUserLimits(ctx, userID)
+	if count >= limits.MaxAlbums {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("每个用户最多只能创建 %d 个未过期影集", limits.MaxAlbums)})
+		return
+	}
+
+	// Copied photos count against the storage quota
+	if req.IncludePhotos && limits.MaxStorage > 0 {
+		used, _ := repository.GetStorageUsedByUser(ctx, userID)
+		size, _ := repository.GetAlbumStorageSize(ctx, source.ID)
+		if used+size > limits.MaxStorage {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("存储空间不足，上限 %s，已使用 %s",
+				util.FormatFileSize(limits.MaxStorage), util.FormatFileSize(used))})
+			return
+		}
+	}
+
+	title := source.Title + " (副本)"
+	if req.Title != nil && *req.Title != "" {
+		title = *req.Title
+	}
+
+	// Keep the source album's lifetime, falling back to the default if it
+	// is no longer allowed (e.g. never-expiring albums copied by non-admins)
+	expiresAt := util.GetDefaultExpiry()
+	expiryReq := service.AlbumExpiryRequest{NeverExpires: util.IsNeverExpires(source.ExpiresAt)}
+	if !expiryReq.NeverExpires {
+		lifetime := source.ExpiresAt.Sub(source.CreatedAt)
+		resolved := time.Now().Add(lifetime)
+		expiryReq.ExpiresAt = &resolved
+	}
+	if resolved, _, err := service.ResolveAlbumExpiry(time.Time{}, expiryReq, middleware.IsAdmin(c)); err == nil {
+		expiresAt = resolved
+	}
+
+	album := &model.Album{
+		UserID:       userID,
+		Title:        title,
+		ShareCode:    util.GenerateShareCode(),
+		Description:  source.Description,
+		ExpiresAt:    expiresAt,
+		ExpiryPolicy: source.ExpiryPolicy,
+	}
+
+	err = repository.CreateAlbum(ctx, album)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新影集失败"})
+		util.Log("Failed to duplicate album %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "复制影集失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "影集已更新"})
+	_ = repository.CreateAlbumExpiryChange(ctx, &model.AlbumExpiryChange{
+		AlbumID:      album.ID,
+		ActorID:      &userID,
+		Action:       service.ExpiryActionCreate,
+		NewExpiresAt: album.ExpiresAt,
+	})
+
+	photoCount := 0
+	if req.IncludePhotos {
+		photoCount, err = service.CopyAlbumPhotos(ctx, source, album)
+		if err != nil {
+			util.Log("Failed to copy photos of album %d: %v", id, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "影集复制成功",
+		"album": gin.H{
+			"id":            album.ID,
+			"title":         album.Title,
+			"shareCode":     album.ShareCode,
+			"description":   album.Description,
+			"expiresAt":     album.ExpiresAt,
+			"neverExpires":  util.IsNeverExpires(album.ExpiresAt),
+			"expiryPolicy":  album.ExpiryPolicy,
+			"photoCount":    photoCount,
+			"viewCount":     0,
+			"downloadCount": 0,
+		},
+		"copiedPhotos": photoCount,
+		"totalPhotos":  source.PhotoCount,
+	})
 }
 
-// DeleteAlbum - DELETE /api/albums/:id
-func DeleteAlbum(c *gin.Context) {
+// MoveAlbumToWorkspace - PUT /api/albums/:id/workspace
+// Moves an album into a workspace, or back to personal albums with a null workspaceId
+func MoveAlbumToWorkspace(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -262,12 +499,69 @@ func DeleteAlbum(c *gin.Context) {
 
 	userID, _ := middleware.GetUserID(c)
 
+	var req struct {
+		WorkspaceID *int `json:"workspaceId"`
+	}
+	c.ShouldBindJSON(&req)
+
 	ctx := context.Background()
 
-	// Verify ownership
-	_, err = repository.FindAlbumByIDWithUser(ctx, id, userID)
+	album, ok := findAuthorizedAlbum(c, id, service.AlbumActionManage)
+	if !ok {
+		return
+	}
+
+	if req.WorkspaceID != nil && !service.CanCreateInWorkspace(ctx, *req.WorkspaceID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权在此工作区创建影集"})
+		return
+	}
+
+	err = repository.SetAlbumWorkspace(ctx, album.ID, req.WorkspaceID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移动影集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "影集已移动"})
+}
+
+// GetAlbumExpiryHistory - GET /api/albums/:id/expiry-history
+func GetAlbumExpiryHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	if _, ok := findAuthorizedAlbum(c, id, service.AlbumActionView); !ok {
+		return
+	}
+
+	history, err := repository.GetAlbumExpiryHistory(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取有效期记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// DeleteAlbum - DELETE /api/albums/:id
+func DeleteAlbum(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	album, ok := findAuthorizedAlbum(c, id, service.AlbumActionManage)
+	if !ok {
 		return
 	}
 
@@ -281,15 +575,76 @@ func DeleteAlbum(c *gin.Context) {
 	}
 
 	// Delete from DB
-	err = repository.DeleteAlbum(ctx, id, userID)
+	err = repository.DeleteAlbum(ctx, id, album.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除影集失败"})
 		return
 	}
 
+	recordAudit(c, model.AuditActionAlbumDelete, model.AuditTargetAlbum, id, map[string]any{
+		"title":      album.Title,
+		"ownerId":    album.UserID,
+		"photoCount": album.PhotoCount,
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "影集已删除"})
 }
 
+// ExtendAlbumByToken - POST /api/extend-album
+// One-click extension from the link in expiry reminder emails
+func ExtendAlbumByToken(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的链接"})
+		return
+	}
+
+	claims, err := util.VerifyAlbumExtendToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "链接已过期或无效"})
+		return
+	}
+
+	ctx := context.Background()
+
+	album, err := service.AuthorizeAlbum(ctx, claims.AlbumID, claims.UserID, false, service.AlbumActionShare)
+	if err != nil || album.UserID != claims.UserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在或已被删除"})
+		return
+	}
+
+	// The link is bound to the expiry it was issued for, so it only works once
+	if album.ExpiresAt.Unix() != claims.ExpiresAt {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "链接已使用或影集有效期已变更"})
+		return
+	}
+
+	cfg := config.Get()
+	extendBy := cfg.Reminder.ExtendHours
+	expiresAt, _, err := service.ResolveAlbumExpiry(album.ExpiresAt, service.AlbumExpiryRequest{ExtendByHours: &extendBy}, false)
+	if err == nil {
+		err = service.ChangeAlbumExpiry(ctx, album, expiresAt, service.ExpiryActionExtendLink, &album.UserID)
+	}
+	if err != nil {
+		var expiryErr *service.ExpiryError
+		if errors.As(err, &expiryErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": expiryErr.Message})
+			return
+		}
+		util.Log("Failed to extend album %d: %v", album.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "延长有效期失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "影集有效期已延长",
+		"title":     album.Title,
+		"expiresAt": expiresAt,
+	})
+}
+
 // GetAlbumQRCode - GET /api/albums/:id/qrcode
 func GetAlbumQRCode(c *gin.Context) {
 	idStr := c.Param("id")
@@ -299,14 +654,8 @@ func GetAlbumQRCode(c *gin.Context) {
 		return
 	}
 
-	userID, _ := middleware.GetUserID(c)
-
-	ctx := context.Background()
-
-	// Verify ownership
-	album, err := repository.FindAlbumByIDWithUser(ctx, id, userID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+	album, ok := findAuthorizedAlbum(c, id, service.AlbumActionView)
+	if !ok {
 		return
 	}
 
@@ -326,3 +675,21 @@ func GetAlbumQRCode(c *gin.Context) {
 		"shareCode": album.ShareCode,
 	})
 }
+
+// findAuthorizedAlbum loads an album the current user may perform the
+// action on, writing the error response if not
+func findAuthorizedAlbum(c *gin.Context, albumID int, action string) (*model.Album, bool) {
+	userID, _ := middleware.GetUserID(c)
+
+	album, err := service.AuthorizeAlbum(context.Background(), albumID, userID, middleware.IsAdmin(c), action)
+	if err != nil {
+		if errors.Is(err, service.ErrAlbumForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "无权执行此操作"})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+		}
+		return nil, false
+	}
+
+	return album, true
+}
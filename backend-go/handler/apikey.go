@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAPIKeysPerUser limits how many usable keys a user can hold at once
+const maxAPIKeysPerUser = 20
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Omit for a key that never expires
+	ExpiresInDays *int `json:"expiresInDays"`
+}
+
+// GetAPIKeys - GET /api/auth/profile/api-keys
+func GetAPIKeys(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	keys, err := repository.GetAPIKeysByUser(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取 API 密钥失败"})
+		return
+	}
+
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"apiKeys": keys})
+}
+
+// CreateAPIKey - POST /api/auth/profile/api-keys
+func CreateAPIKey(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "名称不能为空且不能超过50个字符"})
+		return
+	}
+
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !model.IsValidAPIKeyScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的权限范围：" + scope})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请至少选择一项权限"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		if *req.ExpiresInDays < 1 || *req.ExpiresInDays > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "有效期需在1到365天之间"})
+			return
+		}
+		t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	ctx := context.Background()
+	count, err := repository.CountActiveAPIKeys(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建 API 密钥失败"})
+		return
+	}
+	if count >= maxAPIKeysPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API 密钥数量已达上限，请先撤销不再使用的密钥"})
+		return
+	}
+
+	key := util.GenerateAPIKey()
+	apiKey := &model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		KeyPrefix: key[:len(util.APIKeyPrefix)+8],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := repository.CreateAPIKey(ctx, apiKey, util.HashToken(key)); err != nil {
+		util.Log("Failed to create API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建 API 密钥失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionAPIKeyCreate, model.AuditTargetAPIKey, apiKey.ID, map[string]any{
+		"name":   apiKey.Name,
+		"prefix": apiKey.KeyPrefix,
+		"scopes": apiKey.Scopes,
+	})
+
+	// The key itself is only ever shown here
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API 密钥已创建，请立即复制保存，关闭后将无法再次查看",
+		"apiKey":  apiKey,
+		"key":     key,
+	})
+}
+
+// RevokeAPIKey - DELETE /api/auth/profile/api-keys/:id
+func RevokeAPIKey(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的密钥ID"})
+		return
+	}
+
+	ctx := context.Background()
+	err = repository.RevokeAPIKey(ctx, id, userID)
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API 密钥不存在或已撤销"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销 API 密钥失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionAPIKeyRevoke, model.AuditTargetAPIKey, id, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "API 密钥已撤销"})
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditExportLimit caps the rows in one CSV export
+const auditExportLimit = 50000
+
+// auditActor identifies who made the request for the audit trail
+func auditActor(c *gin.Context) service.AuditActor {
+	actor := service.AuditActor{
+		IP:        c.ClientIP(),
+		UserAgent: util.GetUserAgent(c.Request),
+	}
+	if user, ok := middleware.GetAuthUser(c); ok {
+		actor.UserID = user.ID
+		actor.Email = user.Email
+		actor.ImpersonatedBy = user.ImpersonatedBy
+	}
+	return actor
+}
+
+// recordAudit appends an event by the signed-in user to the audit trail
+func recordAudit(c *gin.Context, action, targetType string, targetID int, details map[string]any) {
+	service.RecordAuditEvent(context.Background(), auditActor(c), action, targetType, targetID, details)
+}
+
+// recordAuditFor appends an event by a user who is not signed in on this
+// request, such as a login or a password reset, to the audit trail
+func recordAuditFor(c *gin.Context, user *model.User, action string, details map[string]any) {
+	actor := auditActor(c)
+	actor.UserID = user.ID
+	actor.Email = user.Email
+	service.RecordAuditEvent(context.Background(), actor, action, model.AuditTargetUser, user.ID, details)
+}
+
+// parseAuditEventFilter reads the audit event filters from the query string.
+// Dates are YYYY-MM-DD in UTC, and the "to" day is included.
+func parseAuditEventFilter(c *gin.Context) (repository.AuditEventFilter, bool) {
+	filter := repository.AuditEventFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		IPAddress:  c.Query("ip"),
+	}
+	filter.ActorID, _ = strconv.Atoi(c.Query("actorId"))
+	filter.TargetID, _ = strconv.Atoi(c.Query("targetId"))
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始日期"})
+			return filter, false
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束日期"})
+			return filter, false
+		}
+		t = t.AddDate(0, 0, 1)
+		filter.To = &t
+	}
+	return filter, true
+}
+
+// GetAuditEvents - GET /api/admin/audit-events
+func GetAuditEvents(c *gin.Context) {
+	page, limit := util.ParsePagination(c.Query("page"), c.Query("limit"))
+	filter, ok := parseAuditEventFilter(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	events, total, err := repository.GetAuditEvents(ctx, page, limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审计日志失败"})
+		return
+	}
+	if events == nil {
+		events = []model.AuditEvent{}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+		},
+	})
+}
+
+// ExportAuditEvents - GET /api/admin/audit-events/export
+func ExportAuditEvents(c *gin.Context) {
+	filter, ok := parseAuditEventFilter(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	events, err := repository.GetAuditEventsForExport(ctx, filter, auditExportLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出审计日志失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionExportAuditEvents, model.AuditTargetAuditEvent, 0, map[string]any{
+		"rows":       len(events),
+		"actorId":    filter.ActorID,
+		"action":     filter.Action,
+		"targetType": filter.TargetType,
+		"targetId":   filter.TargetID,
+		"ip":         filter.IPAddress,
+		"from":       c.Query("from"),
+		"to":         c.Query("to"),
+	})
+
+	filename := fmt.Sprintf("audit-events-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// BOM so spreadsheet apps read the UTF-8 text correctly
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{
+		"id", "created_at", "actor_id", "actor_email", "impersonated_by", "action",
+		"target_type", "target_id", "ip_address", "user_agent", "details", "prev_hash", "hash",
+	})
+	for _, e := range events {
+		w.Write([]string{
+			strconv.Itoa(e.ID),
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			optionalInt(e.ActorID),
+			optionalString(e.ActorEmail),
+			optionalInt(e.ImpersonatedBy),
+			e.Action,
+			e.TargetType,
+			strconv.Itoa(e.TargetID),
+			optionalString(e.IPAddress),
+			optionalString(e.UserAgent),
+			optionalString(e.Details),
+			e.PrevHash,
+			e.Hash,
+		})
+	}
+	w.Flush()
+}
+
+// VerifyAuditEvents - GET /api/admin/audit-events/verify
+func VerifyAuditEvents(c *gin.Context) {
+	ctx := context.Background()
+	report, err := service.VerifyAuditChain(ctx)
+	if err != nil {
+		util.Log("Failed to verify audit chain: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "校验审计日志失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -2,17 +2,29 @@ package handler
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"math"
 	"net/http"
+	"picshare/config"
 	"picshare/middleware"
 	"picshare/model"
 	"picshare/repository"
 	"picshare/service"
 	"picshare/util"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// magicLinkTTL is how long a magic sign-in link stays valid
+const magicLinkTTL = 15 * time.Minute
+
+// emailChangeTTL is how long the link confirming a new email stays valid
+const emailChangeTTL = 24 * time.Hour
+
 type registerRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -24,18 +36,41 @@ type loginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type oidcCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
 type resetPasswordRequest struct {
 	Token       string `json:"token" binding:"required"`
 	NewPassword string `json:"newPassword" binding:"required"`
 }
 
+type magicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type magicLinkLoginRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type changeEmailRequest struct {
+	NewEmail string `json:"newEmail" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
 type changePasswordRequest struct {
 	CurrentPassword string `json:"currentPassword" binding:"required"`
 	NewPassword     string `json:"newPassword" binding:"required"`
 }
 
 type updateProfileRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name         string  `json:"name" binding:"required"`
+	ExpiryPolicy *string `json:"expiryPolicy"`
 }
 
 // Register - POST /api/auth/register
@@ -80,12 +115,12 @@ func Register(c *gin.Context) {
 
 	// Create user
 	user := &model.User{
-		Email:             req.Email,
-		PasswordHash:      passwordHash,
-		Name:              req.Name,
-		Role:              "photographer",
-		EmailVerified:      false,
-		VerificationToken: &verificationToken,
+		Email:               req.Email,
+		PasswordHash:        passwordHash,
+		Name:                req.Name,
+		Role:                "photographer",
+		EmailVerified:       false,
+		VerificationToken:   &verificationToken,
 		VerificationExpires: &verificationExpires,
 	}
 
@@ -107,8 +142,13 @@ func Register(c *gin.Context) {
 		_ = emailService.SendVerificationEmail(req.Email, verificationToken)
 	}
 
-	// Generate JWT token
-	token, _ := util.GenerateJWT(user.ID, user.Email, user.Role, user.Name)
+	// Start a login session
+	tokens, err := service.IssueSession(ctx, user, c.ClientIP(), util.GetUserAgent(c.Request))
+	if err != nil {
+		util.Log("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注册失败，请稍后重试"})
+		return
+	}
 
 	message := "注册成功！"
 	if !user.EmailVerified {
@@ -118,18 +158,30 @@ func Register(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": message,
-		"token":   token,
+		"message":      message,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
 		"user": gin.H{
-			"id":           user.ID,
-			"email":        user.Email,
-			"name":         user.Name,
-			"role":         user.Role,
-			"emailVerified": user.EmailVerified,
+			"id":                   user.ID,
+			"email":                user.Email,
+			"name":                 user.Name,
+			"role":                 user.Role,
+			"emailVerified":        user.EmailVerified,
+			"verificationDeadline": verificationDeadline(user),
 		},
 	})
 }
 
+// verificationDeadline returns when an unverified user loses access to
+// album creation, uploads and sharing, or nil if that does not apply
+func verificationDeadline(user *model.User) *time.Time {
+	if user.EmailVerified {
+		return nil
+	}
+	return middleware.EmailVerificationDeadline(user.CreatedAt)
+}
+
 // Login - POST /api/auth/login
 func Login(c *gin.Context) {
 	var req loginRequest
@@ -140,21 +192,137 @@ func Login(c *gin.Context) {
 
 	ctx := context.Background()
 
+	// Count the attempt first; refused while the account or IP is locked
+	// out or cooling down
+	attempt := reserveLoginAttempt(c, req.Email)
+	if attempt == nil {
+		return
+	}
+
 	// Find user
 	user, err := repository.FindUserByEmail(ctx, req.Email)
 	if err != nil {
+		service.RecordLoginFailure(ctx, attempt, util.GetUserAgent(c.Request))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码错误"})
 		return
 	}
 
 	// Verify password
 	if !util.ComparePassword(req.Password, user.PasswordHash) {
+		service.RecordLoginFailure(ctx, attempt, util.GetUserAgent(c.Request))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码错误"})
 		return
 	}
+	service.ReleaseLoginAttempt(ctx, attempt)
+
+	continueLogin(c, user, model.LoginMethodPassword)
+}
+
+// continueLogin carries on a sign-in whose first factor (password or magic
+// link) has been checked: with 2FA on it only earns a token for the second
+// step, otherwise it starts a session
+func continueLogin(c *gin.Context, user *model.User, method string) {
+	ctx := context.Background()
+
+	twoFactorEnabled, err := repository.IsTwoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return
+	}
+	if twoFactorEnabled {
+		twoFactorToken, err := util.GenerateTwoFactorToken(user.ID, service.TwoFactorLoginWindow)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "请输入两步验证码",
+			"requiresTwoFactor": true,
+			"twoFactorToken":    twoFactorToken,
+		})
+		return
+	}
+
+	completeLogin(c, user, twoFactorEnabled, method)
+}
+
+// reserveLoginAttempt counts a sign-in attempt before its credentials are
+// checked; it writes a 429 response and returns nil if sign-in to the
+// account is currently throttled because of earlier failures
+func reserveLoginAttempt(c *gin.Context, email string) *service.LoginAttempt {
+	attempt, err := service.ReserveLoginAttempt(context.Background(), email, c.ClientIP())
+	var throttleErr *service.LoginThrottleError
+	if errors.As(err, &throttleErr) {
+		retryAfter := int(math.Ceil(throttleErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":      throttleErr.Message,
+			"retryAfter": retryAfter,
+		})
+		return nil
+	}
+	if err != nil {
+		util.Log("Failed to check login throttle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return nil
+	}
+	return attempt
+}
+
+// checkCurrentPassword re-checks the signed-in user's password before a
+// security change; guesses count against the same throttle as sign-in. It
+// writes the error response and returns false if the check does not pass
+func checkCurrentPassword(c *gin.Context, user *model.User, password string) bool {
+	ctx := context.Background()
+
+	attempt := reserveLoginAttempt(c, user.Email)
+	if attempt == nil {
+		return false
+	}
+
+	if !util.ComparePassword(password, user.PasswordHash) {
+		service.RecordLoginFailure(ctx, attempt, util.GetUserAgent(c.Request))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "当前密码错误"})
+		return false
+	}
+	service.ReleaseLoginAttempt(ctx, attempt)
+	return true
+}
+
+// completeLogin starts a session for a user who has passed every login
+// check and writes the login response
+func completeLogin(c *gin.Context, user *model.User, twoFactorEnabled bool, method string) {
+	ctx := context.Background()
+	ip := c.ClientIP()
+	userAgent := util.GetUserAgent(c.Request)
+
+	// Suspended accounts can prove who they are but not sign in
+	suspendedAt, reason, err := repository.FindUserSuspension(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return
+	}
+	if suspendedAt != nil {
+		message := "账号已被停用，如有疑问请联系管理员"
+		if reason != nil && *reason != "" {
+			message = "账号已被停用：" + *reason
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": message, "accountSuspended": true})
+		return
+	}
 
-	// Generate token
-	token, _ := util.GenerateJWT(user.ID, user.Email, user.Role, user.Name)
+	tokens, err := service.IssueSession(ctx, user, ip, userAgent)
+	if err != nil {
+		util.Log("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return
+	}
+
+	service.RecordLoginSuccess(ctx, user, ip, userAgent, method)
+	recordAuditFor(c, user, model.AuditActionLogin, map[string]any{
+		"method":    method,
+		"sessionId": tokens.SessionID,
+	})
 
 	message := "登录成功"
 	emailVerified := user.EmailVerified
@@ -163,20 +331,111 @@ func Login(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":           message,
-		"token":             token,
+		"message":      message,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
 		"user": gin.H{
-			"id":           user.ID,
-			"email":        user.Email,
-			"name":         user.Name,
-			"role":         user.Role,
-			"avatarUrl":    user.AvatarURL,
-			"emailVerified": emailVerified,
+			"id":                   user.ID,
+			"email":                user.Email,
+			"name":                 user.Name,
+			"role":                 user.Role,
+			"avatarUrl":            user.AvatarURL,
+			"emailVerified":        emailVerified,
+			"verificationDeadline": verificationDeadline(user),
 		},
-		"requiresVerification": !emailVerified,
+		"requiresVerification":   !emailVerified,
+		"twoFactorSetupRequired": service.IsTwoFactorRequired(user) && !twoFactorEnabled,
+	})
+}
+
+// GetOIDCConfig - GET /api/auth/oidc/config
+func GetOIDCConfig(c *gin.Context) {
+	cfg := config.Get()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":      service.GetOIDCService() != nil,
+		"providerName": cfg.OIDC.ProviderName,
 	})
 }
 
+// oidcStateCookie binds an OIDC login to the browser that started it, so a
+// callback carrying someone else's code and state is rejected
+const (
+	oidcStateCookie     = "picshare_oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, oidcStateCookiePath, "", config.IsProduction(), true)
+}
+
+// checkOIDCState clears the state cookie and reports whether it matches the
+// state the identity provider returned
+func checkOIDCState(c *gin.Context, state string) bool {
+	cookie, err := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+	if err != nil || cookie == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) == 1
+}
+
+// BeginOIDCLogin - POST /api/auth/oidc/begin
+func BeginOIDCLogin(c *gin.Context) {
+	oidcService := service.GetOIDCService()
+	if oidcService == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+
+	ctx := context.Background()
+	authURL, state, err := oidcService.BeginLogin(ctx)
+	if err != nil {
+		util.Log("Failed to start OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "无法连接身份提供方，请稍后重试"})
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(service.OIDCLoginTTL.Seconds()))
+	c.JSON(http.StatusOK, gin.H{"authUrl": authURL})
+}
+
+// OIDCCallback - POST /api/auth/oidc/callback
+func OIDCCallback(c *gin.Context) {
+	oidcService := service.GetOIDCService()
+	if oidcService == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+
+	var req oidcCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的登录回调"})
+		return
+	}
+
+	if !checkOIDCState(c, req.State) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已超时，请在同一浏览器中重新登录"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := oidcService.FinishLogin(ctx, req.Code, req.State)
+	if err != nil {
+		var oidcErr *service.OIDCError
+		if errors.As(err, &oidcErr) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": oidcErr.Message})
+			return
+		}
+		util.Log("OIDC login failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return
+	}
+
+	continueLogin(c, user, model.LoginMethodOIDC)
+}
+
 // VerifyEmail - GET /api/auth/verify-email
 func VerifyEmail(c *gin.Context) {
 	token := c.Query("token")
@@ -300,9 +559,78 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// Sign out everywhere, whoever knew the old password
+	if _, err := repository.RevokeUserSessions(ctx, user.ID, 0, model.SessionRevokedPasswordChange); err != nil {
+		util.Log("Failed to revoke sessions for user %d: %v", user.ID, err)
+	}
+
+	recordAuditFor(c, user, model.AuditActionPasswordReset, nil)
+
 	c.JSON(http.StatusOK, gin.H{"message": "密码重置成功，请使用新密码登录"})
 }
 
+// RequestMagicLink - POST /api/auth/magic-link
+func RequestMagicLink(c *gin.Context) {
+	var req magicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入有效的邮箱地址"})
+		return
+	}
+
+	ctx := context.Background()
+
+	// Always return success to prevent email enumeration
+	user, err := repository.FindUserByEmail(ctx, req.Email)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "如果该邮箱已注册，我们已发送登录链接"})
+		return
+	}
+
+	// Only the hash is stored; the token itself is only in the email
+	loginToken := util.GenerateRandomToken()
+	loginExpires := time.Now().Add(magicLinkTTL)
+
+	if err := repository.SetLoginToken(ctx, user.ID, util.HashToken(loginToken), loginExpires); err != nil {
+		util.Log("Failed to set login token for user %d: %v", user.ID, err)
+		c.JSON(http.StatusOK, gin.H{"message": "如果该邮箱已注册，我们已发送登录链接"})
+		return
+	}
+
+	emailService := service.GetEmailService()
+	if err := emailService.SendMagicLinkEmail(user.Email, user.Name, loginToken, loginExpires); err != nil {
+		util.Log("Failed to send magic link email to %s: %v", user.Email, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "如果该邮箱已注册，我们已发送登录链接"})
+}
+
+// MagicLinkLogin - POST /api/auth/magic-link/verify
+func MagicLinkLogin(c *gin.Context) {
+	var req magicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的登录链接"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.ConsumeLoginToken(ctx, util.HashToken(req.Token))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "登录链接已过期或已使用，请重新获取"})
+		return
+	}
+
+	// Following the link proves the user controls the mailbox
+	if !user.EmailVerified {
+		if err := repository.MarkEmailVerified(ctx, user.ID); err != nil {
+			util.Log("Failed to mark email verified for user %d: %v", user.ID, err)
+		} else {
+			user.EmailVerified = true
+		}
+	}
+
+	continueLogin(c, user, model.LoginMethodMagicLink)
+}
+
 // GetProfile - GET /api/auth/profile
 func GetProfile(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -314,15 +642,36 @@ func GetProfile(c *gin.Context) {
 		return
 	}
 
+	pendingEmail, err := repository.FindPendingEmail(ctx, userID)
+	if err != nil {
+		util.Log("Failed to load pending email for user %d: %v", userID, err)
+	}
+
+	deletionScheduledAt, err := repository.FindAccountDeletion(ctx, userID)
+	if err != nil {
+		util.Log("Failed to load account deletion for user %d: %v", userID, err)
+	}
+
+	// Set while an admin is signed in as this user, so the client can say so
+	var impersonatedBy *int
+	if authUser, ok := middleware.GetAuthUser(c); ok && authUser.ImpersonatedBy != 0 {
+		impersonatedBy = &authUser.ImpersonatedBy
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":           user.ID,
-			"email":        user.Email,
-			"name":         user.Name,
-			"role":         user.Role,
-			"avatarUrl":    user.AvatarURL,
-			"emailVerified": user.EmailVerified,
-			"createdAt":    user.CreatedAt,
+			"id":                   user.ID,
+			"email":                user.Email,
+			"name":                 user.Name,
+			"role":                 user.Role,
+			"avatarUrl":            user.AvatarURL,
+			"emailVerified":        user.EmailVerified,
+			"verificationDeadline": verificationDeadline(user),
+			"pendingEmail":         pendingEmail,
+			"deletionScheduledAt":  deletionScheduledAt,
+			"expiryPolicy":         user.ExpiryPolicy,
+			"createdAt":            user.CreatedAt,
+			"impersonatedBy":       impersonatedBy,
 		},
 	})
 }
@@ -337,6 +686,11 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if req.ExpiryPolicy != nil && !model.IsValidExpiryPolicy(*req.ExpiryPolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的过期策略"})
+		return
+	}
+
 	ctx := context.Background()
 	err := repository.UpdateUser(ctx, userID, req.Name, nil)
 	if err != nil {
@@ -344,9 +698,146 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if req.ExpiryPolicy != nil {
+		err = repository.UpdateUserExpiryPolicy(ctx, userID, *req.ExpiryPolicy)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
 
+// RequestEmailChange - POST /api/auth/change-email
+func RequestEmailChange(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req changeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入有效的邮箱地址和当前密码"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if !util.ComparePassword(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "当前密码错误"})
+		return
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "新邮箱与当前邮箱相同"})
+		return
+	}
+
+	if _, err := repository.FindUserByEmail(ctx, newEmail); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "该邮箱已被注册"})
+		return
+	}
+
+	// Only the hash is stored; the token itself is only in the email
+	changeToken := util.GenerateRandomToken()
+	changeExpires := time.Now().Add(emailChangeTTL)
+
+	if err := repository.SetEmailChange(ctx, userID, newEmail, util.HashToken(changeToken), changeExpires); err != nil {
+		util.Log("Failed to set email change for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更改邮箱失败"})
+		return
+	}
+
+	emailService := service.GetEmailService()
+	if err := emailService.SendEmailChangeConfirmEmail(newEmail, user.Name, changeToken, changeExpires); err != nil {
+		util.Log("Failed to send email change confirmation to %s: %v", newEmail, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "确认邮件发送失败，请稍后重试"})
+		return
+	}
+	if err := emailService.SendEmailChangeNoticeEmail(user.Email, user.Name, newEmail); err != nil {
+		util.Log("Failed to send email change notice to %s: %v", user.Email, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "确认邮件已发送至新邮箱，请在24小时内点击邮件中的链接完成更改",
+		"pendingEmail": newEmail,
+	})
+}
+
+// CancelEmailChange - DELETE /api/auth/change-email
+func CancelEmailChange(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	err := repository.ClearEmailChange(ctx, userID)
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "没有待确认的邮箱更改"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消邮箱更改"})
+}
+
+// ConfirmEmailChange - POST /api/auth/change-email/confirm
+func ConfirmEmailChange(c *gin.Context) {
+	var req confirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的确认链接"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.ConfirmEmailChange(ctx, util.HashToken(req.Token))
+	if err == repository.ErrEmailTaken {
+		c.JSON(http.StatusConflict, gin.H{"error": "该邮箱已被其他账号注册"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "确认链接已过期或无效"})
+		return
+	}
+
+	// Tokens carry the old email, so sign out everywhere
+	if _, err := repository.RevokeUserSessions(ctx, user.ID, 0, model.SessionRevokedEmailChange); err != nil {
+		util.Log("Failed to revoke sessions for user %d: %v", user.ID, err)
+	}
+
+	recordAuditFor(c, user, model.AuditActionEmailChange, nil)
+
+	// Confirmed from a browser signed in to the same account: hand it a fresh session
+	if currentUserID, ok := middleware.GetUserID(c); ok && currentUserID == user.ID {
+		tokens, err := service.IssueSession(ctx, user, c.ClientIP(), util.GetUserAgent(c.Request))
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"message":      "邮箱已更改，其他设备已退出登录",
+				"token":        tokens.AccessToken,
+				"refreshToken": tokens.RefreshToken,
+				"expiresIn":    tokens.ExpiresIn,
+				"user": gin.H{
+					"id":            user.ID,
+					"email":         user.Email,
+					"name":          user.Name,
+					"role":          user.Role,
+					"avatarUrl":     user.AvatarURL,
+					"emailVerified": user.EmailVerified,
+				},
+			})
+			return
+		}
+		util.Log("Failed to create session: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邮箱已更改，请使用新邮箱重新登录"})
+}
+
 // ChangePassword - PUT /api/auth/change-password
 func ChangePassword(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -388,5 +879,26 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "密码修改成功"})
+	// Sign out every session, including this one, and hand this client a fresh session
+	if _, err := repository.RevokeUserSessions(ctx, userID, 0, model.SessionRevokedPasswordChange); err != nil {
+		util.Log("Failed to revoke sessions for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改密码失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionPasswordChange, model.AuditTargetUser, userID, nil)
+
+	tokens, err := service.IssueSession(ctx, user, c.ClientIP(), util.GetUserAgent(c.Request))
+	if err != nil {
+		util.Log("Failed to create session: %v", err)
+		c.JSON(http.StatusOK, gin.H{"message": "密码修改成功，请重新登录"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "密码修改成功，其他设备已退出登录",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
 }
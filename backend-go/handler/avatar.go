@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"picshare/middleware"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UploadAvatar - PUT /api/auth/profile/avatar
+func UploadAvatar(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	fileHeader := middleware.GetUploadedAvatar(c)
+	if fileHeader == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择头像图片"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件读取失败"})
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件读取失败"})
+		return
+	}
+
+	avatars, err := service.GetImageService().GenerateAvatars(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无法识别的图片，请上传 JPG、PNG 或 GIF 图片"})
+		return
+	}
+
+	// Upload every size; the largest is the one stored on the user
+	ossService := service.GetOSSService()
+	fileID := uuid.New().String()
+	var avatarURL string
+	var uploadedKeys []string
+	for _, size := range service.AvatarSizes {
+		url, key, err := ossService.UploadAvatar(ctx, userID, fileID, size, avatars[size])
+		if err != nil {
+			util.Log("Failed to upload avatar for user %d: %v", userID, err)
+			_ = ossService.DeletePhotos(ctx, uploadedKeys)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "头像上传失败"})
+			return
+		}
+		uploadedKeys = append(uploadedKeys, key)
+		if avatarURL == "" {
+			avatarURL = url
+		}
+	}
+
+	if err := repository.UpdateUserAvatar(ctx, userID, &avatarURL); err != nil {
+		_ = ossService.DeletePhotos(ctx, uploadedKeys)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "头像上传失败"})
+		return
+	}
+
+	// The previous avatar is no longer referenced anywhere
+	if user.AvatarURL != nil {
+		if err := service.DeleteAvatarObjects(ctx, *user.AvatarURL); err != nil {
+			util.Log("Failed to delete previous avatar of user %d: %v", userID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "头像已更新",
+		"avatarUrl":  avatarURL,
+		"avatarUrls": service.AvatarURLs(avatarURL),
+	})
+}
+
+// DeleteAvatar - DELETE /api/auth/profile/avatar
+func DeleteAvatar(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if user.AvatarURL == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "头像已移除"})
+		return
+	}
+
+	if err := repository.UpdateUserAvatar(ctx, userID, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除头像失败"})
+		return
+	}
+
+	if err := service.DeleteAvatarObjects(ctx, *user.AvatarURL); err != nil {
+		util.Log("Failed to delete avatar of user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "头像已移除"})
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// albumInviteValidity is how long a collaborator invitation stays valid
+const albumInviteValidity = 7 * 24 * time.Hour
+
+type albumCollaboratorRequest struct {
+	Email            string `json:"email"`
+	CanUpload        bool   `json:"canUpload"`
+	CanDelete        bool   `json:"canDelete"`
+	CanManageSharing bool   `json:"canManageSharing"`
+}
+
+// InviteAlbumCollaborator - POST /api/albums/:id/collaborators
+func InviteAlbumCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req albumCollaboratorRequest
+	c.ShouldBindJSON(&req)
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if !util.ValidateEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入有效的邮箱地址"})
+		return
+	}
+
+	ctx := context.Background()
+
+	album, ok := findAuthorizedAlbum(c, id, service.AlbumActionManage)
+	if !ok {
+		return
+	}
+
+	invitee, err := repository.FindUserByEmail(ctx, req.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该邮箱尚未注册"})
+		return
+	}
+	if invitee.ID == album.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能邀请影集所有者"})
+		return
+	}
+	if existing, err := repository.FindAlbumCollaborator(ctx, album.ID, invitee.ID); err == nil && existing.IsAccepted() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该用户已是协作者"})
+		return
+	}
+
+	token := util.GenerateRandomToken()
+	tokenHash := util.HashToken(token)
+	expiresAt := time.Now().Add(albumInviteValidity)
+	collaborator := &model.AlbumCollaborator{
+		AlbumID:          album.ID,
+		UserID:           invitee.ID,
+		CanUpload:        req.CanUpload,
+		CanDelete:        req.CanDelete,
+		CanManageSharing: req.CanManageSharing,
+		InvitedBy:        userID,
+		TokenHash:        &tokenHash,
+		ExpiresAt:        &expiresAt,
+		Name:             invitee.Name,
+		Email:            invitee.Email,
+		AlbumTitle:       album.Title,
+	}
+
+	if err := repository.InviteAlbumCollaborator(ctx, collaborator); err != nil {
+		util.Log("Failed to invite collaborator to album %d: %v", album.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发送邀请失败"})
+		return
+	}
+
+	inviterName := ""
+	if inviter, err := repository.FindUserByID(ctx, userID); err == nil {
+		inviterName = inviter.Name
+	}
+	emailService := service.GetEmailService()
+	if err := emailService.SendAlbumCollaboratorInviteEmail(invitee.Email, invitee.Name, inviterName, album.Title, collaboratorPermissionNames(collaborator), token, expiresAt); err != nil {
+		util.Log("Failed to send collaborator invite to %s: %v", invitee.Email, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "邀请已发送",
+		"collaborator": collaborator,
+	})
+}
+
+// GetAlbumCollaborators - GET /api/albums/:id/collaborators
+func GetAlbumCollaborators(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	if _, ok := findAuthorizedAlbum(c, id, service.AlbumActionView); !ok {
+		return
+	}
+
+	ctx := context.Background()
+	collaborators, err := repository.GetAlbumCollaborators(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取协作者列表失败"})
+		return
+	}
+	if collaborators == nil {
+		collaborators = []model.AlbumCollaborator{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collaborators": collaborators})
+}
+
+// UpdateAlbumCollaborator - PUT /api/albums/:id/collaborators/:userId
+func UpdateAlbumCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+	collaboratorID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req albumCollaboratorRequest
+	c.ShouldBindJSON(&req)
+
+	if _, ok := findAuthorizedAlbum(c, id, service.AlbumActionManage); !ok {
+		return
+	}
+
+	ctx := context.Background()
+	err = repository.UpdateAlbumCollaboratorPermissions(ctx, &model.AlbumCollaborator{
+		AlbumID:          id,
+		UserID:           collaboratorID,
+		CanUpload:        req.CanUpload,
+		CanDelete:        req.CanDelete,
+		CanManageSharing: req.CanManageSharing,
+	})
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "协作者不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新协作者权限失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "协作者权限已更新"})
+}
+
+// RemoveAlbumCollaborator - DELETE /api/albums/:id/collaborators/:userId
+// Album managers may remove anyone; collaborators may remove themselves
+func RemoveAlbumCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+	collaboratorID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	action := service.AlbumActionManage
+	if collaboratorID == userID {
+		action = service.AlbumActionView
+	}
+	if _, ok := findAuthorizedAlbum(c, id, action); !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := repository.DeleteAlbumCollaborator(ctx, id, collaboratorID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除协作者失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "协作者已移除"})
+}
+
+// GetSharedAlbums - GET /api/albums/shared
+// Albums the current user collaborates on
+func GetSharedAlbums(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	albums, err := repository.GetCollaboratingAlbums(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取影集列表失败"})
+		return
+	}
+
+	items := make([]gin.H, len(albums))
+	for i, a := range albums {
+		items[i] = formatAlbumListItem(a)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"albums": items})
+}
+
+// AcceptAlbumInvite - POST /api/album-invites/accept
+// Accepts a collaborator invitation from the link in the invite email
+func AcceptAlbumInvite(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的链接"})
+		return
+	}
+
+	ctx := context.Background()
+	collaborator, err := repository.FindAlbumCollaboratorByTokenHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邀请已过期或无效"})
+		return
+	}
+	if collaborator.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "此邀请发送给了其他账号，请使用对应账号登录"})
+		return
+	}
+
+	if err := repository.AcceptAlbumCollaborator(ctx, collaborator.AlbumID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邀请已过期或无效"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "已加入影集协作",
+		"albumId":    collaborator.AlbumID,
+		"albumTitle": collaborator.AlbumTitle,
+	})
+}
+
+// collaboratorPermissionNames lists a collaborator's permissions for emails
+func collaboratorPermissionNames(c *model.AlbumCollaborator) []string {
+	names := []string{"查看照片"}
+	if c.CanUpload {
+		names = append(names, "上传照片")
+	}
+	if c.CanDelete {
+		names = append(names, "删除照片")
+	}
+	if c.CanManageSharing {
+		names = append(names, "管理分享有效期")
+	}
+	return names
+}
@@ -0,0 +1,508 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"picshare/config"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createCollectionRequest struct {
+	Title          string     `json:"title"`
+	Description    *string    `json:"description"`
+	AlbumIDs       []int      `json:"albumIds"`
+	CoverAlbumID   *int       `json:"coverAlbumId"`
+	ExpiresInHours *int       `json:"expiresInHours"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	NeverExpires   bool       `json:"neverExpires"`
+}
+
+type updateCollectionRequest struct {
+	Title          *string    `json:"title"`
+	Description    *string    `json:"description"`
+	AlbumIDs       []int      `json:"albumIds"`
+	CoverAlbumID   *int       `json:"coverAlbumId"`
+	ExpiresInHours *int       `json:"expiresInHours"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	NeverExpires   bool       `json:"neverExpires"`
+}
+
+// maxAlbumsPerCollection limits how many albums one collection can group
+const maxAlbumsPerCollection = 50
+
+// CreateCollection - POST /api/collections
+func CreateCollection(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req createCollectionRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+
+	albumIDs, errMsg := validateCollectionAlbums(ctx, req.AlbumIDs, userID)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+	if len(albumIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择至少一个影集"})
+		return
+	}
+
+	title := req.Title
+	if title == "" {
+		title = util.GenerateAlbumTitle()
+	}
+
+	// Calculate expiry
+	expiresAt := util.GetDefaultExpiry()
+	expiryReq := service.AlbumExpiryRequest{
+		ExpiresInHours: req.ExpiresInHours,
+		ExpiresAt:      req.ExpiresAt,
+		NeverExpires:   req.NeverExpires,
+	}
+	if !expiryReq.IsEmpty() {
+		var err error
+		expiresAt, _, err = service.ResolveAlbumExpiry(time.Time{}, expiryReq, middleware.IsAdmin(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Cover defaults to the first album's cover
+	coverAlbumID := albumIDs[0]
+	if req.CoverAlbumID != nil {
+		coverAlbumID = *req.CoverAlbumID
+	}
+	coverURL, errMsg := collectionCoverURL(ctx, albumIDs, coverAlbumID, userID)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	collection := &model.Collection{
+		UserID:      userID,
+		Title:       title,
+		ShareCode:   util.GenerateShareCode(),
+		Description: req.Description,
+		CoverURL:    coverURL,
+		ExpiresAt:   expiresAt,
+	}
+
+	err := repository.CreateCollection(ctx, collection, albumIDs)
+	if err != nil {
+		util.Log("Failed to create collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建合集失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "合集创建成功",
+		"collection": formatCollection(collection),
+	})
+}
+
+// GetMyCollections - GET /api/collections
+func GetMyCollections(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	page, limit := util.ParsePagination(c.Query("page"), c.Query("limit"))
+
+	ctx := context.Background()
+	collections, total, err := repository.GetCollectionsByUser(ctx, userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取合集列表失败"})
+		return
+	}
+
+	formatted := make([]gin.H, len(collections))
+	for i := range collections {
+		formatted[i] = formatCollection(&collections[i])
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, gin.H{
+		"collections": formatted,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+		},
+	})
+}
+
+// GetCollectionDetail - GET /api/collections/:id
+func GetCollectionDetail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的合集ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+
+	collection, err := repository.FindCollectionByIDWithUser(ctx, id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "合集不存在"})
+		return
+	}
+
+	albums, _ := repository.GetCollectionAlbums(ctx, id)
+
+	now := time.Now()
+	albumList := make([]gin.H, len(albums))
+	for i, a := range albums {
+		albumList[i] = gin.H{
+			"id":         a.ID,
+			"title":      a.Title,
+			"coverUrl":   a.CoverURL,
+			"photoCount": a.PhotoCount,
+			"expiresAt":  a.ExpiresAt,
+			"isExpired":  a.IsExpired || a.ExpiresAt.Before(now),
+			"isArchived": a.IsArchived(),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": formatCollection(collection),
+		"albums":     albumList,
+	})
+}
+
+// UpdateCollection - PUT /api/collections/:id
+func UpdateCollection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的合集ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req updateCollectionRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+
+	collection, err := repository.FindCollectionByIDWithUser(ctx, id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "合集不存在"})
+		return
+	}
+
+	var albumIDs []int
+	if req.AlbumIDs != nil {
+		var errMsg string
+		albumIDs, errMsg = validateCollectionAlbums(ctx, req.AlbumIDs, userID)
+		if errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+		if len(albumIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请选择至少一个影集"})
+			return
+		}
+	}
+
+	var expiresAt *time.Time
+	expiryReq := service.AlbumExpiryRequest{
+		ExpiresInHours: req.ExpiresInHours,
+		ExpiresAt:      req.ExpiresAt,
+		NeverExpires:   req.NeverExpires,
+	}
+	if !expiryReq.IsEmpty() {
+		t, _, err := service.ResolveAlbumExpiry(collection.ExpiresAt, expiryReq, middleware.IsAdmin(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		expiresAt = &t
+	}
+
+	var coverURL *string
+	if req.CoverAlbumID != nil {
+		members := albumIDs
+		if members == nil {
+			albums, _ := repository.GetCollectionAlbums(ctx, id)
+			for _, a := range albums {
+				members = append(members, a.ID)
+			}
+		}
+		var errMsg string
+		coverURL, errMsg = collectionCoverURL(ctx, members, *req.CoverAlbumID, userID)
+		if errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+	}
+
+	if req.Title == nil && req.Description == nil && albumIDs == nil && expiresAt == nil && coverURL == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "没有需要更新的内容"})
+		return
+	}
+
+	if albumIDs != nil {
+		if err := repository.SetCollectionAlbums(ctx, id, albumIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新合集失败"})
+			return
+		}
+	}
+
+	err = repository.UpdateCollection(ctx, id, userID, req.Title, req.Description, coverURL, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新合集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "合集已更新"})
+}
+
+// DeleteCollection - DELETE /api/collections/:id
+func DeleteCollection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的合集ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+
+	if _, err := repository.FindCollectionByIDWithUser(ctx, id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "合集不存在"})
+		return
+	}
+
+	// Member albums are kept, only the grouping is removed
+	if err := repository.DeleteCollection(ctx, id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除合集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "合集已删除"})
+}
+
+// ViewCollectionByShareCode - GET /api/c/:shareCode
+func ViewCollectionByShareCode(c *gin.Context) {
+	ctx := context.Background()
+
+	collection, ok := findPublicCollection(ctx, c)
+	if !ok {
+		return
+	}
+
+	user, _ := repository.FindUserByID(ctx, collection.UserID)
+	photographerName := ""
+	if user != nil {
+		photographerName = user.Name
+	}
+
+	albums, _ := repository.GetCollectionAlbums(ctx, collection.ID)
+
+	albumIDs := make([]int, len(albums))
+	for i, a := range albums {
+		albumIDs[i] = a.ID
+	}
+	takenDown, err := repository.GetTakenDownAlbumIDs(ctx, albumIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取合集失败"})
+		return
+	}
+
+	// Only active albums are listed, and never with their own share codes
+	now := time.Now()
+	publicAlbums := make([]gin.H, 0, len(albums))
+	for _, a := range albums {
+		if a.IsExpired || a.ExpiresAt.Before(now) || takenDown[a.ID] {
+			continue
+		}
+		publicAlbums = append(publicAlbums, gin.H{
+			"id":          a.ID,
+			"title":       a.Title,
+			"description": a.Description,
+			"coverUrl":    a.CoverURL,
+			"photoCount":  a.PhotoCount,
+			"createdAt":   a.CreatedAt,
+		})
+	}
+
+	_ = repository.IncrementCollectionViewCount(ctx, collection.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": gin.H{
+			"id":               collection.ID,
+			"title":            collection.Title,
+			"description":      collection.Description,
+			"coverUrl":         collection.CoverURL,
+			"photographerName": photographerName,
+			"expiresAt":        collection.ExpiresAt,
+			"neverExpires":     util.IsNeverExpires(collection.ExpiresAt),
+			"createdAt":        collection.CreatedAt,
+		},
+		"albums": publicAlbums,
+	})
+}
+
+// ViewCollectionAlbum - GET /api/c/:shareCode/albums/:albumId
+func ViewCollectionAlbum(c *gin.Context) {
+	ctx := context.Background()
+
+	album, ok := findPublicCollectionAlbum(ctx, c)
+	if !ok {
+		return
+	}
+
+	respondPublicAlbum(ctx, c, album)
+}
+
+// DownloadCollectionPhoto - GET /api/c/:shareCode/albums/:albumId/photos/:photoId/download
+func DownloadCollectionPhoto(c *gin.Context) {
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的照片ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	album, ok := findPublicCollectionAlbum(ctx, c)
+	if !ok {
+		return
+	}
+
+	respondPublicDownload(ctx, c, album, photoID)
+}
+
+// findPublicCollection loads an active collection from the share code in the path
+func findPublicCollection(ctx context.Context, c *gin.Context) (*model.Collection, bool) {
+	shareCode := c.Param("shareCode")
+	if shareCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分享链接"})
+		return nil, false
+	}
+
+	collection, err := repository.FindCollectionByShareCode(ctx, shareCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "合集不存在或已被删除"})
+		return nil, false
+	}
+
+	if collection.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "合集已过期"})
+		return nil, false
+	}
+
+	return collection, true
+}
+
+// findPublicCollectionAlbum loads an active member album of a public collection
+func findPublicCollectionAlbum(ctx context.Context, c *gin.Context) (*model.Album, bool) {
+	albumID, err := strconv.Atoi(c.Param("albumId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return nil, false
+	}
+
+	collection, ok := findPublicCollection(ctx, c)
+	if !ok {
+		return nil, false
+	}
+
+	album, err := repository.FindCollectionAlbum(ctx, collection.ID, albumID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在或已被删除"})
+		return nil, false
+	}
+
+	if album.IsExpired || album.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "影集已过期"})
+		return nil, false
+	}
+
+	if respondIfTakenDown(ctx, c, album) {
+		return nil, false
+	}
+
+	return album, true
+}
+
+// validateCollectionAlbums de-duplicates album IDs and checks they are all
+// personal albums of the user
+func validateCollectionAlbums(ctx context.Context, albumIDs []int, userID int) ([]int, string) {
+	seen := make(map[int]bool)
+	unique := make([]int, 0, len(albumIDs))
+	for _, id := range albumIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	if len(unique) > maxAlbumsPerCollection {
+		return nil, fmt.Sprintf("每个合集最多包含 %d 个影集", maxAlbumsPerCollection)
+	}
+	if len(unique) == 0 {
+		return unique, ""
+	}
+
+	count, err := repository.CountAlbumsOwnedByUser(ctx, unique, userID)
+	if err != nil || count != len(unique) {
+		return nil, "只能添加自己的个人影集，工作区影集不能加入合集"
+	}
+
+	return unique, ""
+}
+
+// collectionCoverURL returns the cover of the chosen member album
+func collectionCoverURL(ctx context.Context, albumIDs []int, coverAlbumID, userID int) (*string, string) {
+	member := false
+	for _, id := range albumIDs {
+		if id == coverAlbumID {
+			member = true
+			break
+		}
+	}
+	if !member {
+		return nil, "封面影集必须属于该合集"
+	}
+
+	album, err := repository.FindAlbumByIDWithUser(ctx, coverAlbumID, userID)
+	if err != nil {
+		return nil, "影集不存在"
+	}
+	return album.CoverURL, ""
+}
+
+// formatCollection builds the owner view of a collection
+func formatCollection(collection *model.Collection) gin.H {
+	cfg := config.Get()
+	return gin.H{
+		"id":           collection.ID,
+		"title":        collection.Title,
+		"shareCode":    collection.ShareCode,
+		"description":  collection.Description,
+		"coverUrl":     collection.CoverURL,
+		"albumCount":   collection.AlbumCount,
+		"viewCount":    collection.ViewCount,
+		"expiresAt":    collection.ExpiresAt,
+		"neverExpires": util.IsNeverExpires(collection.ExpiresAt),
+		"isExpired":    collection.ExpiresAt.Before(time.Now()),
+		"createdAt":    collection.CreatedAt,
+		"shareUrl":     fmt.Sprintf("%s/c/%s", cfg.Frontend.URL, collection.ShareCode),
+	}
+}
@@ -2,12 +2,14 @@ package handler
 
 import (
 	"context"
+	"mime/multipart"
 	"net/http"
 	"picshare/middleware"
+	"picshare/model"
 	"picshare/service"
+	"picshare/util"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // SubmitFeedback - POST /api/feedback
@@ -32,45 +34,29 @@ func SubmitFeedback(c *gin.Context) {
 		return
 	}
 
-	// Get user info if authenticated
-	userName := ""
+	feedback := &model.Feedback{Content: content}
+	if contact != "" {
+		feedback.Contact = &contact
+	}
 	if user, ok := middleware.GetAuthUser(c); ok {
-		userName = user.Name
+		feedback.UserID = &user.ID
+		feedback.UserName = &user.Name
 	}
-
-	// Handle uploaded images
-	var imageUrls []string
-	form, _ := c.MultipartForm()
-	if form != nil && form.File != nil {
-		files := form.File["images"]
-		if len(files) > 5 {
-			files = files[:5]
-		}
-
-		ossService := service.GetOSSService()
-		ctx := context.Background()
-
-		for _, fileHeader := range files {
-			file, err := fileHeader.Open()
-			if err != nil {
-				continue
-			}
-
-			fileID := uuid.New().String()
-			imageURL, _, err := ossService.UploadFeedbackImage(ctx, fileID, file, fileHeader)
-			file.Close()
-
-			if err != nil {
-				continue
-			}
-
-			imageUrls = append(imageUrls, imageURL)
-		}
+	ip := c.ClientIP()
+	userAgent := util.GetUserAgent(c.Request)
+	feedback.IPAddress = &ip
+	feedback.UserAgent = &userAgent
+
+	var files []*multipart.FileHeader
+	if form, _ := c.MultipartForm(); form != nil && form.File != nil {
+		files = form.File["images"]
 	}
 
-	// Send feedback email
-	emailService := service.GetEmailService()
-	_ = emailService.SendFeedbackEmail("", userName, content, contact, imageUrls)
+	ctx := context.Background()
+	if err := service.SubmitFeedback(ctx, feedback, files); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交反馈失败，请稍后重试"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "反馈已提交，感谢您的建议！"})
 }
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type moderationReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+type reviewReportRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func parseAlbumPhotoParams(c *gin.Context) (int, int, bool) {
+	albumID, err := strconv.Atoi(c.Param("albumId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return 0, 0, false
+	}
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的照片ID"})
+		return 0, 0, false
+	}
+	return albumID, photoID, true
+}
+
+// TakedownAlbum - POST /api/admin/albums/:albumId/takedown
+func TakedownAlbum(c *gin.Context) {
+	albumID, err := strconv.Atoi(c.Param("albumId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	var req moderationReasonRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+	if err := service.TakedownAlbum(ctx, adminActor(c), albumID, req.Reason); err != nil {
+		respondAdminError(c, err, "下架影集失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "影集已下架，已通知影集所有者"})
+}
+
+// RestoreAlbum - DELETE /api/admin/albums/:albumId/takedown
+func RestoreAlbum(c *gin.Context) {
+	albumID, err := strconv.Atoi(c.Param("albumId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.RestoreAlbum(ctx, adminActor(c), albumID); err != nil {
+		respondAdminError(c, err, "恢复影集失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "影集已恢复公开访问"})
+}
+
+// GetModerationAlbumPhotos - GET /api/admin/albums/:albumId/photos
+func GetModerationAlbumPhotos(c *gin.Context) {
+	albumID, err := strconv.Atoi(c.Param("albumId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	ctx := context.Background()
+
+	album, err := repository.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+		return
+	}
+	photos, err := repository.GetPhotosByAlbum(ctx, album.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取照片失败"})
+		return
+	}
+	if photos == nil {
+		photos = []model.Photo{}
+	}
+	takedown, _ := repository.FindAlbumTakedown(ctx, album.ID)
+
+	recordAudit(c, model.AuditActionViewAlbumPhotos, model.AuditTargetAlbum, album.ID, map[string]any{
+		"ownerId": album.UserID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"album": gin.H{
+			"id":         album.ID,
+			"title":      album.Title,
+			"shareCode":  album.ShareCode,
+			"photoCount": album.PhotoCount,
+			"takedown":   takedown,
+		},
+		"photos": photos,
+	})
+}
+
+// HidePhoto - POST /api/admin/albums/:albumId/photos/:photoId/hide
+func HidePhoto(c *gin.Context) {
+	albumID, photoID, ok := parseAlbumPhotoParams(c)
+	if !ok {
+		return
+	}
+
+	var req moderationReasonRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+	if err := service.HidePhoto(ctx, adminActor(c), albumID, photoID, req.Reason); err != nil {
+		respondAdminError(c, err, "隐藏照片失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "照片已隐藏"})
+}
+
+// UnhidePhoto - POST /api/admin/albums/:albumId/photos/:photoId/unhide
+func UnhidePhoto(c *gin.Context) {
+	albumID, photoID, ok := parseAlbumPhotoParams(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.UnhidePhoto(ctx, adminActor(c), albumID, photoID); err != nil {
+		respondAdminError(c, err, "取消隐藏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "照片已恢复显示"})
+}
+
+// AdminDeletePhoto - DELETE /api/admin/albums/:albumId/photos/:photoId
+func AdminDeletePhoto(c *gin.Context) {
+	albumID, photoID, ok := parseAlbumPhotoParams(c)
+	if !ok {
+		return
+	}
+
+	var req moderationReasonRequest
+	c.ShouldBindJSON(&req)
+
+	ctx := context.Background()
+	if err := service.AdminDeletePhoto(ctx, adminActor(c), albumID, photoID, req.Reason); err != nil {
+		respondAdminError(c, err, "删除照片失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "照片已删除"})
+}
+
+// GetAlbumReports - GET /api/admin/reports
+func GetAlbumReports(c *gin.Context) {
+	page, limit := util.ParsePagination(c.Query("page"), c.Query("limit"))
+	filter := repository.AlbumReportFilter{
+		Status:   c.DefaultQuery("status", model.ReportStatusOpen),
+		Category: c.Query("category"),
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+	filter.AlbumID, _ = strconv.Atoi(c.Query("albumId"))
+
+	ctx := context.Background()
+	reports, total, err := repository.GetAlbumReports(ctx, page, limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取举报列表失败"})
+		return
+	}
+	if reports == nil {
+		reports = []model.AlbumReport{}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+		},
+	})
+}
+
+// ReviewAlbumReport - PUT /api/admin/reports/:reportId
+func ReviewAlbumReport(c *gin.Context) {
+	reportID, err := strconv.Atoi(c.Param("reportId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的举报ID"})
+		return
+	}
+
+	var req reviewReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择处理状态"})
+		return
+	}
+
+	ctx := context.Background()
+	if err := service.ReviewReport(ctx, adminActor(c), reportID, req.Status); err != nil {
+		respondAdminError(c, err, "处理举报失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "举报已更新", "status": req.Status})
+}
+
+// GetReportedAlbums - GET /api/admin/reports/albums
+func GetReportedAlbums(c *gin.Context) {
+	page, limit := util.ParsePagination(c.Query("page"), c.Query("limit"))
+	openOnly := c.Query("status") != "all"
+
+	ctx := context.Background()
+	albums, total, err := repository.GetAlbumReportSummaries(ctx, page, limit, openOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取举报统计失败"})
+		return
+	}
+	if albums == nil {
+		albums = []model.AlbumReportSummary{}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"albums":            albums,
+		"autoHideThreshold": config.Get().Moderation.AutoHideThreshold,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+		},
+	})
+}
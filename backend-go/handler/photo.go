@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"picshare/config"
 	"picshare/middleware"
 	"picshare/model"
 	"picshare/repository"
@@ -18,11 +17,11 @@ import (
 	"github.com/google/uuid"
 )
 
-// UploadPhotos - POST /api/albums/:albumId/photos
+// UploadPhotos - POST /api/albums/:id/photos
 func UploadPhotos(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
 
-	albumIdStr := c.Param("albumId")
+	albumIdStr := c.Param("id")
 	albumID, err := strconv.Atoi(albumIdStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
@@ -37,10 +36,9 @@ func UploadPhotos(c *gin.Context) {
 
 	ctx := context.Background()
 
-	// Verify ownership and check if expired
-	album, err := repository.FindAlbumByIDWithUser(ctx, albumID, userID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+	// Verify permission and check if expired
+	album, ok := findAuthorizedAlbum(c, albumID, service.AlbumActionUpload)
+	if !ok {
 		return
 	}
 
@@ -50,10 +48,10 @@ func UploadPhotos(c *gin.Context) {
 		return
 	}
 
-	// Check photo limit
+	// Check photo limit, which like the storage quota is the album owner's
 	count, _ := repository.CountPhotosInAlbum(ctx, albumID)
-	cfg := config.Get()
-	maxPhotos := cfg.Upload.MaxPhotosPerAlbum
+	limits := service.GetUserLimits(ctx, album.UserID)
+	maxPhotos := limits.MaxPhotosPerAlbum
 
 	if count >= maxPhotos {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("影集最多只能包含 %d 张照片", maxPhotos)})
@@ -66,6 +64,23 @@ func UploadPhotos(c *gin.Context) {
 		return
 	}
 
+	if limits.MaxStorage > 0 {
+		var incoming int64
+		for _, fileHeader := range files {
+			incoming += fileHeader.Size
+		}
+		used, err := repository.GetStorageUsedByUser(ctx, album.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "上传失败"})
+			return
+		}
+		if used+incoming > limits.MaxStorage {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("存储空间不足，上限 %s，已使用 %s",
+				util.FormatFileSize(limits.MaxStorage), util.FormatFileSize(used))})
+			return
+		}
+	}
+
 	ossService := service.GetOSSService()
 	imageService := service.GetImageService()
 
@@ -100,9 +115,9 @@ func UploadPhotos(c *gin.Context) {
 		// Seek back to start
 		file.Seek(0, 0)
 
-		// Upload to OSS
+		// Upload to OSS under the album owner's prefix
 		originalURL, thumbnailURL, ossKey, thumbOSSKey, err := ossService.UploadPhoto(
-			ctx, userID, albumID, fileID, file, fileHeader, thumbnailBuffer,
+			ctx, album.UserID, albumID, fileID, file, fileHeader, thumbnailBuffer,
 			ext, width, height, fileHeader.Size,
 		)
 		file.Close()
@@ -121,16 +136,17 @@ func UploadPhotos(c *gin.Context) {
 
 		photo := &model.Photo{
 			AlbumID:         albumID,
-			UserID:           userID,
-			OriginalName:     fileHeader.Filename,
-			OriginalURL:      originalURL,
-			ThumbnailURL:     thumbnailURL,
-			OSSKey:           ossKey,
-			ThumbnailOSSKey:  thumbOSSKey,
-			FileSize:         fileHeader.Size,
-			Width:            width,
-			Height:           height,
-			MimeType:         mimeType,
+			UserID:          album.UserID,
+			OriginalName:    fileHeader.Filename,
+			OriginalURL:     originalURL,
+			ThumbnailURL:    thumbnailURL,
+			OSSKey:          ossKey,
+			ThumbnailOSSKey: thumbOSSKey,
+			FileSize:        fileHeader.Size,
+			Width:           width,
+			Height:          height,
+			MimeType:        mimeType,
+			UploadedBy:      &userID,
 		}
 
 		err = repository.CreatePhoto(ctx, photo)
@@ -155,6 +171,10 @@ func UploadPhotos(c *gin.Context) {
 	if len(uploadedPhotos) > 0 {
 		repository.IncrementAlbumPhotoCount(ctx, albumID, len(uploadedPhotos))
 
+		recordAudit(c, model.AuditActionPhotoUpload, model.AuditTargetAlbum, albumID, map[string]any{
+			"count": len(uploadedPhotos),
+		})
+
 		// Set cover if not set
 		if album.CoverURL == nil {
 			coverURL := uploadedPhotos[0]["thumbnailUrl"].(string)
@@ -169,11 +189,9 @@ func UploadPhotos(c *gin.Context) {
 	})
 }
 
-// DeletePhoto - DELETE /api/albums/:albumId/photos/:photoId
+// DeletePhoto - DELETE /api/albums/:id/photos/:photoId
 func DeletePhoto(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
-
-	albumIdStr := c.Param("albumId")
+	albumIdStr := c.Param("id")
 	albumID, err := strconv.Atoi(albumIdStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
@@ -189,15 +207,21 @@ func DeletePhoto(c *gin.Context) {
 
 	ctx := context.Background()
 
-	// Verify ownership
+	// Verify permission
+	album, ok := findAuthorizedAlbum(c, albumID, service.AlbumActionDeletePhotos)
+	if !ok {
+		return
+	}
+
 	photo, err := repository.FindPhotoByIDAndAlbum(ctx, photoID, albumID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "照片不存在"})
 		return
 	}
 
-	if photo.UserID != userID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "照片不存在"})
+	// Archived albums are read-only
+	if album.IsArchived() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "影集已归档，无法删除照片"})
 		return
 	}
 
@@ -212,14 +236,17 @@ func DeletePhoto(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, model.AuditActionPhotoDelete, model.AuditTargetPhoto, photoID, map[string]any{
+		"albumId":      albumID,
+		"originalName": photo.OriginalName,
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "照片已删除"})
 }
 
-// GetPhotoOriginal - GET /api/albums/:albumId/photos/:photoId/original
+// GetPhotoOriginal - GET /api/albums/:id/photos/:photoId/original
 func GetPhotoOriginal(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
-
-	albumIdStr := c.Param("albumId")
+	albumIdStr := c.Param("id")
 	albumID, err := strconv.Atoi(albumIdStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
@@ -235,13 +262,12 @@ func GetPhotoOriginal(c *gin.Context) {
 
 	ctx := context.Background()
 
-	photo, err := repository.FindPhotoByIDAndAlbum(ctx, photoID, albumID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "照片不存在"})
+	if _, ok := findAuthorizedAlbum(c, albumID, service.AlbumActionView); !ok {
 		return
 	}
 
-	if photo.UserID != userID {
+	photo, err := repository.FindPhotoByIDAndAlbum(ctx, photoID, albumID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "照片不存在"})
 		return
 	}
@@ -2,42 +2,101 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"picshare/middleware"
 	"picshare/model"
 	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxReportReasonLength limits the free-text reason of an abuse report
+const maxReportReasonLength = 1000
+
+type reportAlbumRequest struct {
+	PhotoID  *int   `json:"photoId"` // nil reports the whole album
+	Category string `json:"category" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
 // ViewAlbumByShareCode - GET /api/s/:shareCode
 func ViewAlbumByShareCode(c *gin.Context) {
+	ctx := context.Background()
+
+	album, ok := findPublicAlbum(ctx, c)
+	if !ok {
+		return
+	}
+
+	respondPublicAlbum(ctx, c, album)
+}
+
+// findPublicAlbum loads the album behind a share link, rejecting expired
+// albums and albums an admin has taken down
+func findPublicAlbum(ctx context.Context, c *gin.Context) (*model.Album, bool) {
 	shareCode := c.Param("shareCode")
 	if shareCode == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分享链接"})
-		return
+		return nil, false
 	}
 
-	ctx := context.Background()
-
 	album, err := repository.FindAlbumByShareCode(ctx, shareCode)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在或已被删除"})
-		return
+		return nil, false
 	}
 
 	now := time.Now()
 	if album.IsExpired || album.ExpiresAt.Before(now) {
 		c.JSON(http.StatusGone, gin.H{"error": "影集已过期"})
-		return
+		return nil, false
 	}
 
+	if respondIfTakenDown(ctx, c, album) {
+		return nil, false
+	}
+
+	return album, true
+}
+
+// respondIfTakenDown writes the takedown notice if an admin has disabled
+// public access to the album, returning true if it did
+func respondIfTakenDown(ctx context.Context, c *gin.Context, album *model.Album) bool {
+	takedown, err := repository.FindAlbumTakedown(ctx, album.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取影集失败"})
+		return true
+	}
+	if takedown == nil {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":     "该影集已被管理员下架",
+		"takenDown": true,
+		"reason":    takedown.Reason,
+	})
+	return true
+}
+
+// respondPublicAlbum writes the public view of an album, counting the view
+func respondPublicAlbum(ctx context.Context, c *gin.Context, album *model.Album) {
 	// Get photographer name
 	user, _ := repository.FindUserByID(ctx, album.UserID)
 	photographerName := ""
+	var photographerAvatar map[string]string
 	if user != nil {
 		photographerName = user.Name
+		if user.AvatarURL != nil {
+			photographerAvatar = service.AvatarURLs(*user.AvatarURL)
+		}
 	}
 
 	// Get photos (public view - use thumbnail URLs)
@@ -69,13 +128,15 @@ func ViewAlbumByShareCode(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"album": gin.H{
-			"id":               album.ID,
-			"title":            album.Title,
-			"description":      album.Description,
-			"photographerName": photographerName,
-			"photoCount":       album.PhotoCount,
-			"expiresAt":        album.ExpiresAt,
-			"createdAt":        album.CreatedAt,
+			"id":                 album.ID,
+			"title":              album.Title,
+			"description":        album.Description,
+			"photographerName":   photographerName,
+			"photographerAvatar": photographerAvatar,
+			"photoCount":         album.PhotoCount,
+			"expiresAt":          album.ExpiresAt,
+			"neverExpires":       util.IsNeverExpires(album.ExpiresAt),
+			"createdAt":          album.CreatedAt,
 		},
 		"photos": publicPhotos,
 	})
@@ -83,7 +144,6 @@ func ViewAlbumByShareCode(c *gin.Context) {
 
 // DownloadPhoto - GET /api/s/:shareCode/photos/:photoId/download
 func DownloadPhoto(c *gin.Context) {
-	shareCode := c.Param("shareCode")
 	photoIdStr := c.Param("photoId")
 
 	photoID, err := strconv.Atoi(photoIdStr)
@@ -94,22 +154,19 @@ func DownloadPhoto(c *gin.Context) {
 
 	ctx := context.Background()
 
-	// Find album by share code
-	album, err := repository.FindAlbumByShareCode(ctx, shareCode)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "影集不存在"})
+	album, ok := findPublicAlbum(ctx, c)
+	if !ok {
 		return
 	}
 
-	now := time.Now()
-	if album.IsExpired || album.ExpiresAt.Before(now) {
-		c.JSON(http.StatusGone, gin.H{"error": "影集已过期"})
-		return
-	}
+	respondPublicDownload(ctx, c, album, photoID)
+}
 
+// respondPublicDownload writes the download link of a photo in an album, counting the download
+func respondPublicDownload(ctx context.Context, c *gin.Context, album *model.Album, photoID int) {
 	// Find photo
 	photo, err := repository.FindPhotoByIDAndAlbum(ctx, photoID, album.ID)
-	if err != nil {
+	if err != nil || photo.HiddenAt != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "照片不存在"})
 		return
 	}
@@ -134,3 +191,62 @@ func DownloadPhoto(c *gin.Context) {
 		"fileName":    photo.OriginalName,
 	})
 }
+
+// ReportAlbum - POST /api/s/:shareCode/report
+func ReportAlbum(c *gin.Context) {
+	var req reportAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择举报类型"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if req.Category == model.ReportCategoryOther && reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写举报原因"})
+		return
+	}
+	if utf8.RuneCountInString(reason) > maxReportReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "举报原因不能超过1000个字符"})
+		return
+	}
+
+	ctx := context.Background()
+
+	album, ok := findPublicAlbum(ctx, c)
+	if !ok {
+		return
+	}
+
+	if req.PhotoID != nil {
+		photo, err := repository.FindPhotoByIDAndAlbum(ctx, *req.PhotoID, album.ID)
+		if err != nil || photo.HiddenAt != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "照片不存在"})
+			return
+		}
+	}
+
+	ipAddress := c.ClientIP()
+	userAgent := util.GetUserAgent(c.Request)
+	report := &model.AlbumReport{
+		PhotoID:           req.PhotoID,
+		Category:          req.Category,
+		Reason:            reason,
+		ReporterIP:        &ipAddress,
+		ReporterUserAgent: &userAgent,
+	}
+	if userID, ok := middleware.GetUserID(c); ok {
+		report.ReporterID = &userID
+	}
+	if err := service.SubmitAlbumReport(ctx, album, report); err != nil {
+		var reportErr *service.ReportError
+		if errors.As(err, &reportErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": reportErr.Message})
+			return
+		}
+		util.Log("Failed to store report on album %d: %v", album.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交举报失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "感谢您的举报，我们会尽快处理"})
+}
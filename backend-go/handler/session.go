@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshToken - POST /api/auth/refresh
+func RefreshToken(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少刷新令牌"})
+		return
+	}
+
+	ctx := context.Background()
+	tokens, err := service.RefreshSession(ctx, req.RefreshToken)
+	if err == repository.ErrRefreshTokenReused {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "检测到登录凭证被重复使用，该设备已退出登录，请重新登录"})
+		return
+	}
+	if err == repository.ErrRefreshTokenInvalid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已过期，请重新登录"})
+		return
+	}
+	if err != nil {
+		util.Log("Failed to refresh session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刷新登录状态失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
+}
+
+// Logout - POST /api/auth/logout
+func Logout(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	sessionID, _ := middleware.GetSessionID(c)
+
+	ctx := context.Background()
+	if user, ok := middleware.GetAuthUser(c); ok && user.ImpersonatedBy != 0 {
+		actor := service.AdminActor{
+			ID:        user.ImpersonatedBy,
+			IP:        c.ClientIP(),
+			UserAgent: util.GetUserAgent(c.Request),
+		}
+		if err := service.EndImpersonation(ctx, actor, sessionID, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "退出登录失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "已退出模拟登录"})
+		return
+	}
+
+	err := repository.RevokeSession(ctx, sessionID, userID, model.SessionRevokedLogout)
+	if err != nil && err != repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "退出登录失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionLogout, model.AuditTargetUser, userID, map[string]any{
+		"sessionId": sessionID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
+
+// GetSessions - GET /api/auth/sessions
+func GetSessions(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	sessionID, _ := middleware.GetSessionID(c)
+
+	ctx := context.Background()
+	sessions, err := repository.GetActiveSessionsByUser(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取登录设备失败"})
+		return
+	}
+
+	if sessions == nil {
+		sessions = []model.Session{}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sessionID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession - DELETE /api/auth/sessions/:id
+func RevokeSession(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的会话ID"})
+		return
+	}
+
+	ctx := context.Background()
+	err = repository.RevokeSession(ctx, id, userID, model.SessionRevokedByUser)
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在或已失效"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销会话失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已注销该设备的登录"})
+}
+
+// RevokeOtherSessions - DELETE /api/auth/sessions
+func RevokeOtherSessions(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	sessionID, _ := middleware.GetSessionID(c)
+
+	ctx := context.Background()
+	count, err := repository.RevokeUserSessions(ctx, userID, sessionID, model.SessionRevokedByUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销会话失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已退出其他所有设备",
+		"revoked": count,
+	})
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type albumTemplateRequest struct {
+	Name           string  `json:"name"`
+	Title          *string `json:"title"`
+	Description    *string `json:"description"`
+	ExpiresInHours *int    `json:"expiresInHours"`
+	ExpiryPolicy   *string `json:"expiryPolicy"`
+}
+
+// maxTemplateNameLength limits album template names (in characters)
+const maxTemplateNameLength = 50
+
+// validate normalizes the request and returns a user-facing error message
+func (req *albumTemplateRequest) validate() string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "请输入模板名称"
+	}
+	if utf8.RuneCountInString(req.Name) > maxTemplateNameLength {
+		return fmt.Sprintf("模板名称不能超过 %d 个字符", maxTemplateNameLength)
+	}
+	if req.ExpiresInHours != nil && *req.ExpiresInHours <= 0 {
+		return "有效期必须大于0"
+	}
+	if req.ExpiryPolicy != nil && !model.IsValidExpiryPolicy(*req.ExpiryPolicy) {
+		return "无效的过期策略"
+	}
+	return ""
+}
+
+// CreateAlbumTemplate - POST /api/album-templates
+func CreateAlbumTemplate(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req albumTemplateRequest
+	c.ShouldBindJSON(&req)
+
+	if errMsg := req.validate(); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	ctx := context.Background()
+
+	if _, err := repository.FindAlbumTemplateByName(ctx, userID, req.Name); err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板名称已存在"})
+		return
+	}
+
+	template := &model.AlbumTemplate{
+		UserID:         userID,
+		Name:           req.Name,
+		Title:          req.Title,
+		Description:    req.Description,
+		ExpiresInHours: req.ExpiresInHours,
+		ExpiryPolicy:   req.ExpiryPolicy,
+	}
+
+	err := repository.CreateAlbumTemplate(ctx, template)
+	if err != nil {
+		util.Log("Failed to create album template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "模板创建成功",
+		"template": template,
+	})
+}
+
+// GetMyAlbumTemplates - GET /api/album-templates
+func GetMyAlbumTemplates(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	templates, err := repository.GetAlbumTemplatesByUser(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取模板列表失败"})
+		return
+	}
+	if templates == nil {
+		templates = []model.AlbumTemplate{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// UpdateAlbumTemplate - PUT /api/album-templates/:id
+func UpdateAlbumTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的模板ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req albumTemplateRequest
+	c.ShouldBindJSON(&req)
+
+	if errMsg := req.validate(); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	ctx := context.Background()
+
+	template, err := repository.FindAlbumTemplateByIDWithUser(ctx, id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在"})
+		return
+	}
+
+	if existing, err := repository.FindAlbumTemplateByName(ctx, userID, req.Name); err == nil && existing.ID != id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板名称已存在"})
+		return
+	}
+
+	template.Name = req.Name
+	template.Title = req.Title
+	template.Description = req.Description
+	template.ExpiresInHours = req.ExpiresInHours
+	template.ExpiryPolicy = req.ExpiryPolicy
+
+	if err := repository.UpdateAlbumTemplate(ctx, template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "模板已更新"})
+}
+
+// DeleteAlbumTemplate - DELETE /api/album-templates/:id
+func DeleteAlbumTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的模板ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	if _, err := repository.FindAlbumTemplateByIDWithUser(ctx, id, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在"})
+		return
+	}
+
+	if err := repository.DeleteAlbumTemplate(ctx, id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "模板已删除"})
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// albumTransferValidity is how long a recipient has to accept a transfer
+const albumTransferValidity = 7 * 24 * time.Hour
+
+type albumTransferRequest struct {
+	Email string `json:"email"`
+	// Move the photos under the recipient's storage prefix; defaults to true
+	RekeyStorage *bool `json:"rekeyStorage"`
+}
+
+// InitiateAlbumTransfer - POST /api/albums/:id/transfer
+func InitiateAlbumTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req albumTransferRequest
+	c.ShouldBindJSON(&req)
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if !util.ValidateEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入有效的邮箱地址"})
+		return
+	}
+
+	ctx := context.Background()
+
+	// Only the creator hands an album over, and only while they may manage it
+	album, ok := findAuthorizedAlbum(c, id, service.AlbumActionManage)
+	if !ok {
+		return
+	}
+	if album.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有影集创建者可以转移影集"})
+		return
+	}
+
+	recipient, err := repository.FindUserByEmail(ctx, req.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "接收用户不存在"})
+		return
+	}
+	if recipient.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能将影集转移给自己"})
+		return
+	}
+
+	rekey := req.RekeyStorage == nil || *req.RekeyStorage
+	if rekey && album.IsArchived() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "已归档影集无法迁移存储路径，请先延长有效期以重新激活"})
+		return
+	}
+
+	token := util.GenerateRandomToken()
+	transfer := &model.AlbumTransfer{
+		AlbumID:      album.ID,
+		FromUserID:   userID,
+		ToUserID:     recipient.ID,
+		TokenHash:    util.HashToken(token),
+		RekeyStorage: rekey,
+		ExpiresAt:    time.Now().Add(albumTransferValidity),
+	}
+
+	err = repository.CreateAlbumTransfer(ctx, transfer)
+	if err != nil {
+		util.Log("Failed to create transfer of album %d: %v", album.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发起转移失败"})
+		return
+	}
+
+	sender, _ := repository.FindUserByID(ctx, userID)
+	senderName := ""
+	if sender != nil {
+		senderName = sender.Name
+	}
+	emailService := service.GetEmailService()
+	if err := emailService.SendAlbumTransferEmail(recipient.Email, recipient.Name, senderName, album.Title, token, transfer.ExpiresAt); err != nil {
+		util.Log("Failed to send album transfer email to %s: %v", recipient.Email, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "转移请求已发送，等待对方接收",
+		"transfer": gin.H{
+			"id":           transfer.ID,
+			"albumId":      album.ID,
+			"toEmail":      recipient.Email,
+			"toName":       recipient.Name,
+			"rekeyStorage": transfer.RekeyStorage,
+			"expiresAt":    transfer.ExpiresAt,
+		},
+	})
+}
+
+// CancelAlbumTransfer - DELETE /api/albums/:id/transfer
+func CancelAlbumTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的影集ID"})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	transfer, err := repository.FindPendingAlbumTransfer(ctx, id)
+	if err != nil || transfer.FromUserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "没有待处理的转移请求"})
+		return
+	}
+
+	if err := repository.CloseAlbumTransfer(ctx, transfer.ID, model.TransferStatusCancelled); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "转移请求已失效"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "转移请求已取消"})
+}
+
+// GetMyAlbumTransfers - GET /api/album-transfers
+func GetMyAlbumTransfers(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	transfers, err := repository.GetPendingAlbumTransfersForUser(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取转移请求失败"})
+		return
+	}
+
+	incoming := []model.AlbumTransfer{}
+	outgoing := []model.AlbumTransfer{}
+	for _, t := range transfers {
+		if t.ToUserID == userID {
+			incoming = append(incoming, t)
+		} else {
+			outgoing = append(outgoing, t)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incoming": incoming,
+		"outgoing": outgoing,
+	})
+}
+
+// AcceptAlbumTransfer - POST /api/album-transfers/:id/accept
+func AcceptAlbumTransfer(c *gin.Context) {
+	transfer, ok := findIncomingAlbumTransfer(c)
+	if !ok {
+		return
+	}
+	completeAlbumTransfer(c, transfer)
+}
+
+// DeclineAlbumTransfer - POST /api/album-transfers/:id/decline
+func DeclineAlbumTransfer(c *gin.Context) {
+	transfer, ok := findIncomingAlbumTransfer(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := repository.CloseAlbumTransfer(ctx, transfer.ID, model.TransferStatusDeclined); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "转移请求已失效"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已拒绝转移请求"})
+}
+
+// AcceptAlbumTransferByToken - POST /api/accept-album-transfer
+// Accepts a transfer from the link in the transfer email; the recipient
+// must be signed in to the account the transfer was sent to
+func AcceptAlbumTransferByToken(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的链接"})
+		return
+	}
+
+	ctx := context.Background()
+	transfer, err := repository.FindAlbumTransferByTokenHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "链接已过期或无效"})
+		return
+	}
+	if transfer.ToUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "此转移请求发送给了其他账号，请使用对应账号登录"})
+		return
+	}
+
+	completeAlbumTransfer(c, transfer)
+}
+
+// findIncomingAlbumTransfer loads the transfer in the URL and checks that
+// the current user is its recipient
+func findIncomingAlbumTransfer(c *gin.Context) (*model.AlbumTransfer, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的转移请求ID"})
+		return nil, false
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	transfer, err := repository.FindAlbumTransferByID(ctx, id)
+	if err != nil || transfer.ToUserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "转移请求不存在"})
+		return nil, false
+	}
+
+	return transfer, true
+}
+
+// completeAlbumTransfer accepts a transfer and writes the response
+func completeAlbumTransfer(c *gin.Context, transfer *model.AlbumTransfer) {
+	ctx := context.Background()
+	err := service.AcceptAlbumTransfer(ctx, transfer)
+	if err != nil {
+		var transferErr *service.TransferError
+		if errors.As(err, &transferErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": transferErr.Message})
+			return
+		}
+		util.Log("Failed to transfer album %d: %v", transfer.AlbumID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "接收影集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "影集已转移到您的账户",
+		"albumId": transfer.AlbumID,
+		"title":   transfer.AlbumTitle,
+	})
+}
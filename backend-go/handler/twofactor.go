@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"picshare/config"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+type loginTwoFactorRequest struct {
+	TwoFactorToken string `json:"twoFactorToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type twoFactorPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type disableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// LoginTwoFactor - POST /api/auth/login/2fa
+func LoginTwoFactor(c *gin.Context) {
+	var req loginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	claims, err := util.VerifyTwoFactorToken(req.TwoFactorToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "验证已超时，请重新登录"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "验证已超时，请重新登录"})
+		return
+	}
+
+	// Wrong codes count as failed sign-ins, like wrong passwords
+	attempt := reserveLoginAttempt(c, user.Email)
+	if attempt == nil {
+		return
+	}
+
+	err = service.VerifyTwoFactorCode(ctx, user.ID, req.Code)
+	if err == service.ErrInvalidTwoFactorCode {
+		service.RecordLoginFailure(ctx, attempt, util.GetUserAgent(c.Request))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "验证码错误或已使用"})
+		return
+	}
+	if err != nil {
+		service.ReleaseLoginAttempt(ctx, attempt)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return
+	}
+	service.ReleaseLoginAttempt(ctx, attempt)
+
+	completeLogin(c, user, true, model.LoginMethodTwoFactor)
+}
+
+// GetTwoFactorStatus - GET /api/auth/2fa
+func GetTwoFactorStatus(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	authUser, _ := middleware.GetAuthUser(c)
+
+	ctx := context.Background()
+	enabled, err := repository.IsTwoFactorEnabled(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取两步验证状态失败"})
+		return
+	}
+
+	remaining := 0
+	if enabled {
+		remaining, err = repository.CountUnusedRecoveryCodes(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取两步验证状态失败"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                enabled,
+		"required":               authUser.Role == "admin" && config.Get().TwoFactor.RequireForAdmins,
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// SetupTwoFactor - POST /api/auth/2fa/setup
+func SetupTwoFactor(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req twoFactorPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入当前密码"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if !checkCurrentPassword(c, user, req.Password) {
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "设置两步验证失败"})
+		return
+	}
+
+	err = repository.SaveTOTPSecret(ctx, userID, secret)
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证已启用，如需更换设备请先关闭"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "设置两步验证失败"})
+		return
+	}
+
+	otpauthURL := util.TOTPURI(config.Get().TwoFactor.Issuer, user.Email, secret)
+	qrCode, err := qrcode.Encode(otpauthURL, qrcode.Medium, 256)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成二维码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     secret,
+		"otpauthUrl": otpauthURL,
+		"qrCode":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode),
+	})
+}
+
+// EnableTwoFactor - POST /api/auth/2fa/enable
+func EnableTwoFactor(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	ctx := context.Background()
+	totp, err := repository.FindUserTOTP(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先开始设置两步验证"})
+		return
+	}
+	if totp.IsEnabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证已启用"})
+		return
+	}
+
+	step, ok := util.ValidateTOTP(totp.Secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误，请确认手机时间准确后重试"})
+		return
+	}
+
+	codes, hashes, err := service.NewRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "启用两步验证失败"})
+		return
+	}
+
+	err = repository.EnableTOTP(ctx, userID, step, hashes)
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "两步验证已启用"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "启用两步验证失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionTwoFactorEnable, model.AuditTargetUser, userID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "两步验证已启用，请妥善保存恢复码",
+		"recoveryCodes": codes,
+	})
+}
+
+// DisableTwoFactor - POST /api/auth/2fa/disable
+func DisableTwoFactor(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req disableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入当前密码和验证码"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if service.IsTwoFactorRequired(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "管理员账号必须启用两步验证"})
+		return
+	}
+
+	if !checkCurrentPassword(c, user, req.Password) {
+		return
+	}
+
+	err = service.VerifyTwoFactorCode(ctx, userID, req.Code)
+	if err == service.ErrInvalidTwoFactorCode {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误或已使用"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关闭两步验证失败"})
+		return
+	}
+
+	if err := repository.DisableTOTP(ctx, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关闭两步验证失败"})
+		return
+	}
+
+	recordAudit(c, model.AuditActionTwoFactorDisable, model.AuditTargetUser, userID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已关闭"})
+}
+
+// RegenerateRecoveryCodes - POST /api/auth/2fa/recovery-codes
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	ctx := context.Background()
+	err := service.VerifyTwoFactorCode(ctx, userID, req.Code)
+	if err == service.ErrInvalidTwoFactorCode {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误或已使用"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成恢复码失败"})
+		return
+	}
+
+	codes, hashes, err := service.NewRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成恢复码失败"})
+		return
+	}
+
+	if err := repository.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成恢复码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "已生成新的恢复码，旧恢复码已失效",
+		"recoveryCodes": codes,
+	})
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type beginPasskeyRegistrationRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
+}
+
+type finishPasskeyRegistrationRequest struct {
+	ChallengeToken string          `json:"challengeToken" binding:"required"`
+	Name           string          `json:"name"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+}
+
+type finishPasskeyLoginRequest struct {
+	ChallengeToken string          `json:"challengeToken" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+}
+
+// writePasskeyError writes the response for a failed passkey ceremony
+func writePasskeyError(c *gin.Context, err error, fallback string) {
+	var passkeyErr *service.PasskeyError
+	if errors.As(err, &passkeyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": passkeyErr.Message})
+		return
+	}
+	util.Log("Passkey error: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+// passkeyService returns the passkey service, writing an error if it is not configured
+func passkeyService(c *gin.Context) (*service.WebAuthnService, bool) {
+	svc := service.GetWebAuthnService()
+	if svc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "通行密钥功能暂不可用"})
+		return nil, false
+	}
+	return svc, true
+}
+
+// BeginPasskeyRegistration - POST /api/auth/webauthn/register/begin
+// A passkey signs in without the TOTP step, so adding one requires the
+// password and, when two-factor authentication is on, a current code
+func BeginPasskeyRegistration(c *gin.Context) {
+	svc, ok := passkeyService(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req beginPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入当前密码"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if !checkCurrentPassword(c, user, req.Password) {
+		return
+	}
+
+	twoFactorEnabled, err := repository.IsTwoFactorEnabled(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建通行密钥失败"})
+		return
+	}
+	if twoFactorEnabled {
+		if req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请输入两步验证码", "twoFactorRequired": true})
+			return
+		}
+		err = service.VerifyTwoFactorCode(ctx, userID, req.Code)
+		if err == service.ErrInvalidTwoFactorCode {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误或已使用", "twoFactorRequired": true})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建通行密钥失败"})
+			return
+		}
+	}
+
+	options, challengeToken, err := svc.BeginRegistration(ctx, user)
+	if err != nil {
+		writePasskeyError(c, err, "创建通行密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"options":        options,
+		"challengeToken": challengeToken,
+	})
+}
+
+// FinishPasskeyRegistration - POST /api/auth/webauthn/register/finish
+func FinishPasskeyRegistration(c *gin.Context) {
+	svc, ok := passkeyService(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req finishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "通行密钥数据无效"})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "通行密钥"
+	}
+	if utf8.RuneCountInString(name) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "名称不能超过50个字符"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	credential, err := svc.FinishRegistration(ctx, user, req.ChallengeToken, name, bytes.NewReader(req.Credential))
+	if err != nil {
+		writePasskeyError(c, err, "创建通行密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "通行密钥已添加",
+		"credential": credential,
+	})
+}
+
+// GetPasskeys - GET /api/auth/webauthn/credentials
+func GetPasskeys(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	credentials, err := repository.GetWebAuthnCredentialsByUser(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通行密钥失败"})
+		return
+	}
+
+	if credentials == nil {
+		credentials = []model.WebAuthnCredential{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// DeletePasskey - DELETE /api/auth/webauthn/credentials/:id
+func DeletePasskey(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通行密钥ID"})
+		return
+	}
+
+	ctx := context.Background()
+	err = repository.DeleteWebAuthnCredential(ctx, id, userID)
+	if err == repository.ErrNoRowsUpdated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通行密钥不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除通行密钥失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "通行密钥已删除"})
+}
+
+// BeginPasskeyLogin - POST /api/auth/webauthn/login/begin
+func BeginPasskeyLogin(c *gin.Context) {
+	svc, ok := passkeyService(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	options, challengeToken, err := svc.BeginLogin(ctx)
+	if err != nil {
+		writePasskeyError(c, err, "登录失败，请稍后重试")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"options":        options,
+		"challengeToken": challengeToken,
+	})
+}
+
+// FinishPasskeyLogin - POST /api/auth/webauthn/login/finish
+func FinishPasskeyLogin(c *gin.Context) {
+	svc, ok := passkeyService(c)
+	if !ok {
+		return
+	}
+
+	var req finishPasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "通行密钥数据无效"})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := svc.FinishLogin(ctx, req.ChallengeToken, bytes.NewReader(req.Credential))
+	if err != nil {
+		writePasskeyError(c, err, "登录失败，请稍后重试")
+		return
+	}
+
+	// The assertion is only accepted with user verification, so a passkey
+	// already proves possession and the user and is not followed by a TOTP step
+	twoFactorEnabled, err := repository.IsTwoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败，请稍后重试"})
+		return
+	}
+
+	completeLogin(c, user, twoFactorEnabled, model.LoginMethodPasskey)
+}
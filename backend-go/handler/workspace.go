@@ -0,0 +1,449 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"picshare/middleware"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/service"
+	"picshare/util"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workspaceInviteValidity is how long a workspace invitation stays valid
+const workspaceInviteValidity = 7 * 24 * time.Hour
+
+// maxWorkspaceNameLength limits workspace names (in characters)
+const maxWorkspaceNameLength = 100
+
+// workspaceRoleNames are the display names of workspace roles used in emails
+var workspaceRoleNames = map[string]string{
+	model.WorkspaceRoleOwner:  "所有者",
+	model.WorkspaceRoleEditor: "编辑",
+	model.WorkspaceRoleViewer: "查看者",
+}
+
+// CreateWorkspace - POST /api/workspaces
+func CreateWorkspace(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	c.ShouldBindJSON(&req)
+
+	name, errMsg := validateWorkspaceName(req.Name)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	ctx := context.Background()
+	workspace := &model.Workspace{
+		Name:      name,
+		CreatedBy: &userID,
+	}
+
+	if err := repository.CreateWorkspace(ctx, workspace); err != nil {
+		util.Log("Failed to create workspace: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建工作区失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "工作区创建成功",
+		"workspace": workspace,
+	})
+}
+
+// GetMyWorkspaces - GET /api/workspaces
+func GetMyWorkspaces(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	ctx := context.Background()
+	workspaces, err := repository.GetWorkspacesByUser(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取工作区列表失败"})
+		return
+	}
+	if workspaces == nil {
+		workspaces = []model.Workspace{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"workspaces": workspaces})
+}
+
+// GetWorkspaceDetail - GET /api/workspaces/:id
+func GetWorkspaceDetail(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, false)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	members, err := repository.GetWorkspaceMembers(ctx, workspace.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取成员列表失败"})
+		return
+	}
+
+	response := gin.H{
+		"workspace": workspace,
+		"members":   members,
+	}
+
+	// Only owners see pending invitations
+	if workspace.Role == model.WorkspaceRoleOwner {
+		invites, err := repository.GetPendingWorkspaceInvites(ctx, workspace.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请列表失败"})
+			return
+		}
+		if invites == nil {
+			invites = []model.WorkspaceInvite{}
+		}
+		response["invites"] = invites
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateWorkspace - PUT /api/workspaces/:id
+func UpdateWorkspace(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, true)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	c.ShouldBindJSON(&req)
+
+	name, errMsg := validateWorkspaceName(req.Name)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	ctx := context.Background()
+	if err := repository.UpdateWorkspaceName(ctx, workspace.ID, name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新工作区失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "工作区已更新"})
+}
+
+// DeleteWorkspace - DELETE /api/workspaces/:id
+// Albums of the workspace return to the personal albums of their creators
+func DeleteWorkspace(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, true)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if err := repository.DeleteWorkspace(ctx, workspace.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除工作区失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "工作区已删除"})
+}
+
+// GetWorkspaceAlbums - GET /api/workspaces/:id/albums
+func GetWorkspaceAlbums(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, false)
+	if !ok {
+		return
+	}
+
+	page, limit := util.ParsePagination(c.Query("page"), c.Query("limit"))
+
+	ctx := context.Background()
+	albums, total, err := repository.GetAlbumsByWorkspace(ctx, workspace.ID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取影集列表失败"})
+		return
+	}
+
+	items := make([]gin.H, len(albums))
+	for i, a := range albums {
+		items[i] = formatAlbumListItem(a)
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, gin.H{
+		"albums": items,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+		},
+	})
+}
+
+// InviteWorkspaceMember - POST /api/workspaces/:id/invites
+func InviteWorkspaceMember(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, true)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	c.ShouldBindJSON(&req)
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+
+	if !util.ValidateEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入有效的邮箱地址"})
+		return
+	}
+	if req.Role == "" {
+		req.Role = model.WorkspaceRoleEditor
+	}
+	if !model.IsValidWorkspaceRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员角色"})
+		return
+	}
+
+	ctx := context.Background()
+
+	// Existing members are managed through their role instead
+	if user, err := repository.FindUserByEmail(ctx, req.Email); err == nil {
+		if _, err := repository.GetWorkspaceRole(ctx, workspace.ID, user.ID); err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "该用户已是工作区成员"})
+			return
+		}
+	}
+
+	token := util.GenerateRandomToken()
+	invite := &model.WorkspaceInvite{
+		WorkspaceID: workspace.ID,
+		Email:       req.Email,
+		Role:        req.Role,
+		TokenHash:   util.HashToken(token),
+		InvitedBy:   userID,
+		ExpiresAt:   time.Now().Add(workspaceInviteValidity),
+	}
+
+	if err := repository.CreateWorkspaceInvite(ctx, invite); err != nil {
+		util.Log("Failed to create workspace invite: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发送邀请失败"})
+		return
+	}
+
+	inviterName := ""
+	if inviter, err := repository.FindUserByID(ctx, userID); err == nil {
+		inviterName = inviter.Name
+	}
+	emailService := service.GetEmailService()
+	if err := emailService.SendWorkspaceInviteEmail(req.Email, inviterName, workspace.Name, workspaceRoleNames[req.Role], token, invite.ExpiresAt); err != nil {
+		util.Log("Failed to send workspace invite to %s: %v", req.Email, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "邀请已发送",
+		"invite":  invite,
+	})
+}
+
+// RevokeWorkspaceInvite - DELETE /api/workspaces/:id/invites/:inviteId
+func RevokeWorkspaceInvite(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, true)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.Atoi(c.Param("inviteId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邀请ID"})
+		return
+	}
+
+	ctx := context.Background()
+	if err := repository.DeleteWorkspaceInvite(ctx, inviteID, workspace.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邀请已撤销"})
+}
+
+// AcceptWorkspaceInvite - POST /api/workspace-invites/accept
+// Accepts the invitation from the link in the invite email. The invite is
+// bound to the email address it was sent to.
+func AcceptWorkspaceInvite(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的链接"})
+		return
+	}
+
+	ctx := context.Background()
+	invite, err := repository.FindWorkspaceInviteByTokenHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邀请已过期或无效"})
+		return
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if !strings.EqualFold(user.Email, invite.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "此邀请发送给了其他邮箱，请使用对应账号登录"})
+		return
+	}
+
+	if err := repository.AcceptWorkspaceInvite(ctx, invite, userID); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "邀请已过期或无效"})
+			return
+		}
+		util.Log("Failed to accept workspace invite %d: %v", invite.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "加入工作区失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "已加入工作区",
+		"workspaceId":   invite.WorkspaceID,
+		"workspaceName": invite.WorkspaceName,
+	})
+}
+
+// UpdateWorkspaceMember - PUT /api/workspaces/:id/members/:userId
+func UpdateWorkspaceMember(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, true)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	c.ShouldBindJSON(&req)
+	if !model.IsValidWorkspaceRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员角色"})
+		return
+	}
+
+	ctx := context.Background()
+
+	if err := repository.UpdateWorkspaceMemberRole(ctx, workspace.ID, memberID, req.Role); err != nil {
+		switch err {
+		case repository.ErrNoRowsUpdated:
+			c.JSON(http.StatusNotFound, gin.H{"error": "成员不存在"})
+		case repository.ErrLastWorkspaceOwner:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "工作区至少需要保留一名所有者"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新成员角色失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "成员角色已更新"})
+}
+
+// RemoveWorkspaceMember - DELETE /api/workspaces/:id/members/:userId
+// Owners may remove anyone; other members may only leave
+func RemoveWorkspaceMember(c *gin.Context) {
+	workspace, ok := findMemberWorkspace(c, false)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if memberID != userID && workspace.Role != model.WorkspaceRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有工作区所有者可以移除成员"})
+		return
+	}
+
+	ctx := context.Background()
+
+	if _, err := repository.GetWorkspaceRole(ctx, workspace.ID, memberID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "成员不存在"})
+		return
+	}
+
+	if err := repository.RemoveWorkspaceMember(ctx, workspace.ID, memberID); err != nil {
+		if err == repository.ErrLastWorkspaceOwner {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "工作区至少需要保留一名所有者"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除成员失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "成员已移除"})
+}
+
+// findMemberWorkspace loads the workspace in the URL for the current member,
+// optionally requiring the owner role, writing the error response if not allowed
+func findMemberWorkspace(c *gin.Context, ownerOnly bool) (*model.Workspace, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的工作区ID"})
+		return nil, false
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	workspace, err := repository.FindWorkspaceForMember(context.Background(), id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "工作区不存在"})
+		return nil, false
+	}
+
+	if ownerOnly && workspace.Role != model.WorkspaceRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有工作区所有者可以执行此操作"})
+		return nil, false
+	}
+
+	return workspace, true
+}
+
+// validateWorkspaceName trims a workspace name and returns an error message if invalid
+func validateWorkspaceName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "请输入工作区名称"
+	}
+	if utf8.RuneCountInString(name) > maxWorkspaceNameLength {
+		return "", fmt.Sprintf("工作区名称不能超过 %d 个字符", maxWorkspaceNameLength)
+	}
+	return name, ""
+}
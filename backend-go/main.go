@@ -46,6 +46,14 @@ func main() {
 	// Initialize image service
 	service.InitImage()
 
+	// Initialize OIDC single sign-on (only when an identity provider is configured)
+	service.InitOIDC()
+
+	// Initialize passkey (WebAuthn) service
+	if err := service.InitWebAuthn(); err != nil {
+		log.Printf("Warning: Failed to initialize WebAuthn: %v", err)
+	}
+
 	// Initialize cleanup service
 	service.InitCleanup()
 	if cleanupService := service.GetCleanupService(); cleanupService != nil {
@@ -55,8 +63,10 @@ func main() {
 	// Create Gin router
 	router := gin.Default()
 
-	// Trust proxy for correct IP detection
-	router.SetTrustedProxies([]string{"0.0.0.0/0"})
+	// Only believe forwarded client IPs from our own reverse proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Apply middleware
 	router.Use(middleware.CORS())
@@ -69,47 +79,162 @@ func main() {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", handler.Register)
-			auth.POST("/login", handler.Login)
+			auth.POST("/login", middleware.AuthRateLimiter(10, 1*time.Minute), handler.Login)
+			auth.POST("/login/2fa", middleware.RateLimiter(10, 1*time.Minute), handler.LoginTwoFactor)
+			auth.GET("/oidc/config", handler.GetOIDCConfig)
+			auth.POST("/oidc/begin", handler.BeginOIDCLogin)
+			auth.POST("/oidc/callback", handler.OIDCCallback)
 			auth.GET("/verify-email", handler.VerifyEmail)
 			auth.POST("/resend-verification", handler.ResendVerification)
-			auth.POST("/forgot-password", handler.ForgotPassword)
+			auth.POST("/forgot-password", middleware.AuthRateLimiter(5, 15*time.Minute), handler.ForgotPassword)
 			auth.POST("/reset-password", handler.ResetPassword)
+			auth.POST("/magic-link", middleware.AuthRateLimiter(5, 15*time.Minute), handler.RequestMagicLink)
+			auth.POST("/magic-link/verify", middleware.RateLimiter(10, 1*time.Minute), handler.MagicLinkLogin)
+			auth.POST("/refresh", handler.RefreshToken)
 
 			// Authenticated routes
 			auth.GET("/profile", middleware.Authenticate(), handler.GetProfile)
 			auth.PUT("/profile", middleware.Authenticate(), handler.UpdateProfile)
+			auth.PUT("/profile/avatar", middleware.Authenticate(), middleware.UploadAvatarMiddleware(
+				middleware.UploadAvatarConfig{
+					MaxFileSize: 10 * 1024 * 1024, // 10MB
+				},
+			), handler.UploadAvatar)
+			auth.DELETE("/profile/avatar", middleware.Authenticate(), handler.DeleteAvatar)
+			auth.GET("/profile/api-keys", middleware.Authenticate(), handler.GetAPIKeys)
+			auth.POST("/profile/api-keys", middleware.Authenticate(), handler.CreateAPIKey)
+			auth.DELETE("/profile/api-keys/:id", middleware.Authenticate(), handler.RevokeAPIKey)
 			auth.PUT("/change-password", middleware.Authenticate(), handler.ChangePassword)
+			auth.POST("/change-email", middleware.Authenticate(), middleware.RateLimiter(5, 1*time.Hour), handler.RequestEmailChange)
+			auth.DELETE("/change-email", middleware.Authenticate(), handler.CancelEmailChange)
+			auth.POST("/change-email/confirm", middleware.OptionalAuth(), handler.ConfirmEmailChange)
+			auth.GET("/export", middleware.Authenticate(), middleware.NoImpersonation(), middleware.RateLimiter(10, 1*time.Hour), handler.ExportData)
+			auth.DELETE("/account", middleware.Authenticate(), middleware.RateLimiter(5, 15*time.Minute), handler.DeleteAccount)
+			auth.POST("/account/restore", middleware.Authenticate(), handler.RestoreAccount)
+			auth.POST("/logout", middleware.Authenticate(), handler.Logout)
+			auth.GET("/sessions", middleware.Authenticate(), handler.GetSessions)
+			auth.DELETE("/sessions", middleware.Authenticate(), handler.RevokeOtherSessions)
+			auth.DELETE("/sessions/:id", middleware.Authenticate(), handler.RevokeSession)
+			auth.GET("/2fa", middleware.Authenticate(), handler.GetTwoFactorStatus)
+			auth.POST("/2fa/setup", middleware.Authenticate(), handler.SetupTwoFactor)
+			auth.POST("/2fa/enable", middleware.Authenticate(), handler.EnableTwoFactor)
+			auth.POST("/2fa/disable", middleware.Authenticate(), handler.DisableTwoFactor)
+			auth.POST("/2fa/recovery-codes", middleware.Authenticate(), handler.RegenerateRecoveryCodes)
+
+			// Passkey (WebAuthn) routes
+			webauthn := auth.Group("/webauthn")
+			{
+				webauthn.POST("/login/begin", handler.BeginPasskeyLogin)
+				webauthn.POST("/login/finish", handler.FinishPasskeyLogin)
+				webauthn.POST("/register/begin", middleware.Authenticate(), handler.BeginPasskeyRegistration)
+				webauthn.POST("/register/finish", middleware.Authenticate(), handler.FinishPasskeyRegistration)
+				webauthn.GET("/credentials", middleware.Authenticate(), handler.GetPasskeys)
+				webauthn.DELETE("/credentials/:id", middleware.Authenticate(), handler.DeletePasskey)
+			}
 		}
 
 		// Album routes (all require authentication)
 		albums := api.Group("/albums")
 		albums.Use(middleware.Authenticate())
 		{
-			albums.POST("", handler.CreateAlbum)
+			albums.POST("", middleware.RequireVerifiedEmail(), handler.CreateAlbum)
 			albums.GET("", handler.GetMyAlbums)
-			albums.GET("/:id/qrcode", handler.GetAlbumQRCode)
+			albums.GET("/shared", handler.GetSharedAlbums)
+			albums.GET("/:id/qrcode", middleware.RequireVerifiedEmail(), handler.GetAlbumQRCode)
 			albums.GET("/:id", handler.GetAlbumDetail)
+			albums.GET("/:id/expiry-history", handler.GetAlbumExpiryHistory)
+			albums.POST("/:id/duplicate", middleware.RequireVerifiedEmail(), handler.DuplicateAlbum)
+			albums.POST("/:id/transfer", middleware.RequireVerifiedEmail(), handler.InitiateAlbumTransfer)
+			albums.DELETE("/:id/transfer", handler.CancelAlbumTransfer)
+			albums.PUT("/:id/workspace", handler.MoveAlbumToWorkspace)
+			albums.GET("/:id/collaborators", handler.GetAlbumCollaborators)
+			albums.POST("/:id/collaborators", middleware.RequireVerifiedEmail(), handler.InviteAlbumCollaborator)
+			albums.PUT("/:id/collaborators/:userId", handler.UpdateAlbumCollaborator)
+			albums.DELETE("/:id/collaborators/:userId", handler.RemoveAlbumCollaborator)
 			albums.PUT("/:id", handler.UpdateAlbum)
 			albums.DELETE("/:id", handler.DeleteAlbum)
 
 			// Photo routes
-			albums.POST("/:albumId/photos", middleware.UploadPhotosMiddleware(
+			albums.POST("/:id/photos", middleware.RequireVerifiedEmail(), middleware.UploadPhotosMiddleware(
 				middleware.UploadPhotosConfig{
 					MaxFileSize: 50 * 1024 * 1024, // 50MB
 					MaxFiles:    20,
 				},
 			), handler.UploadPhotos)
-			albums.DELETE("/:albumId/photos/:photoId", handler.DeletePhoto)
-			albums.GET("/:albumId/photos/:photoId/original", handler.GetPhotoOriginal)
+			albums.DELETE("/:id/photos/:photoId", handler.DeletePhoto)
+			albums.GET("/:id/photos/:photoId/original", handler.GetPhotoOriginal)
+		}
+
+		// Collection routes (all require authentication)
+		collections := api.Group("/collections")
+		collections.Use(middleware.Authenticate())
+		{
+			collections.POST("", middleware.RequireVerifiedEmail(), handler.CreateCollection)
+			collections.GET("", handler.GetMyCollections)
+			collections.GET("/:id", handler.GetCollectionDetail)
+			collections.PUT("/:id", handler.UpdateCollection)
+			collections.DELETE("/:id", handler.DeleteCollection)
+		}
+
+		// Album template routes (all require authentication)
+		templates := api.Group("/album-templates")
+		templates.Use(middleware.Authenticate())
+		{
+			templates.POST("", handler.CreateAlbumTemplate)
+			templates.GET("", handler.GetMyAlbumTemplates)
+			templates.PUT("/:id", handler.UpdateAlbumTemplate)
+			templates.DELETE("/:id", handler.DeleteAlbumTemplate)
 		}
 
+		// Album transfer routes (all require authentication)
+		transfers := api.Group("/album-transfers")
+		transfers.Use(middleware.Authenticate())
+		{
+			transfers.GET("", handler.GetMyAlbumTransfers)
+			transfers.POST("/:id/accept", handler.AcceptAlbumTransfer)
+			transfers.POST("/:id/decline", handler.DeclineAlbumTransfer)
+		}
+
+		// Workspace routes (all require authentication)
+		workspaces := api.Group("/workspaces")
+		workspaces.Use(middleware.Authenticate())
+		{
+			workspaces.POST("", handler.CreateWorkspace)
+			workspaces.GET("", handler.GetMyWorkspaces)
+			workspaces.GET("/:id", handler.GetWorkspaceDetail)
+			workspaces.PUT("/:id", handler.UpdateWorkspace)
+			workspaces.DELETE("/:id", handler.DeleteWorkspace)
+			workspaces.GET("/:id/albums", handler.GetWorkspaceAlbums)
+			workspaces.POST("/:id/invites", middleware.RequireVerifiedEmail(), handler.InviteWorkspaceMember)
+			workspaces.DELETE("/:id/invites/:inviteId", handler.RevokeWorkspaceInvite)
+			workspaces.PUT("/:id/members/:userId", handler.UpdateWorkspaceMember)
+			workspaces.DELETE("/:id/members/:userId", handler.RemoveWorkspaceMember)
+		}
+		api.POST("/workspace-invites/accept", middleware.Authenticate(), handler.AcceptWorkspaceInvite)
+		api.POST("/album-invites/accept", middleware.Authenticate(), handler.AcceptAlbumInvite)
+
 		// Public routes (no authentication required)
 		public := api.Group("/s")
 		{
 			public.GET("/:shareCode", handler.ViewAlbumByShareCode)
 			public.GET("/:shareCode/photos/:photoId/download", handler.DownloadPhoto)
+			public.POST("/:shareCode/report", middleware.OptionalAuth(), middleware.RateLimiter(5, 1*time.Hour), handler.ReportAlbum)
 		}
 
+		// Public collection routes
+		publicCollections := api.Group("/c")
+		{
+			publicCollections.GET("/:shareCode", handler.ViewCollectionByShareCode)
+			publicCollections.GET("/:shareCode/albums/:albumId", handler.ViewCollectionAlbum)
+			publicCollections.GET("/:shareCode/albums/:albumId/photos/:photoId/download", handler.DownloadCollectionPhoto)
+		}
+
+		// One-click album extension from expiry reminder emails
+		api.POST("/extend-album", handler.ExtendAlbumByToken)
+
+		// Accept an album transfer from the link in the transfer email
+		api.POST("/accept-album-transfer", middleware.Authenticate(), handler.AcceptAlbumTransferByToken)
+
 		// Feedback routes
 		api.POST("/feedback", middleware.OptionalAuth(), middleware.UploadFeedbackImagesMiddleware(
 			middleware.UploadFeedbackImagesConfig{
@@ -128,8 +253,32 @@ func main() {
 			admin.GET("/stats", handler.GetDashboardStats)
 			admin.GET("/users", handler.GetAllUsers)
 			admin.GET("/users/:userId/albums", handler.GetUserAlbums)
+			admin.POST("/users/:userId/suspend", handler.SuspendUser)
+			admin.POST("/users/:userId/unsuspend", handler.UnsuspendUser)
+			admin.PUT("/users/:userId/role", handler.ChangeUserRole)
+			admin.PUT("/users/:userId/quota", handler.SetUserQuota)
+			admin.POST("/users/:userId/force-password-reset", handler.ForcePasswordReset)
+			admin.POST("/users/:userId/impersonate", handler.ImpersonateUser)
+			admin.DELETE("/users/:userId", handler.DeleteUser)
 			admin.GET("/albums", handler.GetAllAlbums)
 			admin.GET("/albums/:albumId/logs", handler.GetAlbumLogs)
+			admin.POST("/albums/:albumId/takedown", handler.TakedownAlbum)
+			admin.DELETE("/albums/:albumId/takedown", handler.RestoreAlbum)
+			admin.GET("/albums/:albumId/photos", handler.GetModerationAlbumPhotos)
+			admin.POST("/albums/:albumId/photos/:photoId/hide", handler.HidePhoto)
+			admin.POST("/albums/:albumId/photos/:photoId/unhide", handler.UnhidePhoto)
+			admin.DELETE("/albums/:albumId/photos/:photoId", handler.AdminDeletePhoto)
+			admin.GET("/reports", handler.GetAlbumReports)
+			admin.GET("/reports/albums", handler.GetReportedAlbums)
+			admin.PUT("/reports/:reportId", handler.ReviewAlbumReport)
+			admin.GET("/feedback", handler.GetFeedbackList)
+			admin.GET("/feedback/:feedbackId", handler.GetFeedbackDetail)
+			admin.PUT("/feedback/:feedbackId", handler.UpdateFeedback)
+			admin.POST("/feedback/:feedbackId/reply", handler.ReplyToFeedback)
+			admin.DELETE("/feedback/:feedbackId", handler.DeleteFeedback)
+			admin.GET("/audit-events", handler.GetAuditEvents)
+			admin.GET("/audit-events/export", handler.ExportAuditEvents)
+			admin.GET("/audit-events/verify", handler.VerifyAuditEvents)
 		}
 	}
 
@@ -1,24 +1,42 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
 	"picshare/util"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AuthUser represents the authenticated user info
 type AuthUser struct {
-	ID    int
-	Email string
-	Role  string
-	Name  string
+	ID            int
+	Email         string
+	Role          string
+	Name          string
+	EmailVerified bool
+	CreatedAt     time.Time
+	// ImpersonatedBy is the ID of the admin acting as this user, 0 otherwise
+	ImpersonatedBy int
 }
 
-// Authenticate validates JWT token and sets user context
+// Authenticate validates JWT token (or a personal API key) and sets user context
 func Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key, ok := extractAPIKey(c); ok {
+			if !authenticateAPIKey(c, key) {
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "请先登录"})
@@ -44,22 +62,98 @@ func Authenticate() gin.HandlerFunc {
 			return
 		}
 
-		// Set user context
-		c.Set("userID", claims.ID)
-		c.Set("userEmail", claims.Email)
-		c.Set("userRole", claims.Role)
-		c.Set("userName", claims.Name)
-		c.Set("user", &AuthUser{
-			ID:    claims.ID,
-			Email: claims.Email,
-			Role:  claims.Role,
-			Name:  claims.Name,
-		})
+		// Check the session is still active and load the user's current role
+		user, err := repository.FindSessionUser(context.Background(), claims.SessionID, claims.ID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+		if user.IsSuspended() {
+			respondSuspended(c)
+			c.Abort()
+			return
+		}
+
+		if claims.ImpersonatedBy != 0 && !impersonationAllows(c) {
+			respondImpersonating(c)
+			c.Abort()
+			return
+		}
+
+		setAuthContext(c, claims.SessionID, claims.ImpersonatedBy, user)
 
 		c.Next()
 	}
 }
 
+// respondSuspended rejects a request from a suspended account
+func respondSuspended(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":            "账号已被停用，如有疑问请联系管理员",
+		"accountSuspended": true,
+	})
+}
+
+// impersonationAllowedRoutes are the requests other than reads that an admin
+// impersonating a user may make
+var impersonationAllowedRoutes = map[string]bool{
+	// Signing out ends the impersonation
+	http.MethodPost + " /api/auth/logout": true,
+}
+
+// impersonationAllows reports whether an admin impersonating a user may make
+// the request. Impersonation is for seeing what the user sees, so anything
+// that is not a read is refused unless it is explicitly allowed.
+func impersonationAllows(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return impersonationAllowedRoutes[c.Request.Method+" "+c.FullPath()]
+}
+
+// respondImpersonating rejects a request made by an admin impersonating a
+// user that impersonation does not allow
+func respondImpersonating(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":         "模拟登录期间不能执行此操作",
+		"impersonating": true,
+	})
+}
+
+// NoImpersonation blocks a read while an admin is impersonating the user,
+// for reads that hand out the user's data, like a data export. Everything
+// else is already refused by Authenticate. Must run after Authenticate.
+func NoImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user, ok := GetAuthUser(c); ok && user.ImpersonatedBy != 0 {
+			respondImpersonating(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// setAuthContext stores the authenticated user in the request context
+func setAuthContext(c *gin.Context, sessionID, impersonatedBy int, user *model.User) {
+	c.Set("userID", user.ID)
+	c.Set("userEmail", user.Email)
+	c.Set("userRole", user.Role)
+	c.Set("userName", user.Name)
+	c.Set("sessionID", sessionID)
+	c.Set("user", &AuthUser{
+		ID:             user.ID,
+		Email:          user.Email,
+		Role:           user.Role,
+		Name:           user.Name,
+		EmailVerified:  user.EmailVerified,
+		CreatedAt:      user.CreatedAt,
+		ImpersonatedBy: impersonatedBy,
+	})
+}
+
 // RequireAdmin checks if user has admin role
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -76,6 +170,20 @@ func RequireAdmin() gin.HandlerFunc {
 			return
 		}
 
+		// Admins may be required to have 2FA before seeing other users' data
+		if config.Get().TwoFactor.RequireForAdmins {
+			userID, _ := GetUserID(c)
+			enabled, err := repository.IsTwoFactorEnabled(context.Background(), userID)
+			if err != nil || !enabled {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":                  "管理员账号需先启用两步验证",
+					"twoFactorSetupRequired": true,
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -99,16 +207,15 @@ func OptionalAuth() gin.HandlerFunc {
 
 		claims, err := util.VerifyToken(tokenString)
 		if err == nil {
-			c.Set("userID", claims.ID)
-			c.Set("userEmail", claims.Email)
-			c.Set("userRole", claims.Role)
-			c.Set("userName", claims.Name)
-			c.Set("user", &AuthUser{
-				ID:    claims.ID,
-				Email: claims.Email,
-				Role:  claims.Role,
-				Name:  claims.Name,
-			})
+			user, err := repository.FindSessionUser(context.Background(), claims.SessionID, claims.ID)
+			if err == nil && !user.IsSuspended() {
+				if claims.ImpersonatedBy != 0 && !impersonationAllows(c) {
+					respondImpersonating(c)
+					c.Abort()
+					return
+				}
+				setAuthContext(c, claims.SessionID, claims.ImpersonatedBy, user)
+			}
 		}
 
 		c.Next()
@@ -125,6 +232,16 @@ func GetUserID(c *gin.Context) (int, bool) {
 	return id, ok
 }
 
+// GetSessionID extracts the current login session ID from context
+func GetSessionID(c *gin.Context) (int, bool) {
+	sessionID, exists := c.Get("sessionID")
+	if !exists {
+		return 0, false
+	}
+	id, ok := sessionID.(int)
+	return id, ok
+}
+
 // GetUserRole extracts user role from context
 func GetUserRole(c *gin.Context) (string, bool) {
 	userRole, exists := c.Get("userRole")
@@ -44,7 +44,7 @@ func CORS() gin.HandlerFunc {
 
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusOK)
@@ -54,12 +54,3 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
-
-// TrustProxy middleware - Gin's trusted CIDR
-func TrustProxy() gin.HandlerFunc {
-	// Set trusted proxies - trust all
-	return func(c *gin.Context) {
-		c.Set("Trusted-Proxies", []string{"0.0.0.0/0"})
-		c.Next()
-	}
-}
@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -50,8 +54,12 @@ func RateLimiter(rate int, window time.Duration) gin.HandlerFunc {
 	)
 }
 
+// authRateLimitBodyLimit caps how much of an auth request body is read to find the email
+const authRateLimitBodyLimit = 64 * 1024
+
 // AuthRateLimiter creates a stricter rate limiter for auth endpoints
-// Uses email in request body as part of key to avoid affecting different users on same IP
+// Uses email in request body as part of key to avoid affecting different users on same IP.
+// The body is put back afterwards so the handler can still bind it.
 func AuthRateLimiter(rate int, window time.Duration) gin.HandlerFunc {
 	rateInstance := limiter.Rate{
 		Period: window,
@@ -67,13 +75,15 @@ func AuthRateLimiter(rate int, window time.Duration) gin.HandlerFunc {
 			key := c.ClientIP()
 
 			// Try to extract email from request body
-			if c.Request.Method == "POST" {
-				type authRequest struct {
+			if c.Request.Method == "POST" && c.Request.Body != nil {
+				body, err := io.ReadAll(io.LimitReader(c.Request.Body, authRateLimitBodyLimit))
+				c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+				var req struct {
 					Email string `json:"email"`
 				}
-				var req authRequest
-				if err := c.ShouldBindJSON(&req); err == nil && req.Email != "" {
-					key = key + "-" + req.Email
+				if err == nil && json.Unmarshal(body, &req) == nil && req.Email != "" {
+					key = key + "-" + strings.ToLower(strings.TrimSpace(req.Email))
 				}
 			}
 
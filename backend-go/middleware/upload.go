@@ -149,3 +149,52 @@ func GetUploadedImages(c *gin.Context) []*multipart.FileHeader {
 	}
 	return images.([]*multipart.FileHeader)
 }
+
+// UploadAvatarConfig for avatar upload
+type UploadAvatarConfig struct {
+	MaxFileSize int64
+}
+
+// UploadAvatarMiddleware creates a multipart form handler for a single avatar image
+func UploadAvatarMiddleware(config UploadAvatarConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := c.Request.ParseMultipartForm(config.MaxFileSize); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "文件解析失败"})
+			c.Abort()
+			return
+		}
+
+		form := c.Request.MultipartForm
+		if form.File == nil || len(form.File["avatar"]) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请选择头像图片"})
+			c.Abort()
+			return
+		}
+
+		fileHeader := form.File["avatar"][0]
+		mimeType := fileHeader.Header.Get("Content-Type")
+		if !allowedImageTypes[mimeType] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的文件格式，请上传 JPG、PNG 或 GIF 图片"})
+			c.Abort()
+			return
+		}
+
+		if fileHeader.Size > config.MaxFileSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("图片过大，最大%dMB", config.MaxFileSize/(1024*1024))})
+			c.Abort()
+			return
+		}
+
+		c.Set("uploadedAvatar", fileHeader)
+		c.Next()
+	}
+}
+
+// GetUploadedAvatar retrieves the uploaded avatar from context
+func GetUploadedAvatar(c *gin.Context) *multipart.FileHeader {
+	avatar, _ := c.Get("uploadedAvatar")
+	if avatar == nil {
+		return nil
+	}
+	return avatar.(*multipart.FileHeader)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"picshare/config"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationDeadline returns when an unverified account created at
+// createdAt loses access to album creation, uploads and sharing, or nil if
+// verification is not enforced
+func EmailVerificationDeadline(createdAt time.Time) *time.Time {
+	cfg := config.Get().EmailVerification
+	if !cfg.Required {
+		return nil
+	}
+	deadline := createdAt.Add(cfg.GracePeriod)
+	return &deadline
+}
+
+// RequireVerifiedEmail blocks users whose email is still unverified after
+// the grace period. Must run after Authenticate.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := GetAuthUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "请先登录"})
+			c.Abort()
+			return
+		}
+
+		if user.EmailVerified || user.Role == "admin" {
+			c.Next()
+			return
+		}
+
+		deadline := EmailVerificationDeadline(user.CreatedAt)
+		if deadline != nil && time.Now().After(*deadline) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":                     "请先验证邮箱后再进行此操作",
+				"emailVerificationRequired": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -15,10 +15,422 @@ type User struct {
 	ResetToken       *string   `json:"-" db:"reset_token"`
 	ResetExpires     *time.Time `json:"-" db:"reset_expires"`
 	AvatarURL        *string   `json:"avatarUrl,omitempty" db:"avatar_url"`
+	ExpiryPolicy     string    `json:"expiryPolicy" db:"expiry_policy"` // delete, archive, keep
+	SuspendedAt      *time.Time `json:"suspendedAt,omitempty" db:"suspended_at"`
+	SuspendedReason  *string   `json:"suspendedReason,omitempty" db:"suspended_reason"`
 	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt        time.Time `json:"updatedAt" db:"updated_at"`
 }
 
+// IsSuspended returns true if an admin has suspended the account
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// UserQuota holds an admin's per-user overrides of the upload limits;
+// nil fields use the global defaults
+type UserQuota struct {
+	MaxAlbums         *int   `json:"maxAlbums" db:"max_albums"`
+	MaxPhotosPerAlbum *int   `json:"maxPhotosPerAlbum" db:"max_photos_per_album"`
+	StorageQuotaBytes *int64 `json:"storageQuotaBytes" db:"storage_quota_bytes"`
+}
+
+// Session is a logged-in device, kept alive by rotating refresh tokens
+type Session struct {
+	ID            int        `json:"id" db:"id"`
+	UserID        int        `json:"-" db:"user_id"`
+	IPAddress     *string    `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent     *string    `json:"userAgent,omitempty" db:"user_agent"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt    time.Time  `json:"lastUsedAt" db:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expiresAt" db:"expires_at"`
+	RevokedAt     *time.Time `json:"-" db:"revoked_at"`
+	RevokedReason *string    `json:"-" db:"revoked_reason"`
+
+	// Computed for the requesting session
+	Current bool `json:"current"`
+}
+
+// UserTOTP is a user's authenticator app enrollment; EnabledAt is nil until
+// the first code has been confirmed
+type UserTOTP struct {
+	UserID       int        `json:"-" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`
+	EnabledAt    *time.Time `json:"enabledAt,omitempty" db:"enabled_at"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// IsEnabled reports whether enrollment has been confirmed
+func (t *UserTOTP) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
+
+// WebAuthnCredential is a passkey registered by a user
+type WebAuthnCredential struct {
+	ID              int        `json:"id" db:"id"`
+	UserID          int        `json:"-" db:"user_id"`
+	CredentialID    []byte     `json:"-" db:"credential_id"`
+	PublicKey       []byte     `json:"-" db:"public_key"`
+	AttestationType string     `json:"-" db:"attestation_type"`
+	Transports      []string   `json:"-" db:"transports"`
+	AAGUID          []byte     `json:"-" db:"aaguid"`
+	SignCount       int64      `json:"-" db:"sign_count"`
+	BackupEligible  bool       `json:"backupEligible" db:"backup_eligible"`
+	BackupState     bool       `json:"backupState" db:"backup_state"`
+	Name            string     `json:"name" db:"name"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt      *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+}
+
+// UserIdentity links a user to an account at the OIDC identity provider
+type UserIdentity struct {
+	ID      int    `json:"id" db:"id"`
+	UserID  int    `json:"-" db:"user_id"`
+	Issuer  string `json:"issuer" db:"issuer"`
+	Subject string `json:"-" db:"subject"`
+	Email   string `json:"email" db:"email"`
+	// Whether the user's admin role came from an IdP group, so that it is
+	// taken away again when they leave the group
+	GrantedAdmin bool       `json:"-" db:"granted_admin"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	LastLoginAt  *time.Time `json:"lastLoginAt,omitempty" db:"last_login_at"`
+}
+
+// APIKey is a personal access key used by scripts instead of a login session
+type APIKey struct {
+	ID     int    `json:"id" db:"id"`
+	UserID int    `json:"-" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// First characters of the key, so users can tell their keys apart
+	KeyPrefix  string     `json:"keyPrefix" db:"key_prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	LastUsedIP *string    `json:"lastUsedIp,omitempty" db:"last_used_ip"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// DataExport is a ZIP of everything stored for a user, built in the
+// background and kept in OSS until it expires
+type DataExport struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"-" db:"user_id"`
+	Status      string     `json:"status" db:"status"` // pending, processing, ready, failed
+	OSSKey      *string    `json:"-" db:"oss_key"`
+	FileSize    *int64     `json:"fileSize,omitempty" db:"file_size"`
+	Error       *string    `json:"-" db:"error"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+
+	// Computed for ready exports (not in DB)
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+// Data export statuses
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+)
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout          = "logout"
+	SessionRevokedByUser          = "revoked"
+	SessionRevokedPasswordChange  = "password_change"
+	SessionRevokedTokenReuse      = "token_reuse"
+	SessionRevokedEmailChange     = "email_change"
+	SessionRevokedAccountDeletion = "account_deletion"
+	SessionRevokedSuspended       = "suspended"
+	SessionRevokedPasswordReset   = "password_reset"
+)
+
+// LoginThrottle counts recent failed sign-ins for an account or an IP
+type LoginThrottle struct {
+	ID            int        `json:"id" db:"id"`
+	Scope         string     `json:"scope" db:"scope"` // account, ip
+	Key           string     `json:"key" db:"key"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt" db:"last_failure_at"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty" db:"locked_until"`
+}
+
+// SecurityEvent is an entry in the security log
+type SecurityEvent struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    *int      `json:"userId,omitempty" db:"user_id"`
+	Email     *string   `json:"email,omitempty" db:"email"`
+	EventType string    `json:"eventType" db:"event_type"`
+	IPAddress *string   `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent *string   `json:"userAgent,omitempty" db:"user_agent"`
+	Details   *string   `json:"details,omitempty" db:"details"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Admin actions recorded in the audit trail
+const (
+	AdminActionSuspendUser        = "suspend_user"
+	AdminActionUnsuspendUser      = "unsuspend_user"
+	AdminActionChangeRole         = "change_role"
+	AdminActionSetQuota           = "set_quota"
+	AdminActionForcePasswordReset = "force_password_reset"
+	AdminActionDeleteUser         = "delete_user"
+	AdminActionTakedownAlbum      = "takedown_album"
+	AdminActionRestoreAlbum       = "restore_album"
+	AdminActionHidePhoto          = "hide_photo"
+	AdminActionUnhidePhoto        = "unhide_photo"
+	AdminActionDeletePhoto        = "delete_photo"
+	AdminActionReviewReport       = "review_report"
+	AdminActionUpdateFeedback     = "update_feedback"
+	AdminActionReplyFeedback      = "reply_feedback"
+	AdminActionDeleteFeedback     = "delete_feedback"
+	AdminActionImpersonateUser    = "impersonate_user"
+	AdminActionEndImpersonation   = "end_impersonation"
+)
+
+// Audit event target types
+const (
+	AuditTargetUser       = "user"
+	AuditTargetAlbum      = "album"
+	AuditTargetPhoto      = "photo"
+	AuditTargetReport     = "report"
+	AuditTargetFeedback   = "feedback"
+	AuditTargetAPIKey     = "api_key"
+	AuditTargetAuditEvent = "audit_event"
+)
+
+// AlbumTakedown is an admin's removal of an album from public access.
+// The album stays with its owner; visitors see the reason instead.
+type AlbumTakedown struct {
+	AlbumID   int       `json:"albumId" db:"album_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	AdminID   *int      `json:"-" db:"admin_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+
+	// Computed: taken down by visitor reports rather than an admin
+	Automatic bool `json:"automatic"`
+}
+
+// AlbumReport is an abuse report on a shared album or one of its photos
+type AlbumReport struct {
+	ID                int        `json:"id" db:"id"`
+	AlbumID           int        `json:"albumId" db:"album_id"`
+	PhotoID           *int       `json:"photoId,omitempty" db:"photo_id"` // nil when the whole album is reported
+	Category          string     `json:"category" db:"category"`
+	Reason            string     `json:"reason" db:"reason"` // optional details from the reporter
+	ReporterID        *int       `json:"reporterId,omitempty" db:"reporter_id"`
+	ReporterIP        *string    `json:"reporterIp,omitempty" db:"reporter_ip"`
+	ReporterUserAgent *string    `json:"reporterUserAgent,omitempty" db:"reporter_user_agent"`
+	Status            string     `json:"status" db:"status"`
+	ResolvedBy        *int       `json:"resolvedBy,omitempty" db:"resolved_by"`
+	ResolvedAt        *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+
+	// Joined for the moderation queue
+	AlbumTitle     string  `json:"albumTitle"`
+	ShareCode      string  `json:"shareCode"`
+	OwnerID        int     `json:"ownerId"`
+	OwnerName      string  `json:"ownerName"`
+	OwnerEmail     string  `json:"ownerEmail"`
+	PhotoThumbnail *string `json:"photoThumbnail,omitempty"`
+	TakenDown      bool    `json:"takenDown"`
+}
+
+// Album report statuses
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Album report categories
+const (
+	ReportCategoryCopyright = "copyright"
+	ReportCategoryExplicit  = "explicit"
+	ReportCategorySpam      = "spam"
+	ReportCategoryOther     = "other"
+)
+
+// IsValidReportCategory checks if a report category is known
+func IsValidReportCategory(category string) bool {
+	switch category {
+	case ReportCategoryCopyright, ReportCategoryExplicit, ReportCategorySpam, ReportCategoryOther:
+		return true
+	}
+	return false
+}
+
+// AlbumReportSummary counts the reports on one album for the admin queue
+type AlbumReportSummary struct {
+	AlbumID        int            `json:"albumId"`
+	AlbumTitle     string         `json:"albumTitle"`
+	ShareCode      string         `json:"shareCode"`
+	OwnerID        int            `json:"ownerId"`
+	OwnerName      string         `json:"ownerName"`
+	OwnerEmail     string         `json:"ownerEmail"`
+	OpenCount      int            `json:"openCount"`
+	TotalCount     int            `json:"totalCount"`
+	Categories     map[string]int `json:"categories"` // open reports per category
+	LastReportedAt time.Time      `json:"lastReportedAt"`
+	TakenDown      bool           `json:"takenDown"`
+	AutoTakenDown  bool           `json:"autoTakenDown"`
+}
+
+// AuditEvent is an entry in the tamper-evident audit trail. Hash covers the
+// entry's fields and PrevHash, the hash of the entry before it.
+type AuditEvent struct {
+	ID             int       `json:"id" db:"id"`
+	ActorID        *int      `json:"actorId,omitempty" db:"actor_id"` // nil for the system or a visitor
+	ActorEmail     *string   `json:"actorEmail,omitempty" db:"actor_email"`
+	ImpersonatedBy *int      `json:"impersonatedBy,omitempty" db:"impersonated_by"`
+	Action         string    `json:"action" db:"action"`
+	TargetType     string    `json:"targetType" db:"target_type"`
+	TargetID       int       `json:"targetId" db:"target_id"`
+	Details        *string   `json:"details,omitempty" db:"details"` // JSON
+	IPAddress      *string   `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent      *string   `json:"userAgent,omitempty" db:"user_agent"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	PrevHash       string    `json:"prevHash" db:"prev_hash"`
+	Hash           string    `json:"hash" db:"hash"`
+}
+
+// AuditGenesisHash is the previous hash of the first audit event
+const AuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Audit event actions recorded outside the admin actions
+const (
+	AuditActionLogin             = "login"
+	AuditActionLogout            = "logout"
+	AuditActionPasswordChange    = "password_change"
+	AuditActionPasswordReset     = "password_reset"
+	AuditActionEmailChange       = "email_change"
+	AuditActionTwoFactorEnable   = "two_factor_enable"
+	AuditActionTwoFactorDisable  = "two_factor_disable"
+	AuditActionAPIKeyCreate      = "api_key_create"
+	AuditActionAPIKeyRevoke      = "api_key_revoke"
+	AuditActionAccountDeletion   = "account_deletion_request"
+	AuditActionAlbumCreate       = "album_create"
+	AuditActionAlbumDelete       = "album_delete"
+	AuditActionPhotoUpload       = "photo_upload"
+	AuditActionPhotoDelete       = "photo_delete"
+	AuditActionViewUserAlbums    = "view_user_albums"
+	AuditActionViewAlbumLogs     = "view_album_logs"
+	AuditActionViewAlbumPhotos   = "view_album_photos"
+	AuditActionExportAuditEvents = "export_audit_events"
+)
+
+// Feedback is a message sent through the feedback form
+type Feedback struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     *int       `json:"userId,omitempty" db:"user_id"` // nil for visitors
+	UserName   *string    `json:"userName,omitempty" db:"user_name"`
+	UserEmail  *string    `json:"userEmail,omitempty"`
+	Content    string     `json:"content" db:"content"`
+	Contact    *string    `json:"contact,omitempty" db:"contact"`
+	Status     string     `json:"status" db:"status"`
+	AdminNote  *string    `json:"adminNote,omitempty" db:"admin_note"`
+	IPAddress  *string    `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent  *string    `json:"userAgent,omitempty" db:"user_agent"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+
+	// Computed fields (not in DB)
+	ImageCount int             `json:"imageCount"`
+	ReplyCount int             `json:"replyCount"`
+	Images     []FeedbackImage `json:"images,omitempty"`
+	Replies    []FeedbackReply `json:"replies,omitempty"`
+}
+
+// FeedbackImage is an image uploaded with feedback
+type FeedbackImage struct {
+	ID         int       `json:"id" db:"id"`
+	FeedbackID int       `json:"-" db:"feedback_id"`
+	OSSKey     string    `json:"-" db:"oss_key"`
+	URL        string    `json:"url" db:"url"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// FeedbackReply is an admin's emailed answer to feedback
+type FeedbackReply struct {
+	ID         int       `json:"id" db:"id"`
+	FeedbackID int       `json:"-" db:"feedback_id"`
+	AdminID    *int      `json:"adminId,omitempty" db:"admin_id"`
+	AdminName  *string   `json:"adminName,omitempty"`
+	Message    string    `json:"message" db:"message"`
+	SentTo     string    `json:"sentTo" db:"sent_to"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Feedback statuses
+const (
+	FeedbackStatusNew      = "new"
+	FeedbackStatusTriaged  = "triaged"
+	FeedbackStatusResolved = "resolved"
+)
+
+// IsValidFeedbackStatus checks if a feedback status is known
+func IsValidFeedbackStatus(status string) bool {
+	return status == FeedbackStatusNew || status == FeedbackStatusTriaged || status == FeedbackStatusResolved
+}
+
+// IsValidRole checks if a role name is known
+func IsValidRole(role string) bool {
+	return role == "photographer" || role == "admin"
+}
+
+// Login throttle scopes
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+// Security event types
+const (
+	SecurityEventLoginSuccess      = "login_success"
+	SecurityEventAccountLocked     = "account_locked"
+	SecurityEventIPLocked          = "ip_locked"
+	SecurityEventDataExport        = "data_export"
+	SecurityEventDeletionRequested = "account_deletion_requested"
+	SecurityEventDeletionCancelled = "account_deletion_cancelled"
+)
+
+// Sign-in methods recorded with login_success events
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodTwoFactor = "2fa"
+	LoginMethodPasskey   = "passkey"
+	LoginMethodOIDC      = "oidc"
+	LoginMethodMagicLink = "magic_link"
+)
+
+// API key scopes
+const (
+	ScopeAlbumsRead   = "albums:read"
+	ScopeAlbumsWrite  = "albums:write"
+	ScopePhotosUpload = "photos:upload"
+)
+
+// IsValidAPIKeyScope checks if a scope name is known
+func IsValidAPIKeyScope(scope string) bool {
+	return scope == ScopeAlbumsRead || scope == ScopeAlbumsWrite || scope == ScopePhotosUpload
+}
+
+// Album expiry policies
+const (
+	ExpiryPolicyDelete  = "delete"
+	ExpiryPolicyArchive = "archive"
+	ExpiryPolicyKeep    = "keep"
+)
+
+// IsValidExpiryPolicy checks if a policy name is known
+func IsValidExpiryPolicy(policy string) bool {
+	return policy == ExpiryPolicyDelete || policy == ExpiryPolicyArchive || policy == ExpiryPolicyKeep
+}
+
 // Album represents a photo album
 type Album struct {
 	ID          int        `json:"id" db:"id"`
@@ -32,6 +444,9 @@ type Album struct {
 	DownloadCount int      `json:"downloadCount" db:"download_count"`
 	ExpiresAt   time.Time  `json:"expiresAt" db:"expires_at"`
 	IsExpired   bool       `json:"isExpired" db:"is_expired"`
+	ExpiryPolicy *string   `json:"expiryPolicy,omitempty" db:"expiry_policy"` // nil follows the owner's policy
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty" db:"archived_at"`
+	WorkspaceID *int       `json:"workspaceId,omitempty" db:"workspace_id"` // nil for personal albums
 	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
 
@@ -41,6 +456,41 @@ type Album struct {
 	PhotographerName string `json:"photographerName,omitempty"`
 }
 
+// IsArchived returns true if the album has been moved to the archive
+func (a *Album) IsArchived() bool {
+	return a.ArchivedAt != nil
+}
+
+// Collection groups several albums of one user under a single share link
+type Collection struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"-" db:"user_id"`
+	Title       string     `json:"title" db:"title"`
+	ShareCode   string     `json:"shareCode" db:"share_code"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	CoverURL    *string    `json:"coverUrl,omitempty" db:"cover_url"`
+	ViewCount   int        `json:"viewCount" db:"view_count"`
+	ExpiresAt   time.Time  `json:"expiresAt" db:"expires_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+
+	// Computed fields (not in DB)
+	AlbumCount  int        `json:"albumCount"`
+}
+
+// AlbumTemplate holds reusable settings for new albums
+type AlbumTemplate struct {
+	ID             int       `json:"id" db:"id"`
+	UserID         int       `json:"-" db:"user_id"`
+	Name           string    `json:"name" db:"name"`
+	Title          *string   `json:"title,omitempty" db:"title"`
+	Description    *string   `json:"description,omitempty" db:"description"`
+	ExpiresInHours *int      `json:"expiresInHours,omitempty" db:"expires_in_hours"`
+	ExpiryPolicy   *string   `json:"expiryPolicy,omitempty" db:"expiry_policy"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
 // Photo represents a photo in an album
 type Photo struct {
 	ID              int        `json:"id" db:"id"`
@@ -57,12 +507,26 @@ type Photo struct {
 	MimeType        string     `json:"mimeType" db:"mime_type"`
 	DownloadCount   int        `json:"downloadCount" db:"download_count"`
 	SortOrder       int        `json:"sortOrder" db:"sort_order"`
+	UploadedBy      *int       `json:"uploadedBy,omitempty" db:"uploaded_by"` // nil for photos added before collaborators
+	HiddenAt        *time.Time `json:"hiddenAt,omitempty" db:"hidden_at"`     // hidden from the public view by an admin
+	HiddenReason    *string    `json:"hiddenReason,omitempty" db:"hidden_reason"`
 	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
 
 	// For public view (original URL not exposed)
 	URL             string     `json:"url,omitempty"`
 }
 
+// AlbumExpiryChange is an audit history entry for an album's expiry
+type AlbumExpiryChange struct {
+	ID           int        `json:"id" db:"id"`
+	AlbumID      int        `json:"-" db:"album_id"`
+	ActorID      *int       `json:"actorId,omitempty" db:"actor_id"` // nil for system changes
+	Action       string     `json:"action" db:"action"`                // create, set, extend, never, extend_link
+	OldExpiresAt *time.Time `json:"oldExpiresAt,omitempty" db:"old_expires_at"`
+	NewExpiresAt time.Time  `json:"newExpiresAt" db:"new_expires_at"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+}
+
 // AlbumAccessLog represents a log entry for album access
 type AlbumAccessLog struct {
 	ID        int        `json:"id" db:"id"`
@@ -74,6 +538,133 @@ type AlbumAccessLog struct {
 	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
 }
 
+// ExpiryReminderCandidate is an album due for an expiry reminder along with its owner
+type ExpiryReminderCandidate struct {
+	Album      Album
+	OwnerEmail string
+	OwnerName  string
+}
+
+// Workspace member roles
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleEditor = "editor"
+	WorkspaceRoleViewer = "viewer"
+)
+
+// IsValidWorkspaceRole checks if a role name is known
+func IsValidWorkspaceRole(role string) bool {
+	return role == WorkspaceRoleOwner || role == WorkspaceRoleEditor || role == WorkspaceRoleViewer
+}
+
+// Workspace is a team that shares ownership of albums
+type Workspace struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedBy *int      `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+
+	// Computed for the requesting member
+	Role        string `json:"role,omitempty"`
+	MemberCount int    `json:"memberCount"`
+	AlbumCount  int    `json:"albumCount"`
+}
+
+// WorkspaceMember is a user's membership in a workspace
+type WorkspaceMember struct {
+	WorkspaceID int       `json:"-" db:"workspace_id"`
+	UserID      int       `json:"userId" db:"user_id"`
+	Role        string    `json:"role" db:"role"` // owner, editor, viewer
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	AvatarURL   *string   `json:"avatarUrl,omitempty"`
+	CreatedAt   time.Time `json:"joinedAt" db:"created_at"`
+}
+
+// WorkspaceInvite is an emailed invitation to join a workspace
+type WorkspaceInvite struct {
+	ID          int        `json:"id" db:"id"`
+	WorkspaceID int        `json:"workspaceId" db:"workspace_id"`
+	Email       string     `json:"email" db:"email"`
+	Role        string     `json:"role" db:"role"`
+	TokenHash   string     `json:"-" db:"token_hash"`
+	InvitedBy   int        `json:"invitedBy" db:"invited_by"`
+	ExpiresAt   time.Time  `json:"expiresAt" db:"expires_at"`
+	AcceptedAt  *time.Time `json:"acceptedAt,omitempty" db:"accepted_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+
+	// Joined
+	WorkspaceName string `json:"workspaceName"`
+}
+
+// AlbumCollaborator grants another user access to a single album
+type AlbumCollaborator struct {
+	AlbumID          int        `json:"albumId" db:"album_id"`
+	UserID           int        `json:"userId" db:"user_id"`
+	CanUpload        bool       `json:"canUpload" db:"can_upload"`
+	CanDelete        bool       `json:"canDelete" db:"can_delete"`
+	CanManageSharing bool       `json:"canManageSharing" db:"can_manage_sharing"`
+	InvitedBy        int        `json:"invitedBy" db:"invited_by"`
+	TokenHash        *string    `json:"-" db:"token_hash"`
+	ExpiresAt        *time.Time `json:"inviteExpiresAt,omitempty" db:"expires_at"`
+	AcceptedAt       *time.Time `json:"acceptedAt,omitempty" db:"accepted_at"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+
+	// Joined
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	AlbumTitle string `json:"albumTitle"`
+}
+
+// IsAccepted checks if the collaborator has accepted the invitation
+func (c *AlbumCollaborator) IsAccepted() bool {
+	return c.AcceptedAt != nil
+}
+
+// Album transfer statuses
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusAccepted  = "accepted"
+	TransferStatusDeclined  = "declined"
+	TransferStatusCancelled = "cancelled"
+)
+
+// AlbumTransfer is a request to hand an album over to another user
+type AlbumTransfer struct {
+	ID           int        `json:"id" db:"id"`
+	AlbumID      int        `json:"albumId" db:"album_id"`
+	FromUserID   int        `json:"fromUserId" db:"from_user_id"`
+	ToUserID     int        `json:"toUserId" db:"to_user_id"`
+	TokenHash    string     `json:"-" db:"token_hash"`
+	Status       string     `json:"status" db:"status"` // pending, accepted, declined, cancelled
+	RekeyStorage bool       `json:"rekeyStorage" db:"rekey_storage"`
+	ExpiresAt    time.Time  `json:"expiresAt" db:"expires_at"`
+	RespondedAt  *time.Time `json:"respondedAt,omitempty" db:"responded_at"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+
+	// Joined for listings
+	AlbumTitle string `json:"albumTitle"`
+	FromName   string `json:"fromName"`
+	FromEmail  string `json:"fromEmail"`
+	ToName     string `json:"toName"`
+	ToEmail    string `json:"toEmail"`
+}
+
+// IsPending checks if a transfer can still be accepted
+func (t *AlbumTransfer) IsPending() bool {
+	return t.Status == TransferStatusPending && t.ExpiresAt.After(time.Now())
+}
+
+// PhotoKeyMove describes a photo whose objects were copied to new storage keys
+type PhotoKeyMove struct {
+	PhotoID         int
+	OSSKey          string
+	OriginalURL     string
+	ThumbnailOSSKey string
+	ThumbnailURL    string
+}
+
 // Stats represents dashboard statistics
 type Stats struct {
 	TotalUsers     int      `json:"totalUsers"`
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const dataExportColumns = `
+	id, user_id, status, oss_key, file_size, error, created_at, completed_at, expires_at
+`
+
+func scanDataExport(row interface{ Scan(...any) error }, e *model.DataExport) error {
+	return row.Scan(
+		&e.ID,
+		&e.UserID,
+		&e.Status,
+		&e.OSSKey,
+		&e.FileSize,
+		&e.Error,
+		&e.CreatedAt,
+		&e.CompletedAt,
+		&e.ExpiresAt,
+	)
+}
+
+// CreateDataExport queues a new data export for a user
+func CreateDataExport(ctx context.Context, e *model.DataExport) error {
+	query := `
+		INSERT INTO data_exports (user_id, status)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	return db.QueryRow(ctx, query, e.UserID, e.Status).Scan(&e.ID, &e.CreatedAt)
+}
+
+// FindLatestDataExport returns a user's most recent data export
+func FindLatestDataExport(ctx context.Context, userID int) (*model.DataExport, error) {
+	query := `SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var e model.DataExport
+	if err := scanDataExport(db.QueryRow(ctx, query, userID), &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// StartDataExport moves a pending export to processing
+func StartDataExport(ctx context.Context, id int) error {
+	query := `
+		UPDATE data_exports SET status = $2
+		WHERE id = $1 AND status = $3
+	`
+	result, err := db.Exec(ctx, query, id, model.DataExportProcessing, model.DataExportPending)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// CompleteDataExport records the uploaded ZIP of a finished export
+func CompleteDataExport(ctx context.Context, id int, ossKey string, fileSize int64, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = $2, oss_key = $3, file_size = $4, completed_at = NOW(), expires_at = $5
+		WHERE id = $1
+	`
+	_, err := db.Exec(ctx, query, id, model.DataExportReady, ossKey, fileSize, expiresAt)
+	return err
+}
+
+// FailDataExport marks an export as failed
+func FailDataExport(ctx context.Context, id int, reason string) error {
+	query := `
+		UPDATE data_exports SET status = $2, error = $3, completed_at = NOW()
+		WHERE id = $1
+	`
+	_, err := db.Exec(ctx, query, id, model.DataExportFailed, reason)
+	return err
+}
+
+// FailStaleDataExports fails exports that were started before the given
+// time and never finished, e.g. because the server restarted
+func FailStaleDataExports(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		UPDATE data_exports SET status = $1, error = 'interrupted', completed_at = NOW()
+		WHERE status IN ($2, $3) AND created_at < $4
+	`
+	result, err := db.Exec(ctx, query,
+		model.DataExportFailed, model.DataExportPending, model.DataExportProcessing, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// GetExpiredDataExports returns finished exports whose download period is over
+func GetExpiredDataExports(ctx context.Context) ([]model.DataExport, error) {
+	query := `SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE (status = $1 AND expires_at < NOW())
+			OR (status = $2 AND completed_at < NOW() - INTERVAL '7 days')
+	`
+
+	rows, err := db.Query(ctx, query, model.DataExportReady, model.DataExportFailed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.DataExport
+	for rows.Next() {
+		var e model.DataExport
+		if err := scanDataExport(rows, &e); err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
+
+// DeleteDataExport removes an export record
+func DeleteDataExport(ctx context.Context, id int) error {
+	_, err := db.Exec(ctx, "DELETE FROM data_exports WHERE id = $1", id)
+	return err
+}
+
+// ScheduleAccountDeletion schedules a user's account to be purged at the given time
+func ScheduleAccountDeletion(ctx context.Context, userID int, scheduledAt time.Time) error {
+	query := `
+		UPDATE users
+		SET deletion_requested_at = NOW(), deletion_scheduled_at = $2
+		WHERE id = $1 AND deletion_scheduled_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, userID, scheduledAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// CancelAccountDeletion cancels a scheduled deletion that has not started yet
+func CancelAccountDeletion(ctx context.Context, userID int) error {
+	query := `
+		UPDATE users
+		SET deletion_requested_at = NULL, deletion_scheduled_at = NULL
+		WHERE id = $1 AND deletion_scheduled_at > NOW()
+	`
+	result, err := db.Exec(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// FindAccountDeletion returns when a user's account is scheduled to be
+// purged, or nil if no deletion is scheduled
+func FindAccountDeletion(ctx context.Context, userID int) (*time.Time, error) {
+	query := `SELECT deletion_scheduled_at FROM users WHERE id = $1`
+
+	var scheduledAt *time.Time
+	err := db.QueryRow(ctx, query, userID).Scan(&scheduledAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return scheduledAt, err
+}
+
+// GetAccountsDueForDeletion returns the IDs of users whose grace period has passed
+func GetAccountsDueForDeletion(ctx context.Context) ([]int, error) {
+	query := `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= NOW()
+		ORDER BY deletion_scheduled_at
+	`
+
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteUser deletes a user row; personal albums, photos, sessions and the
+// other per-user tables go with it through ON DELETE CASCADE. Albums in
+// workspaces that still have other members are handed over first.
+func DeleteUser(ctx context.Context, id int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := handOverWorkspaces(ctx, tx, id); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return tx.Commit(ctx)
+}
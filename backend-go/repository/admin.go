@@ -61,3 +61,40 @@ func GetAccessLogsByAlbum(ctx context.Context, albumID int) ([]model.AlbumAccess
 
 	return logs, rows.Err()
 }
+
+// GetAccessLogsByOwner returns all access logs of a user's albums, oldest first
+func GetAccessLogsByOwner(ctx context.Context, userID int) ([]model.AlbumAccessLog, error) {
+	query := `
+		SELECT l.id, l.album_id, l.ip_address, l.user_agent, l.action, l.photo_id, l.created_at
+		FROM album_access_logs l
+		JOIN albums a ON a.id = l.album_id
+		WHERE a.user_id = $1
+		ORDER BY l.created_at, l.id
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []model.AlbumAccessLog
+	for rows.Next() {
+		var l model.AlbumAccessLog
+		err := rows.Scan(
+			&l.ID,
+			&l.AlbumID,
+			&l.IPAddress,
+			&l.UserAgent,
+			&l.Action,
+			&l.PhotoID,
+			&l.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+
+	return logs, rows.Err()
+}
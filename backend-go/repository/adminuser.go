@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SuspendUser suspends an active account
+func SuspendUser(ctx context.Context, id int, reason *string) error {
+	query := `
+		UPDATE users SET suspended_at = NOW(), suspended_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND suspended_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, id, reason)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// UnsuspendUser lifts a suspension
+func UnsuspendUser(ctx context.Context, id int) error {
+	query := `
+		UPDATE users SET suspended_at = NULL, suspended_reason = NULL, updated_at = NOW()
+		WHERE id = $1 AND suspended_at IS NOT NULL
+	`
+	result, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// FindUserSuspension returns when and why a user was suspended, or a nil
+// time if the account is active
+func FindUserSuspension(ctx context.Context, id int) (*time.Time, *string, error) {
+	query := `SELECT suspended_at, suspended_reason FROM users WHERE id = $1`
+
+	var (
+		suspendedAt *time.Time
+		reason      *string
+	)
+	err := db.QueryRow(ctx, query, id).Scan(&suspendedAt, &reason)
+	if err == pgx.ErrNoRows {
+		return nil, nil, nil
+	}
+	return suspendedAt, reason, err
+}
+
+// FindUserQuota returns a user's limit overrides
+func FindUserQuota(ctx context.Context, id int) (*model.UserQuota, error) {
+	query := `
+		SELECT max_albums, max_photos_per_album, storage_quota_bytes
+		FROM users WHERE id = $1
+	`
+
+	var q model.UserQuota
+	err := db.QueryRow(ctx, query, id).Scan(&q.MaxAlbums, &q.MaxPhotosPerAlbum, &q.StorageQuotaBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SetUserQuota replaces a user's limit overrides; nil fields reset to the defaults
+func SetUserQuota(ctx context.Context, id int, q *model.UserQuota) error {
+	query := `
+		UPDATE users
+		SET max_albums = $2, max_photos_per_album = $3, storage_quota_bytes = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := db.Exec(ctx, query, id, q.MaxAlbums, q.MaxPhotosPerAlbum, q.StorageQuotaBytes)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// GetStorageUsedByUser returns the total size of the photos in a user's albums
+func GetStorageUsedByUser(ctx context.Context, userID int) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(p.file_size), 0)
+		FROM photos p
+		JOIN albums a ON a.id = p.album_id
+		WHERE a.user_id = $1
+	`
+
+	var used int64
+	err := db.QueryRow(ctx, query, userID).Scan(&used)
+	return used, err
+}
+
+// GetAlbumStorageSize returns the total size of an album's photos
+func GetAlbumStorageSize(ctx context.Context, albumID int) (int64, error) {
+	var size int64
+	err := db.QueryRow(ctx, "SELECT COALESCE(SUM(file_size), 0) FROM photos WHERE album_id = $1", albumID).Scan(&size)
+	return size, err
+}
@@ -12,8 +12,9 @@ import (
 // CreateAlbum creates a new album
 func CreateAlbum(ctx context.Context, album *model.Album) error {
 	query := `
-		INSERT INTO albums (user_id, title, share_code, description, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO albums (user_id, title, share_code, description, expires_at, expiry_policy,
+			workspace_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -23,6 +24,8 @@ func CreateAlbum(ctx context.Context, album *model.Album) error {
 		album.ShareCode,
 		album.Description,
 		album.ExpiresAt,
+		album.ExpiryPolicy,
+		album.WorkspaceID,
 	).Scan(&album.ID, &album.CreatedAt, &album.UpdatedAt)
 
 	return err
@@ -33,7 +36,7 @@ func FindAlbumByID(ctx context.Context, id int) (*model.Album, error) {
 	query := `
 		SELECT id, user_id, title, share_code, description, cover_url,
 			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
 		FROM albums WHERE id = $1
 	`
 
@@ -50,6 +53,9 @@ func FindAlbumByID(ctx context.Context, id int) (*model.Album, error) {
 		&album.DownloadCount,
 		&album.ExpiresAt,
 		&album.IsExpired,
+		&album.ExpiryPolicy,
+		&album.ArchivedAt,
+		&album.WorkspaceID,
 		&album.CreatedAt,
 		&album.UpdatedAt,
 	)
@@ -65,7 +71,7 @@ func FindAlbumByShareCode(ctx context.Context, shareCode string) (*model.Album,
 	query := `
 		SELECT id, user_id, title, share_code, description, cover_url,
 			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
 		FROM albums WHERE share_code = $1
 	`
 
@@ -82,6 +88,9 @@ func FindAlbumByShareCode(ctx context.Context, shareCode string) (*model.Album,
 		&album.DownloadCount,
 		&album.ExpiresAt,
 		&album.IsExpired,
+		&album.ExpiryPolicy,
+		&album.ArchivedAt,
+		&album.WorkspaceID,
 		&album.CreatedAt,
 		&album.UpdatedAt,
 	)
@@ -92,13 +101,14 @@ func FindAlbumByShareCode(ctx context.Context, shareCode string) (*model.Album,
 	return &album, nil
 }
 
-// FindAlbumByIDWithUser finds an album and checks ownership
+// FindAlbumByIDWithUser finds a personal album and checks ownership;
+// workspace albums belong to the workspace rather than their creator
 func FindAlbumByIDWithUser(ctx context.Context, id, userID int) (*model.Album, error) {
 	query := `
 		SELECT id, user_id, title, share_code, description, cover_url,
 			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
-		FROM albums WHERE id = $1 AND user_id = $2
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
+		FROM albums WHERE id = $1 AND user_id = $2 AND workspace_id IS NULL
 	`
 
 	var album model.Album
@@ -114,6 +124,9 @@ func FindAlbumByIDWithUser(ctx context.Context, id, userID int) (*model.Album, e
 		&album.DownloadCount,
 		&album.ExpiresAt,
 		&album.IsExpired,
+		&album.ExpiryPolicy,
+		&album.ArchivedAt,
+		&album.WorkspaceID,
 		&album.CreatedAt,
 		&album.UpdatedAt,
 	)
@@ -124,13 +137,15 @@ func FindAlbumByIDWithUser(ctx context.Context, id, userID int) (*model.Album, e
 	return &album, nil
 }
 
-// GetAlbumsByUser returns paginated albums for a user
+// GetAlbumsByUser returns paginated personal albums for a user. Workspace
+// albums are listed through their workspace, so creators who leave it no
+// longer see them
 func GetAlbumsByUser(ctx context.Context, userID int, page, limit int) ([]model.Album, int, error) {
 	offset := (page - 1) * limit
 
 	// Get total count
 	var total int
-	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM albums WHERE user_id = $1", userID).Scan(&total)
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM albums WHERE user_id = $1 AND workspace_id IS NULL", userID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -139,8 +154,8 @@ func GetAlbumsByUser(ctx context.Context, userID int, page, limit int) ([]model.
 	query := `
 		SELECT id, user_id, title, share_code, description, cover_url,
 			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
-		FROM albums WHERE user_id = $1
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
+		FROM albums WHERE user_id = $1 AND workspace_id IS NULL
 		ORDER BY created_at DESC LIMIT $2 OFFSET $3
 	`
 
@@ -165,6 +180,9 @@ func GetAlbumsByUser(ctx context.Context, userID int, page, limit int) ([]model.
 			&a.DownloadCount,
 			&a.ExpiresAt,
 			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		)
@@ -177,17 +195,62 @@ func GetAlbumsByUser(ctx context.Context, userID int, page, limit int) ([]model.
 	return albums, total, rows.Err()
 }
 
-// UpdateAlbum updates album fields
-func UpdateAlbum(ctx context.Context, id, userID int, title, description *string, expiresAt *time.Time) error {
+// GetAllAlbumsByUser returns every album a user owns, oldest first
+func GetAllAlbumsByUser(ctx context.Context, userID int) ([]model.Album, error) {
+	query := `
+		SELECT id, user_id, title, share_code, description, cover_url,
+			photo_count, view_count, download_count, expires_at, is_expired,
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
+		FROM albums WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []model.Album
+	for rows.Next() {
+		var a model.Album
+		err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Title,
+			&a.ShareCode,
+			&a.Description,
+			&a.CoverURL,
+			&a.PhotoCount,
+			&a.ViewCount,
+			&a.DownloadCount,
+			&a.ExpiresAt,
+			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, a)
+	}
+
+	return albums, rows.Err()
+}
+
+// UpdateAlbum updates album fields (expiry changes go through SetAlbumExpiry)
+func UpdateAlbum(ctx context.Context, id, userID int, title, description *string) error {
 	query := `
 		UPDATE albums
 		SET title = COALESCE($1, title),
 			description = COALESCE($2, description),
-			expires_at = COALESCE($3, expires_at),
 			updated_at = NOW()
-		WHERE id = $4 AND user_id = $5
+		WHERE id = $3 AND user_id = $4
 	`
-	_, err := db.Exec(ctx, query, title, description, expiresAt, id, userID)
+	_, err := db.Exec(ctx, query, title, description, id, userID)
 	return err
 }
 
@@ -271,7 +334,7 @@ func GetAllAlbumsPaginated(ctx context.Context, page, limit int, status string)
 	query := `
 		SELECT id, user_id, title, share_code, description, cover_url,
 			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
 		FROM albums ` + whereClause + `
 		ORDER BY created_at DESC LIMIT $` + string(rune('0'+argNum)) + ` OFFSET $` + string(rune('0'+argNum+1))
 	args = append(args, limit, offset)
@@ -297,6 +360,9 @@ func GetAllAlbumsPaginated(ctx context.Context, page, limit int, status string)
 			&a.DownloadCount,
 			&a.ExpiresAt,
 			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		)
@@ -314,7 +380,7 @@ func GetRecentAlbums(ctx context.Context, limit int) ([]model.Album, error) {
 	query := `
 		SELECT id, user_id, title, share_code, description, cover_url,
 			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
 		FROM albums ORDER BY created_at DESC LIMIT $1
 	`
 
@@ -339,6 +405,9 @@ func GetRecentAlbums(ctx context.Context, limit int) ([]model.Album, error) {
 			&a.DownloadCount,
 			&a.ExpiresAt,
 			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		)
@@ -365,15 +434,21 @@ func MarkAlbumsExpired(ctx context.Context) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
-// GetExpiredAlbumsForDeletion returns albums expired for more than 1 day
-func GetExpiredAlbumsForDeletion(ctx context.Context) ([]model.Album, error) {
+// GetExpiredAlbumsForCleanup returns albums expired for more than 1 day that
+// still need action. ExpiryPolicy is resolved against the owner's default,
+// so it is always set; albums with the keep policy or already archived are skipped.
+func GetExpiredAlbumsForCleanup(ctx context.Context) ([]model.Album, error) {
 	query := `
-		SELECT id, user_id, title, share_code, description, cover_url,
-			photo_count, view_count, download_count, expires_at, is_expired,
-			created_at, updated_at
-		FROM albums
-		WHERE is_expired = true AND expires_at <= NOW() - INTERVAL '1 day'
-		ORDER BY expires_at ASC
+		SELECT a.id, a.user_id, a.title, a.share_code, a.description, a.cover_url,
+			a.photo_count, a.view_count, a.download_count, a.expires_at, a.is_expired,
+			COALESCE(a.expiry_policy, u.expiry_policy, 'delete'), a.archived_at,
+			a.workspace_id, a.created_at, a.updated_at
+		FROM albums a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.is_expired = true AND a.expires_at <= NOW() - INTERVAL '1 day'
+			AND a.archived_at IS NULL
+			AND COALESCE(a.expiry_policy, u.expiry_policy, 'delete') <> 'keep'
+		ORDER BY a.expires_at ASC
 	`
 
 	rows, err := db.Query(ctx, query)
@@ -397,6 +472,9 @@ func GetExpiredAlbumsForDeletion(ctx context.Context) ([]model.Album, error) {
 			&a.DownloadCount,
 			&a.ExpiresAt,
 			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		)
@@ -409,6 +487,38 @@ func GetExpiredAlbumsForDeletion(ctx context.Context) ([]model.Album, error) {
 	return albums, rows.Err()
 }
 
+// MarkAlbumArchived drops renditions and flags the album as archived
+func MarkAlbumArchived(ctx context.Context, albumID int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE photos SET thumbnail_url = '', thumbnail_oss_key = '' WHERE album_id = $1`, albumID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE albums
+		SET archived_at = NOW(), cover_url = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, albumID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateAlbumExpiryPolicy sets the album's expiry policy (nil follows the owner)
+func UpdateAlbumExpiryPolicy(ctx context.Context, id, userID int, policy *string) error {
+	query := `UPDATE albums SET expiry_policy = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3`
+	_, err := db.Exec(ctx, query, policy, id, userID)
+	return err
+}
+
 // DeleteAlbumByID deletes an album by ID (admin/cleanup)
 func DeleteAlbumByID(ctx context.Context, albumID int) error {
 	query := `DELETE FROM albums WHERE id = $1`
@@ -456,7 +566,9 @@ func GetAllAlbumsWithUserPaginated(ctx context.Context, page, limit int, status
 	// Get albums with user info
 	query := fmt.Sprintf(`
 		SELECT a.id, a.title, a.share_code, a.photo_count, a.view_count, a.download_count,
-			a.expires_at, a.is_expired, a.created_at, u.name as photographer_name, u.email as photographer_email
+			a.expires_at, a.is_expired, a.created_at, u.name as photographer_name, u.email as photographer_email,
+			(SELECT COUNT(*) FROM album_reports r WHERE r.album_id = a.id AND r.status = 'open') as open_reports,
+			EXISTS (SELECT 1 FROM album_takedowns t WHERE t.album_id = a.id) as taken_down
 		FROM albums a
 		JOIN users u ON a.user_id = u.id
 		%s
@@ -474,14 +586,15 @@ func GetAllAlbumsWithUserPaginated(ctx context.Context, page, limit int, status
 	var albums []gin.H
 	for rows.Next() {
 		var (
-			id, photoCount, viewCount, downloadCount int
+			id, photoCount, viewCount, downloadCount, openReports int
 			title, shareCode, photographerName, photographerEmail string
 			expiresAt, createdAt time.Time
-			isExpired bool
+			isExpired, takenDown bool
 		)
 		err := rows.Scan(
 			&id, &title, &shareCode, &photoCount, &viewCount, &downloadCount,
 			&expiresAt, &isExpired, &createdAt, &photographerName, &photographerEmail,
+			&openReports, &takenDown,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -499,6 +612,8 @@ func GetAllAlbumsWithUserPaginated(ctx context.Context, page, limit int, status
 			"created_at":        createdAt,
 			"photographer_name": photographerName,
 			"photographer_email": photographerEmail,
+			"open_reports":      openReports,
+			"taken_down":        takenDown,
 			"isExpired":         isExpired || expiresAt.Before(now),
 		})
 	}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+
+	"picshare/model"
+)
+
+const apiKeyColumns = `
+	id, user_id, name, key_prefix, scopes, expires_at, last_used_at, last_used_ip, created_at
+`
+
+func scanAPIKey(row interface{ Scan(...any) error }, k *model.APIKey) error {
+	return row.Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.KeyPrefix,
+		&k.Scopes,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.LastUsedIP,
+		&k.CreatedAt,
+	)
+}
+
+// CreateAPIKey stores a new API key by its hash
+func CreateAPIKey(ctx context.Context, k *model.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, key_hash, key_prefix, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	return db.QueryRow(ctx, query,
+		k.UserID,
+		k.Name,
+		keyHash,
+		k.KeyPrefix,
+		k.Scopes,
+		k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+// GetAPIKeysByUser returns a user's keys that have not been revoked, newest first
+func GetAPIKeysByUser(ctx context.Context, userID int) ([]model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var k model.APIKey
+		if err := scanAPIKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// CountActiveAPIKeys counts a user's keys that are neither revoked nor expired
+func CountActiveAPIKeys(ctx context.Context, userID int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	var count int
+	err := db.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// RevokeAPIKey revokes one of a user's keys
+func RevokeAPIKey(ctx context.Context, id, userID int) error {
+	query := `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// FindAPIKeyUser looks up a usable key by its hash, records the use and
+// returns the key together with the current state of its owner
+func FindAPIKeyUser(ctx context.Context, keyHash, ip string) (*model.APIKey, *model.User, error) {
+	query := `
+		UPDATE api_keys k SET last_used_at = NOW(), last_used_ip = $2
+		FROM users u
+		WHERE k.key_hash = $1 AND u.id = k.user_id
+		AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		RETURNING k.id, k.user_id, k.name, k.key_prefix, k.scopes, k.expires_at,
+			k.last_used_at, k.last_used_ip, k.created_at,
+			u.id, u.email, u.name, u.role, u.email_verified, u.suspended_at, u.created_at
+	`
+
+	var (
+		k    model.APIKey
+		user model.User
+	)
+	err := db.QueryRow(ctx, query, keyHash, ip).Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.KeyPrefix,
+		&k.Scopes,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.LastUsedIP,
+		&k.CreatedAt,
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.EmailVerified,
+		&user.SuspendedAt,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &k, &user, nil
+}
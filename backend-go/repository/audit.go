@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"picshare/model"
+)
+
+// AuditEventFilter narrows down audit events; zero values match everything
+type AuditEventFilter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   int
+	IPAddress  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// whereClause builds the WHERE clause and arguments for the filter
+func (f AuditEventFilter) whereClause() (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.ActorID > 0 {
+		add("(actor_id = $%d OR impersonated_by = $%[1]d)", f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID > 0 {
+		add("target_id = $%d", f.TargetID)
+	}
+	if f.IPAddress != "" {
+		add("ip_address = $%d", f.IPAddress)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+const auditEventColumns = `
+	id, actor_id, actor_email, impersonated_by, action, target_type, target_id,
+	details, ip_address, user_agent, created_at, prev_hash, hash
+`
+
+func scanAuditEvent(row interface{ Scan(...any) error }) (*model.AuditEvent, error) {
+	var e model.AuditEvent
+	err := row.Scan(
+		&e.ID,
+		&e.ActorID,
+		&e.ActorEmail,
+		&e.ImpersonatedBy,
+		&e.Action,
+		&e.TargetType,
+		&e.TargetID,
+		&e.Details,
+		&e.IPAddress,
+		&e.UserAgent,
+		&e.CreatedAt,
+		&e.PrevHash,
+		&e.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// AppendAuditEvent adds an event to the end of the audit chain. The table is
+// locked against other writers so that every event links to the one before
+// it; hash computes the event's hash once PrevHash is set.
+func AppendAuditEvent(ctx context.Context, e *model.AuditEvent, hash func(*model.AuditEvent) string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE audit_events IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&e.PrevHash)
+	if err == pgx.ErrNoRows {
+		e.PrevHash = model.AuditGenesisHash
+	} else if err != nil {
+		return err
+	}
+	e.Hash = hash(e)
+
+	query := `
+		INSERT INTO audit_events (actor_id, actor_email, impersonated_by, action, target_type,
+			target_id, details, ip_address, user_agent, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, query,
+		e.ActorID,
+		e.ActorEmail,
+		e.ImpersonatedBy,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Details,
+		e.IPAddress,
+		e.UserAgent,
+		e.CreatedAt,
+		e.PrevHash,
+		e.Hash,
+	).Scan(&e.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetAuditEvents returns audit events matching the filter, newest first
+func GetAuditEvents(ctx context.Context, page, limit int, filter AuditEventFilter) ([]model.AuditEvent, int, error) {
+	offset := (page - 1) * limit
+	whereClause, args := filter.whereClause()
+
+	var total int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM audit_events "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + auditEventColumns + ` FROM audit_events ` + whereClause + fmt.Sprintf(`
+		ORDER BY id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []model.AuditEvent
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, total, rows.Err()
+}
+
+// GetAuditEventsForExport returns up to max audit events matching the
+// filter, oldest first
+func GetAuditEventsForExport(ctx context.Context, filter AuditEventFilter, max int) ([]model.AuditEvent, error) {
+	whereClause, args := filter.whereClause()
+	query := `SELECT ` + auditEventColumns + ` FROM audit_events ` + whereClause + fmt.Sprintf(`
+		ORDER BY id ASC
+		LIMIT $%d`, len(args)+1)
+
+	rows, err := db.Query(ctx, query, append(args, max)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.AuditEvent
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, rows.Err()
+}
+
+// WalkAuditEvents calls fn for every audit event in chain order, stopping at
+// the first error fn returns
+func WalkAuditEvents(ctx context.Context, fn func(*model.AuditEvent) error) error {
+	rows, err := db.Query(ctx, `SELECT `+auditEventColumns+` FROM audit_events ORDER BY id ASC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+
+	"picshare/model"
+)
+
+const albumCollaboratorColumns = `
+	c.album_id, c.user_id, c.can_upload, c.can_delete, c.can_manage_sharing, c.invited_by,
+	c.token_hash, c.expires_at, c.accepted_at, c.created_at, u.name, u.email, a.title
+`
+
+const albumCollaboratorJoins = `
+	FROM album_collaborators c
+	JOIN users u ON u.id = c.user_id
+	JOIN albums a ON a.id = c.album_id
+`
+
+// scanAlbumCollaborator scans a row selected with albumCollaboratorColumns
+func scanAlbumCollaborator(row interface{ Scan(...any) error }) (*model.AlbumCollaborator, error) {
+	var c model.AlbumCollaborator
+	err := row.Scan(
+		&c.AlbumID,
+		&c.UserID,
+		&c.CanUpload,
+		&c.CanDelete,
+		&c.CanManageSharing,
+		&c.InvitedBy,
+		&c.TokenHash,
+		&c.ExpiresAt,
+		&c.AcceptedAt,
+		&c.CreatedAt,
+		&c.Name,
+		&c.Email,
+		&c.AlbumTitle,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// InviteAlbumCollaborator creates a pending collaborator invitation, or
+// renews the invitation and permissions if one is still pending
+func InviteAlbumCollaborator(ctx context.Context, c *model.AlbumCollaborator) error {
+	query := `
+		INSERT INTO album_collaborators (album_id, user_id, can_upload, can_delete,
+			can_manage_sharing, invited_by, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (album_id, user_id) DO UPDATE
+		SET can_upload = EXCLUDED.can_upload,
+			can_delete = EXCLUDED.can_delete,
+			can_manage_sharing = EXCLUDED.can_manage_sharing,
+			invited_by = EXCLUDED.invited_by,
+			token_hash = EXCLUDED.token_hash,
+			expires_at = EXCLUDED.expires_at
+		WHERE album_collaborators.accepted_at IS NULL
+		RETURNING created_at
+	`
+
+	err := db.QueryRow(ctx, query,
+		c.AlbumID,
+		c.UserID,
+		c.CanUpload,
+		c.CanDelete,
+		c.CanManageSharing,
+		c.InvitedBy,
+		c.TokenHash,
+		c.ExpiresAt,
+	).Scan(&c.CreatedAt)
+
+	return err
+}
+
+// FindAlbumCollaborator finds a collaborator (accepted or pending) of an album
+func FindAlbumCollaborator(ctx context.Context, albumID, userID int) (*model.AlbumCollaborator, error) {
+	query := `SELECT ` + albumCollaboratorColumns + albumCollaboratorJoins + `
+		WHERE c.album_id = $1 AND c.user_id = $2`
+	return scanAlbumCollaborator(db.QueryRow(ctx, query, albumID, userID))
+}
+
+// FindAlbumCollaboratorByTokenHash finds a pending, unexpired invitation
+func FindAlbumCollaboratorByTokenHash(ctx context.Context, tokenHash string) (*model.AlbumCollaborator, error) {
+	query := `SELECT ` + albumCollaboratorColumns + albumCollaboratorJoins + `
+		WHERE c.token_hash = $1 AND c.accepted_at IS NULL AND c.expires_at > NOW()`
+	return scanAlbumCollaborator(db.QueryRow(ctx, query, tokenHash))
+}
+
+// GetAlbumCollaborators returns all collaborators of an album, including pending invitations
+func GetAlbumCollaborators(ctx context.Context, albumID int) ([]model.AlbumCollaborator, error) {
+	query := `SELECT ` + albumCollaboratorColumns + albumCollaboratorJoins + `
+		WHERE c.album_id = $1 ORDER BY c.created_at ASC`
+
+	rows, err := db.Query(ctx, query, albumID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collaborators []model.AlbumCollaborator
+	for rows.Next() {
+		c, err := scanAlbumCollaborator(rows)
+		if err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, *c)
+	}
+
+	return collaborators, rows.Err()
+}
+
+// UpdateAlbumCollaboratorPermissions changes a collaborator's permissions
+func UpdateAlbumCollaboratorPermissions(ctx context.Context, c *model.AlbumCollaborator) error {
+	query := `
+		UPDATE album_collaborators
+		SET can_upload = $1, can_delete = $2, can_manage_sharing = $3
+		WHERE album_id = $4 AND user_id = $5
+	`
+	result, err := db.Exec(ctx, query, c.CanUpload, c.CanDelete, c.CanManageSharing, c.AlbumID, c.UserID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// AcceptAlbumCollaborator marks a pending invitation accepted
+func AcceptAlbumCollaborator(ctx context.Context, albumID, userID int) error {
+	query := `
+		UPDATE album_collaborators
+		SET accepted_at = NOW(), token_hash = NULL, expires_at = NULL
+		WHERE album_id = $1 AND user_id = $2 AND accepted_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, albumID, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// DeleteAlbumCollaborator removes a collaborator or revokes a pending invitation
+func DeleteAlbumCollaborator(ctx context.Context, albumID, userID int) error {
+	query := `DELETE FROM album_collaborators WHERE album_id = $1 AND user_id = $2`
+	_, err := db.Exec(ctx, query, albumID, userID)
+	return err
+}
+
+// GetCollaboratingAlbums returns the albums a user has accepted to collaborate on
+func GetCollaboratingAlbums(ctx context.Context, userID int) ([]model.Album, error) {
+	query := `
+		SELECT a.id, a.user_id, a.title, a.share_code, a.description, a.cover_url,
+			a.photo_count, a.view_count, a.download_count, a.expires_at, a.is_expired,
+			a.expiry_policy, a.archived_at, a.workspace_id, a.created_at, a.updated_at
+		FROM albums a
+		JOIN album_collaborators c ON c.album_id = a.id
+		WHERE c.user_id = $1 AND c.accepted_at IS NOT NULL
+		ORDER BY a.created_at DESC
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []model.Album
+	for rows.Next() {
+		var a model.Album
+		err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Title,
+			&a.ShareCode,
+			&a.Description,
+			&a.CoverURL,
+			&a.PhotoCount,
+			&a.ViewCount,
+			&a.DownloadCount,
+			&a.ExpiresAt,
+			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, a)
+	}
+
+	return albums, rows.Err()
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+)
+
+// CreateCollection creates a new collection with its member albums
+func CreateCollection(ctx context.Context, collection *model.Collection, albumIDs []int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO collections (user_id, title, share_code, description, cover_url, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		collection.UserID,
+		collection.Title,
+		collection.ShareCode,
+		collection.Description,
+		collection.CoverURL,
+		collection.ExpiresAt,
+	).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i, albumID := range albumIDs {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO collection_albums (collection_id, album_id, sort_order) VALUES ($1, $2, $3)
+		`, collection.ID, albumID, i)
+		if err != nil {
+			return err
+		}
+	}
+	collection.AlbumCount = len(albumIDs)
+
+	return tx.Commit(ctx)
+}
+
+// FindCollectionByIDWithUser finds a collection and checks ownership
+func FindCollectionByIDWithUser(ctx context.Context, id, userID int) (*model.Collection, error) {
+	query := `
+		SELECT c.id, c.user_id, c.title, c.share_code, c.description, c.cover_url,
+			c.view_count, c.expires_at, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM collection_albums ca WHERE ca.collection_id = c.id)
+		FROM collections c WHERE c.id = $1 AND c.user_id = $2
+	`
+	return scanCollection(db.QueryRow(ctx, query, id, userID))
+}
+
+// FindCollectionByShareCode finds a collection by share code
+func FindCollectionByShareCode(ctx context.Context, shareCode string) (*model.Collection, error) {
+	query := `
+		SELECT c.id, c.user_id, c.title, c.share_code, c.description, c.cover_url,
+			c.view_count, c.expires_at, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM collection_albums ca WHERE ca.collection_id = c.id)
+		FROM collections c WHERE c.share_code = $1
+	`
+	return scanCollection(db.QueryRow(ctx, query, shareCode))
+}
+
+// scanCollection scans a single collection row
+func scanCollection(row interface{ Scan(dest ...any) error }) (*model.Collection, error) {
+	var c model.Collection
+	err := row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.Title,
+		&c.ShareCode,
+		&c.Description,
+		&c.CoverURL,
+		&c.ViewCount,
+		&c.ExpiresAt,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.AlbumCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCollectionsByUser returns paginated collections for a user
+func GetCollectionsByUser(ctx context.Context, userID int, page, limit int) ([]model.Collection, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM collections WHERE user_id = $1", userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT c.id, c.user_id, c.title, c.share_code, c.description, c.cover_url,
+			c.view_count, c.expires_at, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM collection_albums ca WHERE ca.collection_id = c.id)
+		FROM collections c WHERE c.user_id = $1
+		ORDER BY c.created_at DESC LIMIT $2 OFFSET $3
+	`
+
+	rows, err := db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var collections []model.Collection
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		collections = append(collections, *c)
+	}
+
+	return collections, total, rows.Err()
+}
+
+// UpdateCollection updates collection fields
+func UpdateCollection(ctx context.Context, id, userID int, title, description, coverURL *string, expiresAt *time.Time) error {
+	query := `
+		UPDATE collections
+		SET title = COALESCE($1, title),
+			description = COALESCE($2, description),
+			cover_url = COALESCE($3, cover_url),
+			expires_at = COALESCE($4, expires_at),
+			updated_at = NOW()
+		WHERE id = $5 AND user_id = $6
+	`
+	_, err := db.Exec(ctx, query, title, description, coverURL, expiresAt, id, userID)
+	return err
+}
+
+// SetCollectionAlbums replaces the member albums of a collection
+func SetCollectionAlbums(ctx context.Context, collectionID int, albumIDs []int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM collection_albums WHERE collection_id = $1`, collectionID)
+	if err != nil {
+		return err
+	}
+
+	for i, albumID := range albumIDs {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO collection_albums (collection_id, album_id, sort_order) VALUES ($1, $2, $3)
+		`, collectionID, albumID, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteCollection deletes a collection (member albums are kept)
+func DeleteCollection(ctx context.Context, id, userID int) error {
+	query := `DELETE FROM collections WHERE id = $1 AND user_id = $2`
+	_, err := db.Exec(ctx, query, id, userID)
+	return err
+}
+
+// IncrementCollectionViewCount increments view count
+func IncrementCollectionViewCount(ctx context.Context, collectionID int) error {
+	query := `UPDATE collections SET view_count = view_count + 1 WHERE id = $1`
+	_, err := db.Exec(ctx, query, collectionID)
+	return err
+}
+
+// CountAlbumsOwnedByUser counts how many of the given albums are personal
+// albums of a user
+func CountAlbumsOwnedByUser(ctx context.Context, albumIDs []int, userID int) (int, error) {
+	var count int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM albums WHERE id = ANY($1) AND user_id = $2 AND workspace_id IS NULL", albumIDs, userID).Scan(&count)
+	return count, err
+}
+
+// GetCollectionAlbums returns the member albums of a collection in order
+func GetCollectionAlbums(ctx context.Context, collectionID int) ([]model.Album, error) {
+	query := `
+		SELECT a.id, a.user_id, a.title, a.share_code, a.description, a.cover_url,
+			a.photo_count, a.view_count, a.download_count, a.expires_at, a.is_expired,
+			a.expiry_policy, a.archived_at, a.workspace_id, a.created_at, a.updated_at
+		FROM albums a
+		JOIN collection_albums ca ON ca.album_id = a.id
+		WHERE ca.collection_id = $1
+		ORDER BY ca.sort_order ASC, a.created_at ASC
+	`
+
+	rows, err := db.Query(ctx, query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []model.Album
+	for rows.Next() {
+		var a model.Album
+		err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Title,
+			&a.ShareCode,
+			&a.Description,
+			&a.CoverURL,
+			&a.PhotoCount,
+			&a.ViewCount,
+			&a.DownloadCount,
+			&a.ExpiresAt,
+			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, a)
+	}
+
+	return albums, rows.Err()
+}
+
+// FindCollectionAlbum finds a member album of a collection
+func FindCollectionAlbum(ctx context.Context, collectionID, albumID int) (*model.Album, error) {
+	query := `
+		SELECT a.id, a.user_id, a.title, a.share_code, a.description, a.cover_url,
+			a.photo_count, a.view_count, a.download_count, a.expires_at, a.is_expired,
+			a.expiry_policy, a.archived_at, a.workspace_id, a.created_at, a.updated_at
+		FROM albums a
+		JOIN collection_albums ca ON ca.album_id = a.id
+		WHERE ca.collection_id = $1 AND a.id = $2
+	`
+
+	var album model.Album
+	err := db.QueryRow(ctx, query, collectionID, albumID).Scan(
+		&album.ID,
+		&album.UserID,
+		&album.Title,
+		&album.ShareCode,
+		&album.Description,
+		&album.CoverURL,
+		&album.PhotoCount,
+		&album.ViewCount,
+		&album.DownloadCount,
+		&album.ExpiresAt,
+		&album.IsExpired,
+		&album.ExpiryPolicy,
+		&album.ArchivedAt,
+		&album.WorkspaceID,
+		&album.CreatedAt,
+		&album.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
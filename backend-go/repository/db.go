@@ -6,11 +6,17 @@ import (
 	"picshare/config"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var db *pgxpool.Pool
 
+// querier is implemented by both the pool and transactions
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // InitDB initializes the database connection pool
 func InitDB() error {
 	cfg := config.Get()
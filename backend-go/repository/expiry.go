@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+)
+
+// SetAlbumExpiry sets a new expiry and records it in the expiry history.
+// The new expiry is always in the future, so the album is revived if it
+// had expired or been archived.
+func SetAlbumExpiry(ctx context.Context, albumID int, expiresAt time.Time, change *model.AlbumExpiryChange) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		UPDATE albums a
+		SET expires_at = $1, is_expired = false, archived_at = NULL, updated_at = NOW()
+		FROM albums old
+		WHERE a.id = $2 AND old.id = a.id
+		RETURNING old.expires_at
+	`, expiresAt, albumID).Scan(&change.OldExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	change.AlbumID = albumID
+	change.NewExpiresAt = expiresAt
+	if err := insertAlbumExpiryChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateAlbumExpiryChange records an expiry history entry
+func CreateAlbumExpiryChange(ctx context.Context, change *model.AlbumExpiryChange) error {
+	return insertAlbumExpiryChange(ctx, db, change)
+}
+
+// insertAlbumExpiryChange inserts a history entry using a pool or transaction
+func insertAlbumExpiryChange(ctx context.Context, q querier, change *model.AlbumExpiryChange) error {
+	query := `
+		INSERT INTO album_expiry_history (album_id, actor_id, action, old_expires_at, new_expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return q.QueryRow(ctx, query,
+		change.AlbumID,
+		change.ActorID,
+		change.Action,
+		change.OldExpiresAt,
+		change.NewExpiresAt,
+	).Scan(&change.ID, &change.CreatedAt)
+}
+
+// GetAlbumExpiryHistory returns the expiry history of an album (newest first)
+func GetAlbumExpiryHistory(ctx context.Context, albumID int) ([]model.AlbumExpiryChange, error) {
+	query := `
+		SELECT id, album_id, actor_id, action, old_expires_at, new_expires_at, created_at
+		FROM album_expiry_history
+		WHERE album_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := db.Query(ctx, query, albumID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []model.AlbumExpiryChange
+	for rows.Next() {
+		var h model.AlbumExpiryChange
+		err := rows.Scan(
+			&h.ID,
+			&h.AlbumID,
+			&h.ActorID,
+			&h.Action,
+			&h.OldExpiresAt,
+			&h.NewExpiresAt,
+			&h.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
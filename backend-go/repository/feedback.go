@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"picshare/model"
+)
+
+// CreateFeedback stores a feedback message
+func CreateFeedback(ctx context.Context, f *model.Feedback) error {
+	query := `
+		INSERT INTO feedback (user_id, user_name, content, contact, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at, updated_at
+	`
+	return db.QueryRow(ctx, query,
+		f.UserID,
+		f.UserName,
+		f.Content,
+		f.Contact,
+		f.IPAddress,
+		f.UserAgent,
+	).Scan(&f.ID, &f.Status, &f.CreatedAt, &f.UpdatedAt)
+}
+
+// CreateFeedbackImage links an uploaded image to its feedback
+func CreateFeedbackImage(ctx context.Context, img *model.FeedbackImage) error {
+	query := `
+		INSERT INTO feedback_images (feedback_id, oss_key, url)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	return db.QueryRow(ctx, query, img.FeedbackID, img.OSSKey, img.URL).Scan(&img.ID, &img.CreatedAt)
+}
+
+const feedbackColumns = `
+	f.id, f.user_id, f.user_name, u.email, f.content, f.contact, f.status, f.admin_note,
+	f.ip_address, f.user_agent, f.resolved_at, f.created_at, f.updated_at,
+	(SELECT COUNT(*) FROM feedback_images i WHERE i.feedback_id = f.id),
+	(SELECT COUNT(*) FROM feedback_replies r WHERE r.feedback_id = f.id)
+`
+
+func scanFeedback(row interface{ Scan(...any) error }) (*model.Feedback, error) {
+	var f model.Feedback
+	err := row.Scan(
+		&f.ID,
+		&f.UserID,
+		&f.UserName,
+		&f.UserEmail,
+		&f.Content,
+		&f.Contact,
+		&f.Status,
+		&f.AdminNote,
+		&f.IPAddress,
+		&f.UserAgent,
+		&f.ResolvedAt,
+		&f.CreatedAt,
+		&f.UpdatedAt,
+		&f.ImageCount,
+		&f.ReplyCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// FindFeedback finds feedback by ID
+func FindFeedback(ctx context.Context, id int) (*model.Feedback, error) {
+	query := `SELECT ` + feedbackColumns + `
+		FROM feedback f
+		LEFT JOIN users u ON u.id = f.user_id
+		WHERE f.id = $1`
+	return scanFeedback(db.QueryRow(ctx, query, id))
+}
+
+// GetFeedbackPaginated returns feedback, newest first, optionally filtered by status
+func GetFeedbackPaginated(ctx context.Context, page, limit int, status string) ([]model.Feedback, int, error) {
+	offset := (page - 1) * limit
+
+	whereClause := ""
+	args := []interface{}{}
+	if status != "" {
+		args = append(args, status)
+		whereClause = "WHERE f.status = $1"
+	}
+
+	var total int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM feedback f "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + feedbackColumns + `
+		FROM feedback f
+		LEFT JOIN users u ON u.id = f.user_id
+		` + whereClause + fmt.Sprintf(`
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var feedback []model.Feedback
+	for rows.Next() {
+		f, err := scanFeedback(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		feedback = append(feedback, *f)
+	}
+
+	return feedback, total, rows.Err()
+}
+
+// GetFeedbackImages returns the images of feedback
+func GetFeedbackImages(ctx context.Context, feedbackID int) ([]model.FeedbackImage, error) {
+	query := `
+		SELECT id, feedback_id, oss_key, url, created_at
+		FROM feedback_images WHERE feedback_id = $1 ORDER BY id ASC
+	`
+	rows, err := db.Query(ctx, query, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []model.FeedbackImage
+	for rows.Next() {
+		var img model.FeedbackImage
+		if err := rows.Scan(&img.ID, &img.FeedbackID, &img.OSSKey, &img.URL, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// GetFeedbackReplies returns the replies sent for feedback, oldest first
+func GetFeedbackReplies(ctx context.Context, feedbackID int) ([]model.FeedbackReply, error) {
+	query := `
+		SELECT r.id, r.feedback_id, r.admin_id, u.name, r.message, r.sent_to, r.created_at
+		FROM feedback_replies r
+		LEFT JOIN users u ON u.id = r.admin_id
+		WHERE r.feedback_id = $1
+		ORDER BY r.created_at ASC, r.id ASC
+	`
+	rows, err := db.Query(ctx, query, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []model.FeedbackReply
+	for rows.Next() {
+		var r model.FeedbackReply
+		err := rows.Scan(&r.ID, &r.FeedbackID, &r.AdminID, &r.AdminName, &r.Message, &r.SentTo, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, r)
+	}
+	return replies, rows.Err()
+}
+
+// UpdateFeedback sets the status and admin note of feedback; resolving it
+// records when, so its images can be cleaned up later
+func UpdateFeedback(ctx context.Context, id int, status string, adminNote *string) error {
+	query := `
+		UPDATE feedback
+		SET status = $2,
+			admin_note = $3,
+			resolved_at = CASE
+				WHEN $2 <> 'resolved' THEN NULL
+				ELSE COALESCE(resolved_at, NOW())
+			END
+		WHERE id = $1
+	`
+	result, err := db.Exec(ctx, query, id, status, adminNote)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// CreateFeedbackReply records a reply sent to the author of feedback
+func CreateFeedbackReply(ctx context.Context, reply *model.FeedbackReply) error {
+	query := `
+		INSERT INTO feedback_replies (feedback_id, admin_id, message, sent_to)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return db.QueryRow(ctx, query, reply.FeedbackID, reply.AdminID, reply.Message, reply.SentTo).
+		Scan(&reply.ID, &reply.CreatedAt)
+}
+
+// DeleteFeedback deletes feedback with its image links and replies
+func DeleteFeedback(ctx context.Context, id int) error {
+	_, err := db.Exec(ctx, `DELETE FROM feedback WHERE id = $1`, id)
+	return err
+}
+
+// GetResolvedFeedbackImages returns the images of feedback resolved before
+// the given time
+func GetResolvedFeedbackImages(ctx context.Context, before time.Time) ([]model.FeedbackImage, error) {
+	query := `
+		SELECT i.id, i.feedback_id, i.oss_key, i.url, i.created_at
+		FROM feedback_images i
+		JOIN feedback f ON f.id = i.feedback_id
+		WHERE f.status = 'resolved' AND f.resolved_at < $1
+	`
+	rows, err := db.Query(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []model.FeedbackImage
+	for rows.Next() {
+		var img model.FeedbackImage
+		if err := rows.Scan(&img.ID, &img.FeedbackID, &img.OSSKey, &img.URL, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// DeleteFeedbackImages removes image links whose files have been deleted
+func DeleteFeedbackImages(ctx context.Context, ids []int) error {
+	_, err := db.Exec(ctx, `DELETE FROM feedback_images WHERE id = ANY($1)`, ids)
+	return err
+}
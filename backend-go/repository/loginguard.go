@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+)
+
+// FindLoginThrottle returns the failure counter for an account or IP
+func FindLoginThrottle(ctx context.Context, scope, key string) (*model.LoginThrottle, error) {
+	query := `
+		SELECT id, scope, key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE scope = $1 AND key = $2
+	`
+
+	var t model.LoginThrottle
+	err := db.QueryRow(ctx, query, scope, key).Scan(
+		&t.ID,
+		&t.Scope,
+		&t.Key,
+		&t.Failures,
+		&t.LastFailureAt,
+		&t.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ReserveLoginThrottle counts a sign-in attempt before its credentials are
+// checked and returns the updated counter, in one statement so concurrent
+// attempts cannot all pass the same check. It returns pgx.ErrNoRows without
+// counting while the account or IP is locked, already has lockAfter attempts
+// counted, or, when delayAfter is set, has to wait after its last attempt:
+// one second after delayAfter attempts, doubling with each further attempt
+// up to maxDelay. The count starts over when the previous attempt is older
+// than windowStart or an earlier lockout has run out.
+func ReserveLoginThrottle(ctx context.Context, scope, key string, windowStart time.Time, lockAfter int, delayAfter *int, maxDelay time.Duration) (*model.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < $3
+					OR login_throttles.locked_until IS NOT NULL THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			locked_until = NULL,
+			last_failure_at = NOW()
+		WHERE (login_throttles.locked_until IS NULL OR login_throttles.locked_until < NOW())
+			AND (
+				login_throttles.last_failure_at < $3
+				OR login_throttles.locked_until IS NOT NULL
+				OR (
+					login_throttles.failures < $4
+					AND (
+						$5::int IS NULL
+						OR login_throttles.failures < $5
+						OR login_throttles.last_failure_at + LEAST(
+							POWER(2, LEAST(login_throttles.failures - $5, 20)), $6
+						) * INTERVAL '1 second' <= NOW()
+					)
+				)
+			)
+		RETURNING id, scope, key, failures, last_failure_at, locked_until
+	`
+
+	var t model.LoginThrottle
+	err := db.QueryRow(ctx, query, scope, key, windowStart, lockAfter, delayAfter, maxDelay.Seconds()).Scan(
+		&t.ID,
+		&t.Scope,
+		&t.Key,
+		&t.Failures,
+		&t.LastFailureAt,
+		&t.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ReleaseLoginThrottle takes back an attempt counted by ReserveLoginThrottle
+// whose credentials turned out to be correct
+func ReleaseLoginThrottle(ctx context.Context, id int) error {
+	_, err := db.Exec(ctx, `UPDATE login_throttles SET failures = GREATEST(failures - 1, 0) WHERE id = $1`, id)
+	return err
+}
+
+// LockLoginThrottle locks an account or IP out of signing in until the given
+// time; it reports false when another failed attempt has locked it already
+func LockLoginThrottle(ctx context.Context, id int, until time.Time) (bool, error) {
+	query := `
+		UPDATE login_throttles SET locked_until = $1
+		WHERE id = $2 AND (locked_until IS NULL OR locked_until < NOW())
+	`
+	result, err := db.Exec(ctx, query, until, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ResetLoginThrottle clears the failure counter for an account or IP
+func ResetLoginThrottle(ctx context.Context, scope, key string) error {
+	_, err := db.Exec(ctx, `DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key)
+	return err
+}
+
+// DeleteStaleLoginThrottles removes counters whose last failure was before
+// the cutoff and that are not locked
+func DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < NOW())
+	`
+	result, err := db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// CreateSecurityEvent appends an entry to the security log
+func CreateSecurityEvent(ctx context.Context, e *model.SecurityEvent) error {
+	query := `
+		INSERT INTO security_events (user_id, email, event_type, ip_address, user_agent, details)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	return db.QueryRow(ctx, query,
+		e.UserID,
+		e.Email,
+		e.EventType,
+		e.IPAddress,
+		e.UserAgent,
+		e.Details,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+// GetSecurityEventsByUser returns a user's security log, oldest first
+func GetSecurityEventsByUser(ctx context.Context, userID int) ([]model.SecurityEvent, error) {
+	query := `
+		SELECT id, user_id, email, event_type, ip_address, user_agent, details, created_at
+		FROM security_events
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.SecurityEvent
+	for rows.Next() {
+		var e model.SecurityEvent
+		err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Email,
+			&e.EventType,
+			&e.IPAddress,
+			&e.UserAgent,
+			&e.Details,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// DeleteSecurityEventsBefore removes security log entries older than the cutoff
+func DeleteSecurityEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := db.Exec(ctx, `DELETE FROM security_events WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
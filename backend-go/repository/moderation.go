@@ -0,0 +1,398 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"picshare/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAlbumTakedown removes an album from public access; a nil admin
+// marks an automatic takedown. An admin takedown replaces an automatic one.
+// Returns ErrNoRowsUpdated if the album is already taken down otherwise.
+func CreateAlbumTakedown(ctx context.Context, albumID int, reason string, adminID *int) error {
+	query := `
+		INSERT INTO album_takedowns (album_id, reason, admin_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (album_id) DO UPDATE
+		SET reason = EXCLUDED.reason, admin_id = EXCLUDED.admin_id, created_at = NOW()
+		WHERE album_takedowns.admin_id IS NULL AND EXCLUDED.admin_id IS NOT NULL
+	`
+	result, err := db.Exec(ctx, query, albumID, reason, adminID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// DeleteAlbumTakedown restores public access to an album. Returns
+// ErrNoRowsUpdated if the album was not taken down.
+func DeleteAlbumTakedown(ctx context.Context, albumID int) error {
+	result, err := db.Exec(ctx, `DELETE FROM album_takedowns WHERE album_id = $1`, albumID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// FindAlbumTakedown returns the takedown of an album, or nil if it is public
+func FindAlbumTakedown(ctx context.Context, albumID int) (*model.AlbumTakedown, error) {
+	query := `SELECT album_id, reason, admin_id, created_at FROM album_takedowns WHERE album_id = $1`
+
+	var t model.AlbumTakedown
+	err := db.QueryRow(ctx, query, albumID).Scan(&t.AlbumID, &t.Reason, &t.AdminID, &t.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Automatic = t.AdminID == nil
+	return &t, nil
+}
+
+// GetTakenDownAlbumIDs returns which of the given albums are taken down
+func GetTakenDownAlbumIDs(ctx context.Context, albumIDs []int) (map[int]bool, error) {
+	rows, err := db.Query(ctx, `SELECT album_id FROM album_takedowns WHERE album_id = ANY($1)`, albumIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// HidePhoto hides a photo from the public view of its album. Returns
+// ErrNoRowsUpdated if the photo is already hidden.
+func HidePhoto(ctx context.Context, photoID, albumID int, reason *string) error {
+	query := `
+		UPDATE photos SET hidden_at = NOW(), hidden_reason = $3
+		WHERE id = $1 AND album_id = $2 AND hidden_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, photoID, albumID, reason)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// UnhidePhoto shows a hidden photo again. Returns ErrNoRowsUpdated if the
+// photo is not hidden.
+func UnhidePhoto(ctx context.Context, photoID, albumID int) error {
+	query := `
+		UPDATE photos SET hidden_at = NULL, hidden_reason = NULL
+		WHERE id = $1 AND album_id = $2 AND hidden_at IS NOT NULL
+	`
+	result, err := db.Exec(ctx, query, photoID, albumID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// CreateAlbumReport stores an abuse report from a visitor
+func CreateAlbumReport(ctx context.Context, report *model.AlbumReport) error {
+	query := `
+		INSERT INTO album_reports (album_id, photo_id, category, reason, reporter_id, reporter_ip, reporter_user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, status, created_at
+	`
+	return db.QueryRow(ctx, query,
+		report.AlbumID,
+		report.PhotoID,
+		report.Category,
+		report.Reason,
+		report.ReporterID,
+		report.ReporterIP,
+		report.ReporterUserAgent,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt)
+}
+
+const albumReportColumns = `
+	r.id, r.album_id, r.photo_id, r.category, r.reason, r.reporter_id, r.reporter_ip, r.reporter_user_agent,
+	r.status, r.resolved_by, r.resolved_at, r.created_at,
+	a.title, a.share_code, u.id, u.name, u.email, p.thumbnail_url,
+	EXISTS (SELECT 1 FROM album_takedowns t WHERE t.album_id = r.album_id)
+`
+
+const albumReportJoins = `
+	FROM album_reports r
+	JOIN albums a ON a.id = r.album_id
+	JOIN users u ON u.id = a.user_id
+	LEFT JOIN photos p ON p.id = r.photo_id
+`
+
+func scanAlbumReport(row interface{ Scan(...any) error }) (*model.AlbumReport, error) {
+	var r model.AlbumReport
+	err := row.Scan(
+		&r.ID,
+		&r.AlbumID,
+		&r.PhotoID,
+		&r.Category,
+		&r.Reason,
+		&r.ReporterID,
+		&r.ReporterIP,
+		&r.ReporterUserAgent,
+		&r.Status,
+		&r.ResolvedBy,
+		&r.ResolvedAt,
+		&r.CreatedAt,
+		&r.AlbumTitle,
+		&r.ShareCode,
+		&r.OwnerID,
+		&r.OwnerName,
+		&r.OwnerEmail,
+		&r.PhotoThumbnail,
+		&r.TakenDown,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// FindAlbumReport finds a report by ID
+func FindAlbumReport(ctx context.Context, id int) (*model.AlbumReport, error) {
+	query := `SELECT ` + albumReportColumns + albumReportJoins + ` WHERE r.id = $1`
+	return scanAlbumReport(db.QueryRow(ctx, query, id))
+}
+
+// AlbumReportFilter narrows the moderation queue; zero values match all
+type AlbumReportFilter struct {
+	Status   string
+	Category string
+	AlbumID  int
+}
+
+// GetAlbumReports returns the moderation queue, oldest first
+func GetAlbumReports(ctx context.Context, page, limit int, filter AlbumReportFilter) ([]model.AlbumReport, int, error) {
+	offset := (page - 1) * limit
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("r.status = $%d", len(args)))
+	}
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		conditions = append(conditions, fmt.Sprintf("r.category = $%d", len(args)))
+	}
+	if filter.AlbumID > 0 {
+		args = append(args, filter.AlbumID)
+		conditions = append(conditions, fmt.Sprintf("r.album_id = $%d", len(args)))
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM album_reports r "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + albumReportColumns + albumReportJoins + whereClause + fmt.Sprintf(`
+		ORDER BY r.created_at ASC, r.id ASC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var reports []model.AlbumReport
+	for rows.Next() {
+		r, err := scanAlbumReport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reports = append(reports, *r)
+	}
+
+	return reports, total, rows.Err()
+}
+
+// UpdateAlbumReportStatus sets the status of a report, recording who closed
+// it; reopening a report clears the reviewer
+func UpdateAlbumReportStatus(ctx context.Context, id int, status string, adminID int) error {
+	query := `
+		UPDATE album_reports
+		SET status = $2,
+			resolved_by = CASE WHEN $2 = 'open' THEN NULL ELSE $3::int END,
+			resolved_at = CASE WHEN $2 = 'open' THEN NULL ELSE NOW() END
+		WHERE id = $1
+	`
+	result, err := db.Exec(ctx, query, id, status, adminID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// ResolveOpenAlbumReports closes every open report on an album, after an
+// admin has acted on it
+func ResolveOpenAlbumReports(ctx context.Context, albumID, adminID int) (int64, error) {
+	query := `
+		UPDATE album_reports SET status = 'resolved', resolved_by = $2, resolved_at = NOW()
+		WHERE album_id = $1 AND status = 'open'
+	`
+	result, err := db.Exec(ctx, query, albumID, adminID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// HasRecentAlbumReport checks whether an IP or a signed-in user already
+// reported the same album or photo since the given time
+func HasRecentAlbumReport(ctx context.Context, albumID int, photoID *int, reporterID *int, ip string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM album_reports
+			WHERE album_id = $1 AND photo_id IS NOT DISTINCT FROM $2
+				AND (reporter_ip = $4 OR reporter_id = $3) AND created_at > $5
+		)
+	`
+	var exists bool
+	err := db.QueryRow(ctx, query, albumID, photoID, reporterID, ip, since).Scan(&exists)
+	return exists, err
+}
+
+// CountOpenReporters counts the distinct signed-in users with a verified
+// email who have open reports on an album (nil photo) or on one of its photos
+func CountOpenReporters(ctx context.Context, albumID int, photoID *int) (int, error) {
+	query := `
+		SELECT COUNT(DISTINCT r.reporter_id) FROM album_reports r
+		JOIN users u ON u.id = r.reporter_id
+		WHERE r.album_id = $1 AND r.photo_id IS NOT DISTINCT FROM $2 AND r.status = 'open'
+			AND u.email_verified
+	`
+	var count int
+	err := db.QueryRow(ctx, query, albumID, photoID).Scan(&count)
+	return count, err
+}
+
+// GetAlbumReportSummaries returns the reported albums with their report
+// counts, those with the most open reports first
+func GetAlbumReportSummaries(ctx context.Context, page, limit int, openOnly bool) ([]model.AlbumReportSummary, int, error) {
+	offset := (page - 1) * limit
+
+	havingClause := ""
+	if openOnly {
+		havingClause = "HAVING COUNT(*) FILTER (WHERE r.status = 'open') > 0"
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM (SELECT r.album_id FROM album_reports r GROUP BY r.album_id ` + havingClause + `) s`
+	if err := db.QueryRow(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT a.id, a.title, a.share_code, u.id, u.name, u.email,
+			COUNT(*) FILTER (WHERE r.status = 'open'),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE r.status = 'open' AND r.category = 'copyright'),
+			COUNT(*) FILTER (WHERE r.status = 'open' AND r.category = 'explicit'),
+			COUNT(*) FILTER (WHERE r.status = 'open' AND r.category = 'spam'),
+			COUNT(*) FILTER (WHERE r.status = 'open' AND r.category = 'other'),
+			MAX(r.created_at),
+			t.album_id IS NOT NULL,
+			t.album_id IS NOT NULL AND t.admin_id IS NULL
+		FROM album_reports r
+		JOIN albums a ON a.id = r.album_id
+		JOIN users u ON u.id = a.user_id
+		LEFT JOIN album_takedowns t ON t.album_id = a.id
+		GROUP BY a.id, u.id, t.album_id, t.admin_id
+		` + havingClause + `
+		ORDER BY COUNT(*) FILTER (WHERE r.status = 'open') DESC, MAX(r.created_at) DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var summaries []model.AlbumReportSummary
+	for rows.Next() {
+		var (
+			s                                model.AlbumReportSummary
+			copyright, explicit, spam, other int
+		)
+		err := rows.Scan(
+			&s.AlbumID,
+			&s.AlbumTitle,
+			&s.ShareCode,
+			&s.OwnerID,
+			&s.OwnerName,
+			&s.OwnerEmail,
+			&s.OpenCount,
+			&s.TotalCount,
+			&copyright,
+			&explicit,
+			&spam,
+			&other,
+			&s.LastReportedAt,
+			&s.TakenDown,
+			&s.AutoTakenDown,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		s.Categories = map[string]int{
+			model.ReportCategoryCopyright: copyright,
+			model.ReportCategoryExplicit:  explicit,
+			model.ReportCategorySpam:      spam,
+			model.ReportCategoryOther:     other,
+		}
+		summaries = append(summaries, s)
+	}
+
+	return summaries, total, rows.Err()
+}
+
+// DismissOpenReports dismisses the open reports on an album (nil photo) or
+// on one of its photos, after an admin decided the content may stay public
+func DismissOpenReports(ctx context.Context, albumID int, photoID *int, adminID int) (int64, error) {
+	query := `
+		UPDATE album_reports SET status = 'dismissed', resolved_by = $3, resolved_at = NOW()
+		WHERE album_id = $1 AND photo_id IS NOT DISTINCT FROM $2 AND status = 'open'
+	`
+	result, err := db.Exec(ctx, query, albumID, photoID, adminID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+)
+
+// CreateOIDCState stores the PKCE verifier and nonce of an OIDC login that
+// was sent to the identity provider
+func CreateOIDCState(ctx context.Context, stateHash, codeVerifier, nonce string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := db.Exec(ctx, query, stateHash, codeVerifier, nonce, expiresAt)
+	return err
+}
+
+// ConsumeOIDCState deletes a pending OIDC login and returns its verifier and
+// nonce, so each state value can only be used once
+func ConsumeOIDCState(ctx context.Context, stateHash string) (string, string, error) {
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING code_verifier, nonce
+	`
+
+	var codeVerifier, nonce string
+	err := db.QueryRow(ctx, query, stateHash).Scan(&codeVerifier, &nonce)
+	if err != nil {
+		return "", "", err
+	}
+	return codeVerifier, nonce, nil
+}
+
+// DeleteExpiredOIDCStates removes OIDC logins that were never completed
+func DeleteExpiredOIDCStates(ctx context.Context) (int64, error) {
+	result, err := db.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// FindUserIdentity finds the user linked to an identity provider account
+func FindUserIdentity(ctx context.Context, issuer, subject string) (*model.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, issuer, subject, email, granted_admin, created_at, last_login_at
+		FROM user_identities WHERE issuer = $1 AND subject = $2
+	`
+
+	var i model.UserIdentity
+	err := db.QueryRow(ctx, query, issuer, subject).Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.GrantedAdmin,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// CreateUserIdentity links a user to an identity provider account
+func CreateUserIdentity(ctx context.Context, i *model.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return db.QueryRow(ctx, query, i.UserID, i.Issuer, i.Subject, i.Email).Scan(&i.ID, &i.CreatedAt)
+}
+
+// UpdateUserIdentityLogin records a login through an identity and the
+// email and admin grant the provider reported
+func UpdateUserIdentityLogin(ctx context.Context, id int, email string, grantedAdmin bool) error {
+	query := `
+		UPDATE user_identities
+		SET email = $1, granted_admin = $2, last_login_at = NOW()
+		WHERE id = $3
+	`
+	_, err := db.Exec(ctx, query, email, grantedAdmin, id)
+	return err
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 
 	"picshare/model"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // CreatePhoto creates a new photo
 func CreatePhoto(ctx context.Context, photo *model.Photo) error {
 	query := `
 		INSERT INTO photos (album_id, user_id, original_name, original_url, thumbnail_url,
-			oss_key, thumbnail_oss_key, file_size, width, height, mime_type)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			oss_key, thumbnail_oss_key, file_size, width, height, mime_type, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at
 	`
 
@@ -27,6 +29,7 @@ func CreatePhoto(ctx context.Context, photo *model.Photo) error {
 		photo.Width,
 		photo.Height,
 		photo.MimeType,
+		photo.UploadedBy,
 	).Scan(&photo.ID, &photo.CreatedAt)
 
 	return err
@@ -37,7 +40,7 @@ func FindPhotoByID(ctx context.Context, id int) (*model.Photo, error) {
 	query := `
 		SELECT id, album_id, user_id, original_name, original_url, thumbnail_url,
 			oss_key, thumbnail_oss_key, file_size, width, height, mime_type,
-			download_count, sort_order, created_at
+			download_count, sort_order, uploaded_by, hidden_at, hidden_reason, created_at
 		FROM photos WHERE id = $1
 	`
 
@@ -57,6 +60,9 @@ func FindPhotoByID(ctx context.Context, id int) (*model.Photo, error) {
 		&photo.MimeType,
 		&photo.DownloadCount,
 		&photo.SortOrder,
+		&photo.UploadedBy,
+		&photo.HiddenAt,
+		&photo.HiddenReason,
 		&photo.CreatedAt,
 	)
 
@@ -71,7 +77,7 @@ func FindPhotoByIDAndAlbum(ctx context.Context, photoID, albumID int) (*model.Ph
 	query := `
 		SELECT id, album_id, user_id, original_name, original_url, thumbnail_url,
 			oss_key, thumbnail_oss_key, file_size, width, height, mime_type,
-			download_count, sort_order, created_at
+			download_count, sort_order, uploaded_by, hidden_at, hidden_reason, created_at
 		FROM photos WHERE id = $1 AND album_id = $2
 	`
 
@@ -91,6 +97,9 @@ func FindPhotoByIDAndAlbum(ctx context.Context, photoID, albumID int) (*model.Ph
 		&photo.MimeType,
 		&photo.DownloadCount,
 		&photo.SortOrder,
+		&photo.UploadedBy,
+		&photo.HiddenAt,
+		&photo.HiddenReason,
 		&photo.CreatedAt,
 	)
 
@@ -105,7 +114,7 @@ func GetPhotosByAlbum(ctx context.Context, albumID int) ([]model.Photo, error) {
 	query := `
 		SELECT id, album_id, user_id, original_name, original_url, thumbnail_url,
 			oss_key, thumbnail_oss_key, file_size, width, height, mime_type,
-			download_count, sort_order, created_at
+			download_count, sort_order, uploaded_by, hidden_at, hidden_reason, created_at
 		FROM photos WHERE album_id = $1 ORDER BY sort_order ASC, created_at ASC
 	`
 
@@ -133,6 +142,9 @@ func GetPhotosByAlbum(ctx context.Context, albumID int) ([]model.Photo, error) {
 			&p.MimeType,
 			&p.DownloadCount,
 			&p.SortOrder,
+			&p.UploadedBy,
+			&p.HiddenAt,
+			&p.HiddenReason,
 			&p.CreatedAt,
 		)
 		if err != nil {
@@ -151,6 +163,13 @@ func DeletePhoto(ctx context.Context, photoID, albumID int) error {
 	return err
 }
 
+// UpdatePhotoThumbnail sets a regenerated thumbnail for a photo
+func UpdatePhotoThumbnail(ctx context.Context, photoID int, thumbnailURL, thumbnailOSSKey string) error {
+	query := `UPDATE photos SET thumbnail_url = $1, thumbnail_oss_key = $2 WHERE id = $3`
+	_, err := db.Exec(ctx, query, thumbnailURL, thumbnailOSSKey, photoID)
+	return err
+}
+
 // IncrementPhotoDownloadCount increments download count
 func IncrementPhotoDownloadCount(ctx context.Context, photoID int) error {
 	query := `UPDATE photos SET download_count = download_count + 1 WHERE id = $1`
@@ -164,7 +183,8 @@ func GetPhotosByAlbumForPublic(ctx context.Context, albumID int) ([]model.Photo,
 		SELECT id, album_id, user_id, original_name, original_url, thumbnail_url,
 			oss_key, thumbnail_oss_key, file_size, width, height, mime_type,
 			download_count, sort_order, created_at
-		FROM photos WHERE album_id = $1 ORDER BY sort_order ASC, created_at ASC
+		FROM photos WHERE album_id = $1 AND hidden_at IS NULL
+		ORDER BY sort_order ASC, created_at ASC
 	`
 
 	rows, err := db.Query(ctx, query, albumID)
@@ -233,6 +253,36 @@ func GetPhotoOSSKeys(ctx context.Context, albumID int) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	return scanPhotoOSSKeys(rows)
+}
+
+// GetUserPhotoOSSKeys returns the OSS keys of every photo that is deleted
+// together with a user: photos they own and all photos in their albums.
+// Albums in a workspace with other members are handed over instead (see
+// DeleteUser), so their photos are left out.
+// Keys are read from the rows because an album transferred without moving
+// its files keeps them under the previous owner's prefix.
+func GetUserPhotoOSSKeys(ctx context.Context, userID int) ([]string, error) {
+	query := `
+		SELECT p.oss_key, p.thumbnail_oss_key
+		FROM photos p
+		JOIN albums a ON a.id = p.album_id
+		WHERE (p.user_id = $1 OR a.user_id = $1)
+			AND (a.workspace_id IS NULL OR NOT EXISTS (
+				SELECT 1 FROM workspace_members m
+				WHERE m.workspace_id = a.workspace_id AND m.user_id <> $1
+			))
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPhotoOSSKeys(rows)
+}
+
+// scanPhotoOSSKeys collects the original and thumbnail keys of photo rows
+func scanPhotoOSSKeys(rows pgx.Rows) ([]string, error) {
 	defer rows.Close()
 
 	var keys []string
@@ -242,7 +292,11 @@ func GetPhotoOSSKeys(ctx context.Context, albumID int) ([]string, error) {
 		if err != nil {
 			return nil, err
 		}
-		keys = append(keys, ossKey, thumbKey)
+		keys = append(keys, ossKey)
+		// Archived albums have no thumbnails
+		if thumbKey != "" {
+			keys = append(keys, thumbKey)
+		}
 	}
 
 	return keys, rows.Err()
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+)
+
+// Expiry reminder kinds not derived from an offset
+const (
+	ReminderKindBeforeDeletion = "before_deletion"
+)
+
+// GetAlbumsDueForExpiryReminder returns active albums that expire within
+// offset and have not had the given reminder for their current expiry.
+// Albums whose whole lifetime is shorter than offset are skipped.
+func GetAlbumsDueForExpiryReminder(ctx context.Context, offset time.Duration, kind string) ([]model.ExpiryReminderCandidate, error) {
+	query := `
+		SELECT a.id, a.user_id, a.title, a.share_code, a.expires_at, u.email, u.name
+		FROM albums a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.is_expired = false AND a.archived_at IS NULL
+			AND a.expires_at > NOW()
+			AND a.expires_at - make_interval(secs => $1) <= NOW()
+			AND a.created_at < a.expires_at - make_interval(secs => $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM album_expiry_reminders r
+				WHERE r.album_id = a.id AND r.kind = $2 AND r.expires_at = a.expires_at
+			)
+		ORDER BY a.expires_at ASC
+	`
+	return queryReminderCandidates(ctx, query, offset.Seconds(), kind)
+}
+
+// GetAlbumsDueForDeletionNotice returns expired albums that will be deleted
+// by the cleanup job within before and have not had a final notice yet
+func GetAlbumsDueForDeletionNotice(ctx context.Context, before time.Duration) ([]model.ExpiryReminderCandidate, error) {
+	query := `
+		SELECT a.id, a.user_id, a.title, a.share_code, a.expires_at, u.email, u.name
+		FROM albums a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.archived_at IS NULL
+			AND COALESCE(a.expiry_policy, u.expiry_policy, 'delete') = 'delete'
+			AND a.expires_at <= NOW()
+			AND a.expires_at + INTERVAL '1 day' - make_interval(secs => $1) <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM album_expiry_reminders r
+				WHERE r.album_id = a.id AND r.kind = $2 AND r.expires_at = a.expires_at
+			)
+		ORDER BY a.expires_at ASC
+	`
+	return queryReminderCandidates(ctx, query, before.Seconds(), ReminderKindBeforeDeletion)
+}
+
+// queryReminderCandidates runs a reminder query and scans album + owner rows
+func queryReminderCandidates(ctx context.Context, query string, args ...interface{}) ([]model.ExpiryReminderCandidate, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []model.ExpiryReminderCandidate
+	for rows.Next() {
+		var c model.ExpiryReminderCandidate
+		err := rows.Scan(
+			&c.Album.ID,
+			&c.Album.UserID,
+			&c.Album.Title,
+			&c.Album.ShareCode,
+			&c.Album.ExpiresAt,
+			&c.OwnerEmail,
+			&c.OwnerName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// RecordExpiryReminder claims a reminder for an album's current expiry.
+// It returns false if the reminder was already recorded.
+func RecordExpiryReminder(ctx context.Context, albumID int, kind string, expiresAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO album_expiry_reminders (album_id, kind, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (album_id, kind, expires_at) DO NOTHING
+	`
+	result, err := db.Exec(ctx, query, albumID, kind, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// DeleteExpiryReminder removes a reminder record so it can be retried
+func DeleteExpiryReminder(ctx context.Context, albumID int, kind string, expiresAt time.Time) error {
+	query := `DELETE FROM album_expiry_reminders WHERE album_id = $1 AND kind = $2 AND expires_at = $3`
+	_, err := db.Exec(ctx, query, albumID, kind, expiresAt)
+	return err
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"picshare/model"
+)
+
+// Refresh token errors
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// CreateSession creates a session with its first refresh token
+func CreateSession(ctx context.Context, s *model.Session, refreshTokenHash string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO auth_sessions (user_id, ip_address, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, last_used_at
+	`
+	err = tx.QueryRow(ctx, query, s.UserID, s.IPAddress, s.UserAgent, s.ExpiresAt).
+		Scan(&s.ID, &s.CreatedAt, &s.LastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)
+	`, s.ID, refreshTokenHash)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateImpersonationSession creates a session in which an admin acts as the
+// user. It has no refresh token, so it ends when it expires.
+func CreateImpersonationSession(ctx context.Context, s *model.Session, adminID int) error {
+	query := `
+		INSERT INTO auth_sessions (user_id, ip_address, user_agent, expires_at, impersonated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, last_used_at
+	`
+	return db.QueryRow(ctx, query, s.UserID, s.IPAddress, s.UserAgent, s.ExpiresAt, adminID).
+		Scan(&s.ID, &s.CreatedAt, &s.LastUsedAt)
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one and extends the
+// session. Presenting an already used token revokes the whole session, since
+// it means the token was stolen or replayed. Returns the session ID and user ID.
+func RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (int, int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		tokenID, sessionID, userID int
+		usedAt, revokedAt          *time.Time
+		sessionExpiresAt           time.Time
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT rt.id, rt.session_id, rt.used_at, s.user_id, s.revoked_at, s.expires_at
+		FROM refresh_tokens rt
+		JOIN auth_sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE
+	`, oldHash).Scan(&tokenID, &sessionID, &usedAt, &userID, &revokedAt, &sessionExpiresAt)
+	if err == pgx.ErrNoRows {
+		return 0, 0, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if revokedAt != nil || sessionExpiresAt.Before(time.Now()) {
+		return 0, 0, ErrRefreshTokenInvalid
+	}
+
+	if usedAt != nil {
+		_, err = tx.Exec(ctx, `
+			UPDATE auth_sessions SET revoked_at = NOW(), revoked_reason = $1 WHERE id = $2
+		`, model.SessionRevokedTokenReuse, sessionID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return 0, 0, err
+		}
+		return sessionID, userID, ErrRefreshTokenReused
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, tokenID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)
+	`, sessionID, newHash)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE auth_sessions SET last_used_at = NOW(), expires_at = $1 WHERE id = $2
+	`, expiresAt, sessionID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return sessionID, userID, tx.Commit(ctx)
+}
+
+// FindSessionUser returns the current state of the user behind an active
+// session, so that role changes, revocations and email verification apply
+// immediately. An impersonation session also ends as soon as the admin who
+// opened it is no longer an active admin.
+func FindSessionUser(ctx context.Context, sessionID, userID int) (*model.User, error) {
+	query := `
+		SELECT u.id, u.email, u.name, u.role, u.email_verified, u.suspended_at, u.created_at
+		FROM auth_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.user_id = $2
+		AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		AND (s.impersonated_by IS NULL OR EXISTS (
+			SELECT 1 FROM users a
+			WHERE a.id = s.impersonated_by AND a.role = 'admin' AND a.suspended_at IS NULL
+		))
+	`
+
+	var user model.User
+	err := db.QueryRow(ctx, query, sessionID, userID).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.EmailVerified,
+		&user.SuspendedAt,
+		&user.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetActiveSessionsByUser returns a user's active sessions, most recently
+// used first. Sessions opened by an admin impersonating the user are left out.
+func GetActiveSessionsByUser(ctx context.Context, userID int) ([]model.Session, error) {
+	query := `
+		SELECT id, user_id, ip_address, user_agent, created_at, last_used_at, expires_at,
+			revoked_at, revoked_reason
+		FROM auth_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		AND impersonated_by IS NULL
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var s model.Session
+		err := rows.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.IPAddress,
+			&s.UserAgent,
+			&s.CreatedAt,
+			&s.LastUsedAt,
+			&s.ExpiresAt,
+			&s.RevokedAt,
+			&s.RevokedReason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes one of a user's sessions
+func RevokeSession(ctx context.Context, id, userID int, reason string) error {
+	query := `
+		UPDATE auth_sessions SET revoked_at = NOW(), revoked_reason = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, reason, id, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes all active sessions of a user except exceptID (0 for none)
+func RevokeUserSessions(ctx context.Context, userID, exceptID int, reason string) (int64, error) {
+	query := `
+		UPDATE auth_sessions SET revoked_at = NOW(), revoked_reason = $1
+		WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, reason, userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// DeleteStaleSessions removes sessions that ended before the cutoff, along
+// with their refresh tokens
+func DeleteStaleSessions(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM auth_sessions
+		WHERE expires_at < $1 OR revoked_at < $1
+	`
+	result, err := db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+
+	"picshare/model"
+)
+
+// CreateAlbumTemplate creates a new album template
+func CreateAlbumTemplate(ctx context.Context, t *model.AlbumTemplate) error {
+	query := `
+		INSERT INTO album_templates (user_id, name, title, description, expires_in_hours, expiry_policy)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := db.QueryRow(ctx, query,
+		t.UserID,
+		t.Name,
+		t.Title,
+		t.Description,
+		t.ExpiresInHours,
+		t.ExpiryPolicy,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+
+	return err
+}
+
+// FindAlbumTemplateByIDWithUser finds a template and checks ownership
+func FindAlbumTemplateByIDWithUser(ctx context.Context, id, userID int) (*model.AlbumTemplate, error) {
+	query := `
+		SELECT id, user_id, name, title, description, expires_in_hours, expiry_policy,
+			created_at, updated_at
+		FROM album_templates WHERE id = $1 AND user_id = $2
+	`
+
+	var t model.AlbumTemplate
+	err := db.QueryRow(ctx, query, id, userID).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Title,
+		&t.Description,
+		&t.ExpiresInHours,
+		&t.ExpiryPolicy,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetAlbumTemplatesByUser returns all templates of a user ordered by name
+func GetAlbumTemplatesByUser(ctx context.Context, userID int) ([]model.AlbumTemplate, error) {
+	query := `
+		SELECT id, user_id, name, title, description, expires_in_hours, expiry_policy,
+			created_at, updated_at
+		FROM album_templates WHERE user_id = $1 ORDER BY name ASC
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []model.AlbumTemplate
+	for rows.Next() {
+		var t model.AlbumTemplate
+		err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Name,
+			&t.Title,
+			&t.Description,
+			&t.ExpiresInHours,
+			&t.ExpiryPolicy,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// UpdateAlbumTemplate replaces all template settings
+func UpdateAlbumTemplate(ctx context.Context, t *model.AlbumTemplate) error {
+	query := `
+		UPDATE album_templates
+		SET name = $1, title = $2, description = $3, expires_in_hours = $4,
+			expiry_policy = $5, updated_at = NOW()
+		WHERE id = $6 AND user_id = $7
+	`
+	_, err := db.Exec(ctx, query, t.Name, t.Title, t.Description, t.ExpiresInHours, t.ExpiryPolicy, t.ID, t.UserID)
+	return err
+}
+
+// DeleteAlbumTemplate deletes a template
+func DeleteAlbumTemplate(ctx context.Context, id, userID int) error {
+	query := `DELETE FROM album_templates WHERE id = $1 AND user_id = $2`
+	_, err := db.Exec(ctx, query, id, userID)
+	return err
+}
+
+// FindAlbumTemplateByName finds a user's template by name
+func FindAlbumTemplateByName(ctx context.Context, userID int, name string) (*model.AlbumTemplate, error) {
+	var t model.AlbumTemplate
+	err := db.QueryRow(ctx, "SELECT id FROM album_templates WHERE user_id = $1 AND name = $2", userID, name).Scan(&t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+
+	"picshare/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const albumTransferColumns = `
+	t.id, t.album_id, t.from_user_id, t.to_user_id, t.token_hash, t.status,
+	t.rekey_storage, t.expires_at, t.responded_at, t.created_at,
+	a.title, fu.name, fu.email, tu.name, tu.email
+`
+
+const albumTransferJoins = `
+	FROM album_transfers t
+	JOIN albums a ON a.id = t.album_id
+	JOIN users fu ON fu.id = t.from_user_id
+	JOIN users tu ON tu.id = t.to_user_id
+`
+
+// CreateAlbumTransfer creates a pending transfer, cancelling any earlier
+// pending transfer of the same album
+func CreateAlbumTransfer(ctx context.Context, t *model.AlbumTransfer) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE album_transfers SET status = $1, responded_at = NOW()
+		WHERE album_id = $2 AND status = $3
+	`, model.TransferStatusCancelled, t.AlbumID, model.TransferStatusPending)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO album_transfers (album_id, from_user_id, to_user_id, token_hash, status,
+			rekey_storage, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, query,
+		t.AlbumID,
+		t.FromUserID,
+		t.ToUserID,
+		t.TokenHash,
+		model.TransferStatusPending,
+		t.RekeyStorage,
+		t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return err
+	}
+	t.Status = model.TransferStatusPending
+
+	return tx.Commit(ctx)
+}
+
+// scanAlbumTransfer scans a row selected with albumTransferColumns
+func scanAlbumTransfer(row interface{ Scan(...any) error }) (*model.AlbumTransfer, error) {
+	var t model.AlbumTransfer
+	err := row.Scan(
+		&t.ID,
+		&t.AlbumID,
+		&t.FromUserID,
+		&t.ToUserID,
+		&t.TokenHash,
+		&t.Status,
+		&t.RekeyStorage,
+		&t.ExpiresAt,
+		&t.RespondedAt,
+		&t.CreatedAt,
+		&t.AlbumTitle,
+		&t.FromName,
+		&t.FromEmail,
+		&t.ToName,
+		&t.ToEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// FindAlbumTransferByID finds a transfer by ID
+func FindAlbumTransferByID(ctx context.Context, id int) (*model.AlbumTransfer, error) {
+	query := `SELECT ` + albumTransferColumns + albumTransferJoins + ` WHERE t.id = $1`
+	return scanAlbumTransfer(db.QueryRow(ctx, query, id))
+}
+
+// FindAlbumTransferByTokenHash finds a transfer by the hash of its email token
+func FindAlbumTransferByTokenHash(ctx context.Context, tokenHash string) (*model.AlbumTransfer, error) {
+	query := `SELECT ` + albumTransferColumns + albumTransferJoins + ` WHERE t.token_hash = $1`
+	return scanAlbumTransfer(db.QueryRow(ctx, query, tokenHash))
+}
+
+// FindPendingAlbumTransfer finds the pending transfer of an album
+func FindPendingAlbumTransfer(ctx context.Context, albumID int) (*model.AlbumTransfer, error) {
+	query := `SELECT ` + albumTransferColumns + albumTransferJoins + `
+		WHERE t.album_id = $1 AND t.status = $2 AND t.expires_at > NOW()`
+	return scanAlbumTransfer(db.QueryRow(ctx, query, albumID, model.TransferStatusPending))
+}
+
+// GetPendingAlbumTransfersForUser returns pending transfers sent or received by a user
+func GetPendingAlbumTransfersForUser(ctx context.Context, userID int) ([]model.AlbumTransfer, error) {
+	query := `SELECT ` + albumTransferColumns + albumTransferJoins + `
+		WHERE (t.from_user_id = $1 OR t.to_user_id = $1)
+		AND t.status = $2 AND t.expires_at > NOW()
+		ORDER BY t.created_at DESC`
+
+	rows, err := db.Query(ctx, query, userID, model.TransferStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []model.AlbumTransfer
+	for rows.Next() {
+		t, err := scanAlbumTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// CloseAlbumTransfer moves a pending transfer to a final status
+func CloseAlbumTransfer(ctx context.Context, id int, status string) error {
+	query := `
+		UPDATE album_transfers SET status = $1, responded_at = NOW()
+		WHERE id = $2 AND status = $3
+	`
+	result, err := db.Exec(ctx, query, status, id, model.TransferStatusPending)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// CompleteAlbumTransfer atomically hands the album and its photos to the
+// recipient, applies any storage key moves and marks the transfer accepted.
+// The album leaves its workspace and the previous owner's collections.
+// workspaceID is the workspace the album was checked in; the transfer fails
+// with ErrNoRowsUpdated if the album has moved since or the sender is no
+// longer one of that workspace's owners.
+func CompleteAlbumTransfer(ctx context.Context, t *model.AlbumTransfer, workspaceID *int, moves []model.PhotoKeyMove, coverURL *string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE album_transfers SET status = $1, responded_at = NOW()
+		WHERE id = $2 AND status = $3
+	`, model.TransferStatusAccepted, t.ID, model.TransferStatusPending)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+
+	// Hold the sender's owner membership until commit so it cannot be
+	// downgraded or removed while the album changes hands
+	if workspaceID != nil {
+		var role string
+		err = tx.QueryRow(ctx, `
+			SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2 FOR SHARE
+		`, *workspaceID, t.FromUserID).Scan(&role)
+		if err == pgx.ErrNoRows || (err == nil && role != model.WorkspaceRoleOwner) {
+			return ErrNoRowsUpdated
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err = tx.Exec(ctx, `
+		UPDATE albums SET user_id = $1, workspace_id = NULL, cover_url = COALESCE($2, cover_url), updated_at = NOW()
+		WHERE id = $3 AND user_id = $4 AND workspace_id IS NOT DISTINCT FROM $5
+	`, t.ToUserID, coverURL, t.AlbumID, t.FromUserID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE photos SET user_id = $1 WHERE album_id = $2`, t.ToUserID, t.AlbumID)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range moves {
+		_, err = tx.Exec(ctx, `
+			UPDATE photos SET oss_key = $1, original_url = $2, thumbnail_oss_key = $3, thumbnail_url = $4
+			WHERE id = $5 AND album_id = $6
+		`, m.OSSKey, m.OriginalURL, m.ThumbnailOSSKey, m.ThumbnailURL, m.PhotoID, t.AlbumID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The recipient no longer needs collaborator access to their own album
+	_, err = tx.Exec(ctx, `DELETE FROM album_collaborators WHERE album_id = $1 AND user_id = $2`, t.AlbumID, t.ToUserID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM collection_albums
+		WHERE album_id = $1 AND collection_id IN (SELECT id FROM collections WHERE user_id = $2)
+	`, t.AlbumID, t.FromUserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
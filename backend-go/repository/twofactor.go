@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"picshare/model"
+)
+
+// FindUserTOTP returns a user's authenticator enrollment
+func FindUserTOTP(ctx context.Context, userID int) (*model.UserTOTP, error) {
+	query := `
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_totp WHERE user_id = $1
+	`
+
+	var t model.UserTOTP
+	err := db.QueryRow(ctx, query, userID).Scan(
+		&t.UserID,
+		&t.Secret,
+		&t.EnabledAt,
+		&t.LastUsedStep,
+		&t.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// IsTwoFactorEnabled reports whether a user has confirmed 2FA enrollment
+func IsTwoFactorEnabled(ctx context.Context, userID int) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM user_totp WHERE user_id = $1 AND enabled_at IS NOT NULL)`
+
+	var enabled bool
+	err := db.QueryRow(ctx, query, userID).Scan(&enabled)
+	return enabled, err
+}
+
+// SaveTOTPSecret starts (or restarts) enrollment with a new secret. An
+// enrollment that is already enabled is left untouched.
+func SaveTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_totp.enabled_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// EnableTOTP confirms enrollment and stores a fresh set of recovery code hashes
+func EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE user_totp SET enabled_at = NOW(), last_used_step = $1
+		WHERE user_id = $2 AND enabled_at IS NULL
+	`, step, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MarkTOTPStepUsed records the time step of an accepted code. It fails with
+// ErrNoRowsUpdated if that step (or a later one) was already used, which
+// stops a code from being replayed within its validity window.
+func MarkTOTPStepUsed(ctx context.Context, userID int, step int64) error {
+	query := `
+		UPDATE user_totp SET last_used_step = $1
+		WHERE user_id = $2 AND enabled_at IS NOT NULL AND last_used_step < $1
+	`
+	result, err := db.Exec(ctx, query, step, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// DisableTOTP removes a user's enrollment and recovery codes
+func DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode consumes an unused recovery code
+func UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	query := `
+		UPDATE totp_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates all recovery codes and stores new ones
+func ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a user has left
+func CountUnusedRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM totp_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	err := db.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
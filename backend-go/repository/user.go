@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"picshare/model"
 )
 
@@ -36,7 +39,7 @@ func FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
 		SELECT id, email, password_hash, name, role, email_verified,
 			verification_token, verification_expires, reset_token, reset_expires,
-			avatar_url, created_at, updated_at
+			avatar_url, expiry_policy, created_at, updated_at
 		FROM users WHERE email = $1
 	`
 
@@ -53,6 +56,7 @@ func FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
 		&user.ResetToken,
 		&user.ResetExpires,
 		&user.AvatarURL,
+		&user.ExpiryPolicy,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -68,7 +72,7 @@ func FindUserByID(ctx context.Context, id int) (*model.User, error) {
 	query := `
 		SELECT id, email, password_hash, name, role, email_verified,
 			verification_token, verification_expires, reset_token, reset_expires,
-			avatar_url, created_at, updated_at
+			avatar_url, expiry_policy, created_at, updated_at
 		FROM users WHERE id = $1
 	`
 
@@ -85,6 +89,7 @@ func FindUserByID(ctx context.Context, id int) (*model.User, error) {
 		&user.ResetToken,
 		&user.ResetExpires,
 		&user.AvatarURL,
+		&user.ExpiryPolicy,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -95,13 +100,34 @@ func FindUserByID(ctx context.Context, id int) (*model.User, error) {
 	return &user, nil
 }
 
-// UpdateUser updates user fields
+// UpdateUser updates user fields; a nil avatarURL keeps the current avatar
 func UpdateUser(ctx context.Context, id int, name string, avatarURL *string) error {
-	query := `UPDATE users SET name = $1, avatar_url = $2, updated_at = NOW() WHERE id = $3`
+	query := `UPDATE users SET name = $1, avatar_url = COALESCE($2, avatar_url), updated_at = NOW() WHERE id = $3`
 	_, err := db.Exec(ctx, query, name, avatarURL, id)
 	return err
 }
 
+// UpdateUserAvatar sets or, with nil, removes a user's avatar
+func UpdateUserAvatar(ctx context.Context, id int, avatarURL *string) error {
+	query := `UPDATE users SET avatar_url = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.Exec(ctx, query, avatarURL, id)
+	return err
+}
+
+// UpdateUserExpiryPolicy updates the user's default album expiry policy
+func UpdateUserExpiryPolicy(ctx context.Context, id int, policy string) error {
+	query := `UPDATE users SET expiry_policy = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.Exec(ctx, query, policy, id)
+	return err
+}
+
+// UpdateUserRole changes a user's role
+func UpdateUserRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.Exec(ctx, query, role, id)
+	return err
+}
+
 // UpdateUserPassword updates user password
 func UpdateUserPassword(ctx context.Context, id int, passwordHash string) error {
 	query := `UPDATE users SET password_hash = $1, reset_token = NULL, reset_expires = NULL, updated_at = NOW() WHERE id = $2`
@@ -147,7 +173,7 @@ func FindUserByResetToken(ctx context.Context, token string) (*model.User, error
 	query := `
 		SELECT id, email, password_hash, name, role, email_verified,
 			verification_token, verification_expires, reset_token, reset_expires,
-			avatar_url, created_at, updated_at
+			avatar_url, expiry_policy, created_at, updated_at
 		FROM users WHERE reset_token = $1
 		AND (reset_expires IS NULL OR reset_expires > NOW())
 	`
@@ -165,6 +191,7 @@ func FindUserByResetToken(ctx context.Context, token string) (*model.User, error
 		&user.ResetToken,
 		&user.ResetExpires,
 		&user.AvatarURL,
+		&user.ExpiryPolicy,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -175,6 +202,147 @@ func FindUserByResetToken(ctx context.Context, token string) (*model.User, error
 	return &user, nil
 }
 
+// SetLoginToken stores the hash of a magic sign-in link token, replacing any earlier one
+func SetLoginToken(ctx context.Context, id int, tokenHash string, expires time.Time) error {
+	query := `UPDATE users SET login_token = $1, login_token_expires = $2 WHERE id = $3`
+	_, err := db.Exec(ctx, query, tokenHash, expires, id)
+	return err
+}
+
+// ConsumeLoginToken finds the user a magic sign-in link was sent to and
+// clears the token, so that each link works only once
+func ConsumeLoginToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	query := `
+		UPDATE users SET login_token = NULL, login_token_expires = NULL
+		WHERE login_token = $1 AND login_token_expires > NOW()
+		RETURNING id, email, password_hash, name, role, email_verified,
+			verification_token, verification_expires, reset_token, reset_expires,
+			avatar_url, expiry_policy, created_at, updated_at
+	`
+
+	var user model.User
+	err := db.QueryRow(ctx, query, tokenHash).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Name,
+		&user.Role,
+		&user.EmailVerified,
+		&user.VerificationToken,
+		&user.VerificationExpires,
+		&user.ResetToken,
+		&user.ResetExpires,
+		&user.AvatarURL,
+		&user.ExpiryPolicy,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetEmailChange records a requested email change awaiting confirmation,
+// replacing any earlier request
+func SetEmailChange(ctx context.Context, id int, newEmail, tokenHash string, expires time.Time) error {
+	query := `
+		UPDATE users SET pending_email = $1, email_change_token = $2, email_change_expires = $3
+		WHERE id = $4
+	`
+	_, err := db.Exec(ctx, query, newEmail, tokenHash, expires, id)
+	return err
+}
+
+// ClearEmailChange cancels a user's pending email change
+func ClearEmailChange(ctx context.Context, id int) error {
+	query := `
+		UPDATE users SET pending_email = NULL, email_change_token = NULL, email_change_expires = NULL
+		WHERE id = $1 AND pending_email IS NOT NULL
+	`
+	result, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// FindPendingEmail returns the address a user asked to switch to, if the
+// request has not expired
+func FindPendingEmail(ctx context.Context, id int) (*string, error) {
+	query := `
+		SELECT pending_email FROM users
+		WHERE id = $1 AND email_change_expires > NOW()
+	`
+
+	var pendingEmail *string
+	err := db.QueryRow(ctx, query, id).Scan(&pendingEmail)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return pendingEmail, err
+}
+
+// ConfirmEmailChange switches a user to the pending email the token was sent
+// to. The new address counts as verified, and links sent to the old address
+// (verification, password reset, magic sign-in) stop working. Returns
+// ErrEmailTaken if another account took the address in the meantime.
+func ConfirmEmailChange(ctx context.Context, tokenHash string) (*model.User, error) {
+	query := `
+		UPDATE users SET email = pending_email, email_verified = true,
+			pending_email = NULL, email_change_token = NULL, email_change_expires = NULL,
+			verification_token = NULL, verification_expires = NULL,
+			reset_token = NULL, reset_expires = NULL,
+			login_token = NULL, login_token_expires = NULL
+		WHERE email_change_token = $1 AND email_change_expires > NOW()
+		RETURNING id, email, password_hash, name, role, email_verified,
+			verification_token, verification_expires, reset_token, reset_expires,
+			avatar_url, expiry_policy, created_at, updated_at
+	`
+
+	var user model.User
+	err := db.QueryRow(ctx, query, tokenHash).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Name,
+		&user.Role,
+		&user.EmailVerified,
+		&user.VerificationToken,
+		&user.VerificationExpires,
+		&user.ResetToken,
+		&user.ResetExpires,
+		&user.AvatarURL,
+		&user.ExpiryPolicy,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// MarkEmailVerified marks a user's email as verified without a verification token
+func MarkEmailVerified(ctx context.Context, id int) error {
+	query := `
+		UPDATE users
+		SET email_verified = true, verification_token = NULL, verification_expires = NULL
+		WHERE id = $1
+	`
+	_, err := db.Exec(ctx, query, id)
+	return err
+}
+
 // CountUsers returns total user count
 func CountUsers(ctx context.Context) (int, error) {
 	var count int
@@ -187,7 +355,7 @@ func GetRecentUsers(ctx context.Context, limit int) ([]model.User, error) {
 	query := `
 		SELECT id, email, password_hash, name, role, email_verified,
 			verification_token, verification_expires, reset_token, reset_expires,
-			avatar_url, created_at, updated_at
+			avatar_url, expiry_policy, created_at, updated_at
 		FROM users ORDER BY created_at DESC LIMIT $1
 	`
 
@@ -212,6 +380,7 @@ func GetRecentUsers(ctx context.Context, limit int) ([]model.User, error) {
 			&u.ResetToken,
 			&u.ResetExpires,
 			&u.AvatarURL,
+			&u.ExpiryPolicy,
 			&u.CreatedAt,
 			&u.UpdatedAt,
 		)
@@ -239,7 +408,7 @@ func GetUsersPaginated(ctx context.Context, page, limit int) ([]model.User, int,
 	query := `
 		SELECT id, email, password_hash, name, role, email_verified,
 			verification_token, verification_expires, reset_token, reset_expires,
-			avatar_url, created_at, updated_at
+			avatar_url, expiry_policy, created_at, updated_at
 		FROM users ORDER BY created_at DESC LIMIT $1 OFFSET $2
 	`
 
@@ -264,6 +433,7 @@ func GetUsersPaginated(ctx context.Context, page, limit int) ([]model.User, int,
 			&u.ResetToken,
 			&u.ResetExpires,
 			&u.AvatarURL,
+			&u.ExpiryPolicy,
 			&u.CreatedAt,
 			&u.UpdatedAt,
 		)
@@ -290,6 +460,8 @@ func GetPhotographerUsersPaginated(ctx context.Context, page, limit int) ([]gin.
 	// Get users with album stats (matching Node.js query)
 	query := `
 		SELECT u.id, u.email, u.name, u.role, u.email_verified, u.created_at,
+			u.suspended_at, u.suspended_reason,
+			u.max_albums, u.max_photos_per_album, u.storage_quota_bytes,
 			COUNT(DISTINCT a.id) as album_count,
 			COALESCE(SUM(a.photo_count), 0) as total_photos,
 			COALESCE(SUM(a.view_count), 0) as total_views,
@@ -315,25 +487,33 @@ func GetPhotographerUsersPaginated(ctx context.Context, page, limit int) ([]gin.
 			email, name, role                                       string
 			emailVerified                                           bool
 			createdAt                                               time.Time
+			suspendedAt                                             *time.Time
+			suspendedReason                                         *string
+			quota                                                   model.UserQuota
 		)
 		err := rows.Scan(
 			&id, &email, &name, &role, &emailVerified, &createdAt,
+			&suspendedAt, &suspendedReason,
+			&quota.MaxAlbums, &quota.MaxPhotosPerAlbum, &quota.StorageQuotaBytes,
 			&albumCount, &totalPhotos, &totalViews, &totalDownloads,
 		)
 		if err != nil {
 			return nil, 0, err
 		}
 		users = append(users, gin.H{
-			"id":              id,
-			"email":           email,
-			"name":            name,
-			"role":            role,
-			"email_verified":  emailVerified,
-			"created_at":      createdAt,
-			"album_count":     albumCount,
-			"total_photos":    totalPhotos,
-			"total_views":     totalViews,
-			"total_downloads": totalDownloads,
+			"id":               id,
+			"email":            email,
+			"name":             name,
+			"role":             role,
+			"email_verified":   emailVerified,
+			"created_at":       createdAt,
+			"suspended_at":     suspendedAt,
+			"suspended_reason": suspendedReason,
+			"quota":            quota,
+			"album_count":      albumCount,
+			"total_photos":     totalPhotos,
+			"total_views":      totalViews,
+			"total_downloads":  totalDownloads,
 		})
 	}
 
@@ -364,4 +544,5 @@ func CountRecentAlbums(ctx context.Context, days int) (int, error) {
 // Custom errors
 var (
 	ErrNoRowsUpdated = fmt.Errorf("no rows updated")
+	ErrEmailTaken    = fmt.Errorf("email already registered")
 )
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+)
+
+const webAuthnCredentialColumns = `
+	id, user_id, credential_id, public_key, attestation_type, transports, aaguid,
+	sign_count, backup_eligible, backup_state, name, created_at, last_used_at
+`
+
+func scanWebAuthnCredential(row interface{ Scan(...any) error }, c *model.WebAuthnCredential) error {
+	return row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.CredentialID,
+		&c.PublicKey,
+		&c.AttestationType,
+		&c.Transports,
+		&c.AAGUID,
+		&c.SignCount,
+		&c.BackupEligible,
+		&c.BackupState,
+		&c.Name,
+		&c.CreatedAt,
+		&c.LastUsedAt,
+	)
+}
+
+// CreateWebAuthnCredential stores a newly registered passkey
+func CreateWebAuthnCredential(ctx context.Context, c *model.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (user_id, credential_id, public_key, attestation_type,
+			transports, aaguid, sign_count, backup_eligible, backup_state, name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+
+	return db.QueryRow(ctx, query,
+		c.UserID,
+		c.CredentialID,
+		c.PublicKey,
+		c.AttestationType,
+		c.Transports,
+		c.AAGUID,
+		c.SignCount,
+		c.BackupEligible,
+		c.BackupState,
+		c.Name,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+// GetWebAuthnCredentialsByUser returns a user's passkeys, newest first
+func GetWebAuthnCredentialsByUser(ctx context.Context, userID int) ([]model.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + `
+		FROM webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []model.WebAuthnCredential
+	for rows.Next() {
+		var c model.WebAuthnCredential
+		if err := scanWebAuthnCredential(rows, &c); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
+
+// FindWebAuthnCredentialByCredentialID finds a passkey by the authenticator's credential ID
+func FindWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + `
+		FROM webauthn_credentials WHERE credential_id = $1
+	`
+
+	var c model.WebAuthnCredential
+	if err := scanWebAuthnCredential(db.QueryRow(ctx, query, credentialID), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateWebAuthnCredentialUsage records a successful sign-in with a passkey
+func UpdateWebAuthnCredentialUsage(ctx context.Context, id int, signCount int64, backupState bool) error {
+	query := `
+		UPDATE webauthn_credentials
+		SET sign_count = $1, backup_state = $2, last_used_at = NOW()
+		WHERE id = $3
+	`
+	_, err := db.Exec(ctx, query, signCount, backupState, id)
+	return err
+}
+
+// DeleteWebAuthnCredential removes one of a user's passkeys
+func DeleteWebAuthnCredential(ctx context.Context, id, userID int) error {
+	query := `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`
+	result, err := db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// CreateWebAuthnChallenge stores the server state of a pending passkey
+// ceremony. userID is nil for sign-in, where the user is not known yet.
+func CreateWebAuthnChallenge(ctx context.Context, tokenHash string, userID *int, purpose string, sessionData []byte, expiresAt time.Time) error {
+	query := `
+		INSERT INTO webauthn_challenges (token_hash, user_id, purpose, session_data, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := db.Exec(ctx, query, tokenHash, userID, purpose, sessionData, expiresAt)
+	return err
+}
+
+// ConsumeWebAuthnChallenge deletes a pending ceremony and returns its state,
+// so each challenge can only be answered once
+func ConsumeWebAuthnChallenge(ctx context.Context, tokenHash, purpose string) (*int, []byte, error) {
+	query := `
+		DELETE FROM webauthn_challenges
+		WHERE token_hash = $1 AND purpose = $2 AND expires_at > NOW()
+		RETURNING user_id, session_data
+	`
+
+	var userID *int
+	var sessionData []byte
+	err := db.QueryRow(ctx, query, tokenHash, purpose).Scan(&userID, &sessionData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return userID, sessionData, nil
+}
+
+// DeleteExpiredWebAuthnChallenges removes ceremonies that were never completed
+func DeleteExpiredWebAuthnChallenges(ctx context.Context) (int64, error) {
+	result, err := db.Exec(ctx, `DELETE FROM webauthn_challenges WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,518 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"picshare/model"
+)
+
+// ErrLastWorkspaceOwner is returned when a change would leave a workspace
+// without an owner
+var ErrLastWorkspaceOwner = errors.New("workspace must keep an owner")
+
+// CreateWorkspace creates a workspace with its creator as owner
+func CreateWorkspace(ctx context.Context, w *model.Workspace) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO workspaces (name, created_by)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, w.Name, w.CreatedBy).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+	`, w.ID, w.CreatedBy, model.WorkspaceRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	w.Role = model.WorkspaceRoleOwner
+	w.MemberCount = 1
+	return tx.Commit(ctx)
+}
+
+const workspaceForMemberQuery = `
+	SELECT w.id, w.name, w.created_by, w.created_at, w.updated_at, m.role,
+		(SELECT COUNT(*) FROM workspace_members WHERE workspace_id = w.id),
+		(SELECT COUNT(*) FROM albums WHERE workspace_id = w.id)
+	FROM workspaces w
+	JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $1
+`
+
+// scanWorkspace scans a row selected with workspaceForMemberQuery
+func scanWorkspace(row interface{ Scan(...any) error }) (*model.Workspace, error) {
+	var w model.Workspace
+	err := row.Scan(
+		&w.ID,
+		&w.Name,
+		&w.CreatedBy,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+		&w.Role,
+		&w.MemberCount,
+		&w.AlbumCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// FindWorkspaceForMember finds a workspace the user is a member of,
+// with the user's role
+func FindWorkspaceForMember(ctx context.Context, id, userID int) (*model.Workspace, error) {
+	return scanWorkspace(db.QueryRow(ctx, workspaceForMemberQuery+` WHERE w.id = $2`, userID, id))
+}
+
+// GetWorkspacesByUser returns all workspaces a user is a member of
+func GetWorkspacesByUser(ctx context.Context, userID int) ([]model.Workspace, error) {
+	rows, err := db.Query(ctx, workspaceForMemberQuery+` ORDER BY w.name ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []model.Workspace
+	for rows.Next() {
+		w, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, *w)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// UpdateWorkspaceName renames a workspace
+func UpdateWorkspaceName(ctx context.Context, id int, name string) error {
+	query := `UPDATE workspaces SET name = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.Exec(ctx, query, name, id)
+	return err
+}
+
+// DeleteWorkspace deletes a workspace; its albums return to their creators
+func DeleteWorkspace(ctx context.Context, id int) error {
+	query := `DELETE FROM workspaces WHERE id = $1`
+	_, err := db.Exec(ctx, query, id)
+	return err
+}
+
+// GetWorkspaceRole returns a user's role in a workspace
+func GetWorkspaceRole(ctx context.Context, workspaceID, userID int) (string, error) {
+	var role string
+	err := db.QueryRow(ctx,
+		"SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+		workspaceID, userID,
+	).Scan(&role)
+	return role, err
+}
+
+// GetWorkspaceMembers returns the members of a workspace
+func GetWorkspaceMembers(ctx context.Context, workspaceID int) ([]model.WorkspaceMember, error) {
+	query := `
+		SELECT m.workspace_id, m.user_id, m.role, u.name, u.email, u.avatar_url, m.created_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = $1
+		ORDER BY m.created_at ASC
+	`
+
+	rows, err := db.Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []model.WorkspaceMember
+	for rows.Next() {
+		var m model.WorkspaceMember
+		err := rows.Scan(
+			&m.WorkspaceID,
+			&m.UserID,
+			&m.Role,
+			&m.Name,
+			&m.Email,
+			&m.AvatarURL,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// UpdateWorkspaceMemberRole changes a member's role.
+// Returns ErrLastWorkspaceOwner instead of demoting the only owner.
+func UpdateWorkspaceMemberRole(ctx context.Context, workspaceID, userID int, role string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if role != model.WorkspaceRoleOwner {
+		if err := ensureOtherWorkspaceOwner(ctx, tx, workspaceID, userID); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3`
+	result, err := tx.Exec(ctx, query, role, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveWorkspaceMember removes a member from a workspace.
+// Returns ErrLastWorkspaceOwner instead of removing the only owner.
+func RemoveWorkspaceMember(ctx context.Context, workspaceID, userID int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := ensureOtherWorkspaceOwner(ctx, tx, workspaceID, userID); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(ctx, query, workspaceID, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ensureOtherWorkspaceOwner returns ErrLastWorkspaceOwner if the user is the
+// workspace's only owner. The workspace row is locked so that concurrent
+// demotions or removals see each other's changes.
+func ensureOtherWorkspaceOwner(ctx context.Context, tx pgx.Tx, workspaceID, userID int) error {
+	if _, err := tx.Exec(ctx, "SELECT 1 FROM workspaces WHERE id = $1 FOR UPDATE", workspaceID); err != nil {
+		return err
+	}
+
+	var isOwner bool
+	var others int
+	err := tx.QueryRow(ctx, `
+		SELECT
+			COALESCE(BOOL_OR(user_id = $2), false),
+			COUNT(*) FILTER (WHERE user_id <> $2)
+		FROM workspace_members
+		WHERE workspace_id = $1 AND role = $3
+	`, workspaceID, userID, model.WorkspaceRoleOwner).Scan(&isOwner, &others)
+	if err != nil {
+		return err
+	}
+	if isOwner && others == 0 {
+		return ErrLastWorkspaceOwner
+	}
+	return nil
+}
+
+// handOverWorkspaces prepares the workspaces of a user who is about to be
+// deleted. Workspace albums they created, and the photos in them, pass to
+// another owner, or to the longest-standing member who is made owner if
+// there is none; otherwise they would be deleted along with the user. A
+// workspace the user is the only member of is deleted, which returns its
+// albums to their creators.
+func handOverWorkspaces(ctx context.Context, tx pgx.Tx, userID int) error {
+	rows, err := tx.Query(ctx, "SELECT workspace_id FROM workspace_members WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+	var workspaceIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		workspaceIDs = append(workspaceIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, workspaceID := range workspaceIDs {
+		if _, err := tx.Exec(ctx, "SELECT 1 FROM workspaces WHERE id = $1 FOR UPDATE", workspaceID); err != nil {
+			return err
+		}
+
+		var successorID int
+		err := tx.QueryRow(ctx, `
+			SELECT user_id FROM workspace_members
+			WHERE workspace_id = $1 AND user_id <> $2
+			ORDER BY role = $3 DESC, created_at ASC, user_id ASC
+			LIMIT 1
+		`, workspaceID, userID, model.WorkspaceRoleOwner).Scan(&successorID)
+		if err == pgx.ErrNoRows {
+			if _, err := tx.Exec(ctx, "DELETE FROM workspaces WHERE id = $1", workspaceID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE workspace_members SET role = $3 WHERE workspace_id = $1 AND user_id = $2
+		`, workspaceID, successorID, model.WorkspaceRoleOwner)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE photos SET user_id = $3
+			WHERE user_id = $2 AND album_id IN (SELECT id FROM albums WHERE workspace_id = $1)
+		`, workspaceID, userID, successorID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE albums SET user_id = $3, updated_at = NOW()
+			WHERE workspace_id = $1 AND user_id = $2
+		`, workspaceID, userID, successorID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateWorkspaceInvite creates an invitation, replacing any pending
+// invitation of the same email to the same workspace
+func CreateWorkspaceInvite(ctx context.Context, inv *model.WorkspaceInvite) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM workspace_invites
+		WHERE workspace_id = $1 AND email = $2 AND accepted_at IS NULL
+	`, inv.WorkspaceID, inv.Email)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO workspace_invites (workspace_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, query,
+		inv.WorkspaceID,
+		inv.Email,
+		inv.Role,
+		inv.TokenHash,
+		inv.InvitedBy,
+		inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindWorkspaceInviteByTokenHash finds a pending, unexpired invitation
+func FindWorkspaceInviteByTokenHash(ctx context.Context, tokenHash string) (*model.WorkspaceInvite, error) {
+	query := `
+		SELECT i.id, i.workspace_id, i.email, i.role, i.token_hash, i.invited_by,
+			i.expires_at, i.accepted_at, i.created_at, w.name
+		FROM workspace_invites i
+		JOIN workspaces w ON w.id = i.workspace_id
+		WHERE i.token_hash = $1 AND i.accepted_at IS NULL AND i.expires_at > NOW()
+	`
+
+	var inv model.WorkspaceInvite
+	err := db.QueryRow(ctx, query, tokenHash).Scan(
+		&inv.ID,
+		&inv.WorkspaceID,
+		&inv.Email,
+		&inv.Role,
+		&inv.TokenHash,
+		&inv.InvitedBy,
+		&inv.ExpiresAt,
+		&inv.AcceptedAt,
+		&inv.CreatedAt,
+		&inv.WorkspaceName,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// GetPendingWorkspaceInvites returns unaccepted, unexpired invitations of a workspace
+func GetPendingWorkspaceInvites(ctx context.Context, workspaceID int) ([]model.WorkspaceInvite, error) {
+	query := `
+		SELECT i.id, i.workspace_id, i.email, i.role, i.token_hash, i.invited_by,
+			i.expires_at, i.accepted_at, i.created_at, w.name
+		FROM workspace_invites i
+		JOIN workspaces w ON w.id = i.workspace_id
+		WHERE i.workspace_id = $1 AND i.accepted_at IS NULL AND i.expires_at > NOW()
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := db.Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []model.WorkspaceInvite
+	for rows.Next() {
+		var inv model.WorkspaceInvite
+		err := rows.Scan(
+			&inv.ID,
+			&inv.WorkspaceID,
+			&inv.Email,
+			&inv.Role,
+			&inv.TokenHash,
+			&inv.InvitedBy,
+			&inv.ExpiresAt,
+			&inv.AcceptedAt,
+			&inv.CreatedAt,
+			&inv.WorkspaceName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, inv)
+	}
+
+	return invites, rows.Err()
+}
+
+// DeleteWorkspaceInvite revokes an invitation
+func DeleteWorkspaceInvite(ctx context.Context, id, workspaceID int) error {
+	query := `DELETE FROM workspace_invites WHERE id = $1 AND workspace_id = $2 AND accepted_at IS NULL`
+	result, err := db.Exec(ctx, query, id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+	return nil
+}
+
+// AcceptWorkspaceInvite marks an invitation accepted and adds the user to
+// the workspace. Existing members keep their current role.
+func AcceptWorkspaceInvite(ctx context.Context, inv *model.WorkspaceInvite, userID int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE workspace_invites SET accepted_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL
+	`, inv.ID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNoRowsUpdated
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id) DO NOTHING
+	`, inv.WorkspaceID, userID, inv.Role)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetAlbumsByWorkspace returns paginated albums of a workspace
+func GetAlbumsByWorkspace(ctx context.Context, workspaceID int, page, limit int) ([]model.Album, int, error) {
+	offset := (page - 1) * limit
+
+	// Get total count
+	var total int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM albums WHERE workspace_id = $1", workspaceID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get albums
+	query := `
+		SELECT id, user_id, title, share_code, description, cover_url,
+			photo_count, view_count, download_count, expires_at, is_expired,
+			expiry_policy, archived_at, workspace_id, created_at, updated_at
+		FROM albums WHERE workspace_id = $1
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3
+	`
+
+	rows, err := db.Query(ctx, query, workspaceID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var albums []model.Album
+	for rows.Next() {
+		var a model.Album
+		err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Title,
+			&a.ShareCode,
+			&a.Description,
+			&a.CoverURL,
+			&a.PhotoCount,
+			&a.ViewCount,
+			&a.DownloadCount,
+			&a.ExpiresAt,
+			&a.IsExpired,
+			&a.ExpiryPolicy,
+			&a.ArchivedAt,
+			&a.WorkspaceID,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		albums = append(albums, a)
+	}
+
+	return albums, total, rows.Err()
+}
+
+// SetAlbumWorkspace moves an album into a workspace, or back to its
+// creator's personal albums when workspaceID is nil
+func SetAlbumWorkspace(ctx context.Context, albumID int, workspaceID *int) error {
+	query := `UPDATE albums SET workspace_id = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.Exec(ctx, query, workspaceID, albumID)
+	return err
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+)
+
+// accountStoragePrefixes are the OSS prefixes holding only a user's files:
+// avatars and data exports. Photos are deleted by key, since an album
+// transferred without moving its files keeps them under photos/<userId>/.
+func accountStoragePrefixes(userID int) []string {
+	return []string{
+		fmt.Sprintf("avatars/%d/", userID),
+		fmt.Sprintf("exports/%d/", userID),
+	}
+}
+
+// ScheduleAccountDeletion schedules the user's account to be purged after
+// the grace period and signs out every other session. Returns
+// repository.ErrNoRowsUpdated if a deletion is already scheduled.
+func ScheduleAccountDeletion(ctx context.Context, user *model.User, currentSessionID int, ip, userAgent string) (time.Time, error) {
+	scheduledAt := time.Now().Add(config.Get().Account.DeletionGracePeriod)
+	if err := repository.ScheduleAccountDeletion(ctx, user.ID, scheduledAt); err != nil {
+		return time.Time{}, err
+	}
+
+	if _, err := repository.RevokeUserSessions(ctx, user.ID, currentSessionID, model.SessionRevokedAccountDeletion); err != nil {
+		util.Log("Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+	logSecurityEvent(ctx, model.SecurityEventDeletionRequested, &user.ID, user.Email, ip, userAgent,
+		"scheduled for "+util.FormatDate(scheduledAt))
+
+	if err := GetEmailService().SendAccountDeletionScheduledEmail(user.Email, user.Name, scheduledAt); err != nil {
+		util.Log("Failed to send account deletion notice to %s: %v", user.Email, err)
+	}
+
+	return scheduledAt, nil
+}
+
+// CancelAccountDeletion keeps the account if its grace period has not
+// passed. Returns repository.ErrNoRowsUpdated if there is nothing to cancel.
+func CancelAccountDeletion(ctx context.Context, user *model.User, ip, userAgent string) error {
+	if err := repository.CancelAccountDeletion(ctx, user.ID); err != nil {
+		return err
+	}
+	logSecurityEvent(ctx, model.SecurityEventDeletionCancelled, &user.ID, user.Email, ip, userAgent, "")
+	return nil
+}
+
+// PurgeAccount deletes every stored file of a user and then the user's
+// database rows. Files go first so a failure leaves the account in place
+// to be retried, rather than orphaned objects nobody can find. Albums in
+// workspaces shared with others are kept and handed to another member.
+func PurgeAccount(ctx context.Context, userID int) error {
+	ossService := GetOSSService()
+	if ossService == nil {
+		return fmt.Errorf("OSS service not available")
+	}
+
+	keys, err := repository.GetUserPhotoOSSKeys(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get photo keys: %w", err)
+	}
+	if err := ossService.DeletePhotos(ctx, keys); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		util.Log("Deleted %d photo objects of user %d", len(keys), userID)
+	}
+
+	for _, prefix := range accountStoragePrefixes(userID) {
+		count, err := ossService.DeletePrefix(ctx, prefix)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", prefix, err)
+		}
+		if count > 0 {
+			util.Log("Deleted %d objects under %s", count, prefix)
+		}
+	}
+
+	return repository.DeleteUser(ctx, userID)
+}
+
+// runAccountDeletions purges accounts whose deletion grace period has passed
+func runAccountDeletions(ctx context.Context) {
+	ids, err := repository.GetAccountsDueForDeletion(ctx)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to get accounts due for deletion: %v\n", err)
+		return
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if err := PurgeAccount(ctx, id); err != nil {
+			fmt.Printf("[Cron] Failed to delete account %d: %v\n", id, err)
+			continue
+		}
+		deleted++
+	}
+	if len(ids) > 0 {
+		fmt.Printf("[Cron] Deleted %d of %d accounts due for deletion\n", deleted, len(ids))
+	}
+}
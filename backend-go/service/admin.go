@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+)
+
+// forcedResetTTL is how long the link sent by a forced password reset works
+const forcedResetTTL = 24 * time.Hour
+
+// AdminError is a user-facing error for an admin action
+type AdminError struct {
+	Message string
+}
+
+func (e *AdminError) Error() string {
+	return e.Message
+}
+
+// AdminActor is the admin performing an action, for the audit log
+type AdminActor struct {
+	ID        int
+	IP        string
+	UserAgent string
+}
+
+// systemActor stands in for an admin when the system moderates on its own
+var systemActor = AdminActor{}
+
+// LogAdminAction records an admin action in the audit trail. Details are
+// stored as JSON; failures are logged rather than failing the action.
+func LogAdminAction(ctx context.Context, actor AdminActor, action, targetType string, targetID int, details map[string]any) {
+	RecordAuditEvent(ctx, AuditActor{
+		UserID:    actor.ID,
+		IP:        actor.IP,
+		UserAgent: actor.UserAgent,
+	}, action, targetType, targetID, details)
+}
+
+// findManagedUser loads the target of an admin action on a user. Admins
+// cannot act on their own account, so they cannot lock themselves out.
+func findManagedUser(ctx context.Context, actor AdminActor, userID int) (*model.User, error) {
+	if userID == actor.ID {
+		return nil, &AdminError{"不能对自己的账号执行此操作"}
+	}
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, &AdminError{"用户不存在"}
+	}
+	return user, nil
+}
+
+// SuspendUser blocks sign-in and API access for a user and signs out all
+// of their sessions
+func SuspendUser(ctx context.Context, actor AdminActor, userID int, reason string) error {
+	user, err := findManagedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+	if err := repository.SuspendUser(ctx, user.ID, reasonPtr); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			return &AdminError{"该用户已被停用"}
+		}
+		return err
+	}
+
+	if _, err := repository.RevokeUserSessions(ctx, user.ID, 0, model.SessionRevokedSuspended); err != nil {
+		util.Log("Failed to revoke sessions of suspended user %d: %v", user.ID, err)
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionSuspendUser, model.AuditTargetUser, user.ID, map[string]any{
+		"email":  user.Email,
+		"reason": reason,
+	})
+	return nil
+}
+
+// UnsuspendUser lets a suspended user sign in again
+func UnsuspendUser(ctx context.Context, actor AdminActor, userID int) error {
+	user, err := findManagedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.UnsuspendUser(ctx, user.ID); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			return &AdminError{"该用户未被停用"}
+		}
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionUnsuspendUser, model.AuditTargetUser, user.ID, map[string]any{
+		"email": user.Email,
+	})
+	return nil
+}
+
+// ChangeUserRole promotes or demotes a user. The new role applies to the
+// user's next request, as sessions load the role on every request.
+func ChangeUserRole(ctx context.Context, actor AdminActor, userID int, role string) error {
+	if !model.IsValidRole(role) {
+		return &AdminError{"无效的角色"}
+	}
+
+	user, err := findManagedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	if err := repository.UpdateUserRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionChangeRole, model.AuditTargetUser, user.ID, map[string]any{
+		"email": user.Email,
+		"from":  user.Role,
+		"to":    role,
+	})
+	return nil
+}
+
+// SetUserQuota replaces a user's limit overrides; nil fields use the defaults
+func SetUserQuota(ctx context.Context, actor AdminActor, userID int, quota *model.UserQuota) error {
+	if (quota.MaxAlbums != nil && *quota.MaxAlbums < 0) ||
+		(quota.MaxPhotosPerAlbum != nil && *quota.MaxPhotosPerAlbum < 0) ||
+		(quota.StorageQuotaBytes != nil && *quota.StorageQuotaBytes < 0) {
+		return &AdminError{"配额不能为负数"}
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		return &AdminError{"用户不存在"}
+	}
+	previous, err := repository.FindUserQuota(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.SetUserQuota(ctx, user.ID, quota); err != nil {
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionSetQuota, model.AuditTargetUser, user.ID, map[string]any{
+		"email": user.Email,
+		"from":  previous,
+		"to":    quota,
+	})
+	return nil
+}
+
+// ForcePasswordReset replaces a user's password with an unusable one,
+// signs out all of their sessions and emails them a link to set a new one
+func ForcePasswordReset(ctx context.Context, actor AdminActor, userID int) error {
+	user, err := findManagedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	// Nobody knows this password, so only the emailed link gets back in
+	hash, err := util.HashPassword(util.GenerateRandomToken())
+	if err != nil {
+		return err
+	}
+	if err := repository.UpdateUserPassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+	if _, err := repository.RevokeUserSessions(ctx, user.ID, 0, model.SessionRevokedPasswordReset); err != nil {
+		util.Log("Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	resetToken := util.GenerateRandomToken()
+	resetExpires := time.Now().Add(forcedResetTTL)
+	if err := repository.SetResetToken(ctx, user.ID, resetToken, resetExpires); err != nil {
+		return err
+	}
+	if err := GetEmailService().SendForcedPasswordResetEmail(user.Email, user.Name, resetToken, resetExpires); err != nil {
+		util.Log("Failed to send forced password reset email to %s: %v", user.Email, err)
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionForcePasswordReset, model.AuditTargetUser, user.ID, map[string]any{
+		"email": user.Email,
+	})
+	return nil
+}
+
+// AdminDeleteUser immediately deletes a user with all of their personal
+// albums, photos and stored files; see PurgeAccount
+func AdminDeleteUser(ctx context.Context, actor AdminActor, userID int) error {
+	user, err := findManagedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := PurgeAccount(ctx, user.ID); err != nil {
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionDeleteUser, model.AuditTargetUser, user.ID, map[string]any{
+		"email": user.Email,
+		"name":  user.Name,
+	})
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strings"
+)
+
+// ArchiveAlbum moves an album's originals to the archive storage class,
+// drops its thumbnails and marks it archived. The album stays visible to
+// its owner in a read-only state until it is reactivated.
+func ArchiveAlbum(ctx context.Context, album *model.Album) error {
+	photos, err := repository.GetPhotosByAlbum(ctx, album.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	ossService := GetOSSService()
+	if ossService == nil {
+		return fmt.Errorf("OSS not initialized")
+	}
+
+	storageClass := config.Get().OSS.ArchiveStorageClass
+	var thumbKeys []string
+	for _, p := range photos {
+		if err := ossService.SetStorageClass(ctx, p.OSSKey, storageClass); err != nil {
+			return fmt.Errorf("failed to archive photo %d: %w", p.ID, err)
+		}
+		if p.ThumbnailOSSKey != "" {
+			thumbKeys = append(thumbKeys, p.ThumbnailOSSKey)
+		}
+	}
+
+	if err := ossService.DeletePhotos(ctx, thumbKeys); err != nil {
+		return fmt.Errorf("failed to delete thumbnails: %w", err)
+	}
+
+	return repository.MarkAlbumArchived(ctx, album.ID)
+}
+
+// RestoreArchivedAlbum moves originals back to standard storage and
+// regenerates the thumbnails dropped by ArchiveAlbum. If any thumbnail
+// cannot be regenerated an error is returned so the album stays archived;
+// thumbnails that were rebuilt are kept and skipped on the next attempt.
+func RestoreArchivedAlbum(ctx context.Context, album *model.Album) error {
+	photos, err := repository.GetPhotosByAlbum(ctx, album.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	ossService := GetOSSService()
+	if ossService == nil {
+		return fmt.Errorf("OSS not initialized")
+	}
+	imageService := GetImageService()
+
+	var coverURL string
+	var failed []int
+	for _, p := range photos {
+		if err := ossService.SetStorageClass(ctx, p.OSSKey, "Standard"); err != nil {
+			return fmt.Errorf("failed to restore photo %d: %w", p.ID, err)
+		}
+
+		thumbnailURL := p.ThumbnailURL
+		if p.ThumbnailOSSKey == "" {
+			data, err := ossService.GetObjectBytes(ctx, p.OSSKey)
+			if err != nil {
+				util.Log("Failed to read archived photo %d: %v", p.ID, err)
+				failed = append(failed, p.ID)
+				continue
+			}
+
+			thumbnail, _, _, err := imageService.GenerateThumbnailFromBuffer(data, p.MimeType)
+			if err != nil {
+				util.Log("Failed to regenerate thumbnail for photo %d: %v", p.ID, err)
+				failed = append(failed, p.ID)
+				continue
+			}
+
+			thumbKey := thumbnailKeyFor(p.OSSKey)
+			thumbnailURL, err = ossService.UploadBytes(ctx, thumbKey, thumbnail, "image/jpeg")
+			if err != nil {
+				util.Log("Failed to upload thumbnail for photo %d: %v", p.ID, err)
+				failed = append(failed, p.ID)
+				continue
+			}
+
+			if err := repository.UpdatePhotoThumbnail(ctx, p.ID, thumbnailURL, thumbKey); err != nil {
+				return fmt.Errorf("failed to save thumbnail for photo %d: %w", p.ID, err)
+			}
+		}
+
+		if coverURL == "" {
+			coverURL = thumbnailURL
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to regenerate thumbnails for photos %v", failed)
+	}
+
+	if coverURL != "" {
+		return repository.UpdateAlbumCover(ctx, album.ID, coverURL)
+	}
+	return nil
+}
+
+// thumbnailKeyFor derives the thumbnail key for an original, following
+// the photos/<user>/<album>/thumb_<fileID>.jpg layout used by UploadPhoto
+func thumbnailKeyFor(ossKey string) string {
+	dir, file := path.Split(ossKey)
+	return dir + "thumb_" + strings.TrimSuffix(file, path.Ext(file)) + ".jpg"
+}
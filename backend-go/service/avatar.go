@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AvatarSizes are the square sizes (in pixels) each avatar is stored at. The
+// first is the one saved in users.avatar_url; the others sit next to it.
+var AvatarSizes = []int{512, 256, 64}
+
+const avatarQuality = 85
+
+// AvatarOSSKey returns the OSS key of one size of an avatar
+func AvatarOSSKey(userID int, fileID string, size int) string {
+	return fmt.Sprintf("avatars/%d/%s_%d.jpg", userID, fileID, size)
+}
+
+// avatarSizeSuffix is the end of an avatar object name for a size
+func avatarSizeSuffix(size int) string {
+	return "_" + strconv.Itoa(size) + ".jpg"
+}
+
+// AvatarURLs returns the URL of every avatar size, keyed by size, given the
+// stored avatar URL. Avatars not in the multi-size layout use the same URL
+// for every size.
+func AvatarURLs(avatarURL string) map[string]string {
+	urls := make(map[string]string, len(AvatarSizes))
+	base, ok := strings.CutSuffix(avatarURL, avatarSizeSuffix(AvatarSizes[0]))
+	for _, size := range AvatarSizes {
+		if ok {
+			urls[strconv.Itoa(size)] = base + avatarSizeSuffix(size)
+		} else {
+			urls[strconv.Itoa(size)] = avatarURL
+		}
+	}
+	return urls
+}
+
+// DeleteAvatarObjects removes every stored size of an avatar
+func DeleteAvatarObjects(ctx context.Context, avatarURL string) error {
+	ossService := GetOSSService()
+	if ossService == nil {
+		return nil
+	}
+
+	key, ok := ossService.KeyFromURL(avatarURL)
+	if !ok {
+		return nil
+	}
+
+	keys := []string{key}
+	if base, ok := strings.CutSuffix(key, avatarSizeSuffix(AvatarSizes[0])); ok {
+		keys = keys[:0]
+		for _, size := range AvatarSizes {
+			keys = append(keys, base+avatarSizeSuffix(size))
+		}
+	}
+
+	return ossService.DeletePhotos(ctx, keys)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"picshare/config"
+	"picshare/model"
 	"picshare/repository"
 
 	"github.com/robfig/cron/v3"
@@ -28,6 +30,16 @@ func InitCleanup() {
 		fmt.Printf("[Cron] Failed to schedule cleanup job: %v\n", err)
 	}
 
+	// Reminders run more often than the shortest reminder offset, so that
+	// e.g. a 1h reminder is not sent after the album has already expired
+	interval := config.Get().Reminder.Interval
+	_, err = cleanupService.cron.AddFunc(fmt.Sprintf("@every %s", interval), func() {
+		runExpiryReminders(context.Background())
+	})
+	if err != nil {
+		fmt.Printf("[Cron] Failed to schedule expiry reminder job: %v\n", err)
+	}
+
 	// Start cron
 	cleanupService.cron.Start()
 	fmt.Println("[Cron] Cleanup service started, running every hour")
@@ -52,6 +64,19 @@ func runCleanup() {
 
 	ctx := context.Background()
 
+	// Purge ended login sessions and abandoned passkey and SSO logins
+	runSessionCleanup(ctx)
+	runPasskeyChallengeCleanup(ctx)
+	runOIDCStateCleanup(ctx)
+	runLoginGuardCleanup(ctx)
+
+	// Purge accounts past their deletion grace period and expired data exports
+	runAccountDeletions(ctx)
+	runDataExportCleanup(ctx)
+
+	// Drop images of feedback resolved long ago
+	runFeedbackImageCleanup(ctx)
+
 	// Mark newly expired albums
 	count, err := repository.MarkAlbumsExpired(ctx)
 	if err != nil {
@@ -61,24 +86,36 @@ func runCleanup() {
 	}
 
 	// Get albums expired for more than 1 day
-	albums, err := repository.GetExpiredAlbumsForDeletion(ctx)
+	albums, err := repository.GetExpiredAlbumsForCleanup(ctx)
 	if err != nil {
 		fmt.Printf("[Cron] Failed to get expired albums: %v\n", err)
 		return
 	}
 
 	if len(albums) == 0 {
-		fmt.Println("[Cron] No albums to delete")
+		fmt.Println("[Cron] No albums to clean up")
 		return
 	}
 
-	fmt.Printf("[Cron] Found %d albums to delete\n", len(albums))
+	fmt.Printf("[Cron] Found %d albums to clean up\n", len(albums))
 
-	// Delete each album with its OSS files
+	// Delete or archive each album according to its expiry policy
 	deletedCount := 0
+	archivedCount := 0
 	filesDeleted := 0
 
-	for _, album := range albums {
+	for i := range albums {
+		album := &albums[i]
+
+		if album.ExpiryPolicy != nil && *album.ExpiryPolicy == model.ExpiryPolicyArchive {
+			if err := ArchiveAlbum(ctx, album); err != nil {
+				fmt.Printf("[Cron] Failed to archive album %d: %v\n", album.ID, err)
+				continue
+			}
+			archivedCount++
+			continue
+		}
+
 		// Get all photo OSS keys
 		keys, err := repository.GetPhotoOSSKeys(ctx, album.ID)
 		if err != nil {
@@ -107,8 +144,8 @@ func runCleanup() {
 		deletedCount++
 	}
 
-	fmt.Printf("[Cron] Cleanup completed: %d albums deleted, %d files removed from OSS\n",
-		deletedCount, filesDeleted)
+	fmt.Printf("[Cron] Cleanup completed: %d albums deleted, %d archived, %d files removed from OSS\n",
+		deletedCount, archivedCount, filesDeleted)
 }
 
 // RunCleanupNow triggers an immediate cleanup (for testing)
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+
+	"github.com/google/uuid"
+)
+
+// CopyAlbumPhotos copies every photo of src into dest with server-side OSS
+// copies, so no image data passes through the backend. Returns the number
+// of photos copied; photos that fail to copy are skipped and logged.
+func CopyAlbumPhotos(ctx context.Context, src, dest *model.Album) (int, error) {
+	photos, err := repository.GetPhotosByAlbum(ctx, src.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	ossService := GetOSSService()
+	if ossService == nil {
+		return 0, fmt.Errorf("OSS not initialized")
+	}
+
+	copied := 0
+	var coverURL string
+	for _, p := range photos {
+		fileID := uuid.New().String()
+		ossKey := fmt.Sprintf("photos/%d/%d/%s%s", dest.UserID, dest.ID, fileID, path.Ext(p.OSSKey))
+		thumbKey := thumbnailKeyFor(ossKey)
+
+		originalURL, err := ossService.CopyObject(ctx, p.OSSKey, ossKey)
+		if err != nil {
+			util.Log("Failed to copy photo %d: %v", p.ID, err)
+			continue
+		}
+		thumbnailURL, err := ossService.CopyObject(ctx, p.ThumbnailOSSKey, thumbKey)
+		if err != nil {
+			util.Log("Failed to copy thumbnail of photo %d: %v", p.ID, err)
+			_ = ossService.DeleteSingle(ctx, ossKey)
+			continue
+		}
+
+		photo := &model.Photo{
+			AlbumID:         dest.ID,
+			UserID:          dest.UserID,
+			OriginalName:    p.OriginalName,
+			OriginalURL:     originalURL,
+			ThumbnailURL:    thumbnailURL,
+			OSSKey:          ossKey,
+			ThumbnailOSSKey: thumbKey,
+			FileSize:        p.FileSize,
+			Width:           p.Width,
+			Height:          p.Height,
+			MimeType:        p.MimeType,
+		}
+		if err := repository.CreatePhoto(ctx, photo); err != nil {
+			util.Log("Failed to save copy of photo %d: %v", p.ID, err)
+			_ = ossService.DeletePhotos(ctx, []string{ossKey, thumbKey})
+			continue
+		}
+
+		copied++
+		if coverURL == "" {
+			coverURL = thumbnailURL
+		}
+	}
+
+	if copied > 0 {
+		if err := repository.IncrementAlbumPhotoCount(ctx, dest.ID, copied); err != nil {
+			return copied, err
+		}
+		if err := repository.UpdateAlbumCover(ctx, dest.ID, coverURL); err != nil {
+			return copied, err
+		}
+	}
+
+	return copied, nil
+}
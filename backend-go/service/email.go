@@ -2,10 +2,12 @@ package service
 
 import (
 	"fmt"
+	"html"
 	"picshare/config"
 	"picshare/util"
 	"net/smtp"
 	"strings"
+	"time"
 )
 
 type EmailService struct {
@@ -71,6 +73,77 @@ func (s *EmailService) SendPasswordResetEmail(email, token string) error {
 	return s.send(email, subject, body)
 }
 
+// SendForcedPasswordResetEmail tells a user that an admin has reset their
+// password and sends the link to choose a new one
+func (s *EmailService) SendForcedPasswordResetEmail(email, userName, token string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping forced password reset email for: %s", email)
+		return nil
+	}
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.frontendURL, token)
+	subject := "PicShare - 请重新设置密码"
+	message := fmt.Sprintf("出于账号安全考虑，管理员已重置您的密码，所有设备均已退出登录。请点击下方按钮设置新密码，链接将于 %s 失效。",
+		util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 密码重置", userName, message, "设置新密码", resetURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendMagicLinkEmail sends a one-time sign-in link
+func (s *EmailService) SendMagicLinkEmail(email, userName, token string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping magic link email for: %s", email)
+		return nil
+	}
+
+	loginURL := fmt.Sprintf("%s/magic-link?token=%s", s.frontendURL, token)
+	subject := "PicShare - 登录链接"
+	message := fmt.Sprintf("点击下方按钮即可登录 PicShare，无需输入密码。该链接只能使用一次，将于 %s 失效。如果这不是您本人的操作，请忽略此邮件。",
+		util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 登录", userName, message, "立即登录", loginURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendEmailChangeConfirmEmail asks a user to confirm a new login email from
+// the new mailbox
+func (s *EmailService) SendEmailChangeConfirmEmail(email, userName, token string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping email change confirmation for: %s", email)
+		return nil
+	}
+
+	confirmURL := fmt.Sprintf("%s/confirm-email-change?token=%s", s.frontendURL, token)
+	subject := "PicShare - 确认新邮箱"
+	message := fmt.Sprintf("您申请将 PicShare 账号的登录邮箱更改为此邮箱。请点击下方按钮确认，确认后需使用此邮箱登录。该链接将于 %s 失效。如果这不是您本人的操作，请忽略此邮件。",
+		util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 更改邮箱", userName, message, "确认更改", confirmURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendEmailChangeNoticeEmail tells a user at their current address that a
+// change of login email was requested
+func (s *EmailService) SendEmailChangeNoticeEmail(email, userName, newEmail string) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping email change notice for: %s", email)
+		return nil
+	}
+
+	profileURL := fmt.Sprintf("%s/profile", s.frontendURL)
+	subject := "PicShare - 登录邮箱更改提醒"
+	message := fmt.Sprintf("您的账号申请将登录邮箱更改为 %s，新邮箱确认后此邮箱将不能再用于登录。如果这不是您本人的操作，请立即修改密码，并在个人设置中取消此次更改。",
+		html.EscapeString(newEmail))
+
+	body := s.buildActionHTML("PicShare 账号安全提醒", userName, message, "前往个人设置", profileURL)
+
+	return s.send(email, subject, body)
+}
+
 // SendFeedbackEmail sends feedback notification to admin
 func (s *EmailService) SendFeedbackEmail(feedbackEmail, userName, content, contactInfo string, imageUrls []string) error {
 	if !s.IsConfigured() {
@@ -91,6 +164,177 @@ func (s *EmailService) SendFeedbackEmail(feedbackEmail, userName, content, conta
 	return s.send(toEmail, subject, body)
 }
 
+// SendFeedbackReplyEmail sends an admin's answer to the author of feedback,
+// quoting the original message
+func (s *EmailService) SendFeedbackReplyEmail(email, userName, originalContent, reply string) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping feedback reply for: %s", email)
+		return nil
+	}
+
+	if userName == "" {
+		userName = "用户"
+	}
+	feedbackURL := fmt.Sprintf("%s/feedback", s.frontendURL)
+	subject := "PicShare - 关于您的意见反馈"
+	message := strings.ReplaceAll(html.EscapeString(reply), "\n", "<br>") +
+		`<br><br><span style="color: #666;">您的反馈：` + html.EscapeString(originalContent) + `</span>`
+
+	body := s.buildActionHTML("PicShare 反馈回复", userName, message, "继续反馈", feedbackURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendExpiryReminderEmail reminds an album owner that the album is about to
+// expire (or, when deleting is true, about to be deleted) with an extend
+// link, unless extendToken is empty because the album cannot be extended
+func (s *EmailService) SendExpiryReminderEmail(email, userName, albumTitle string, deadline time.Time, deleting bool, extendToken string, extendHours int) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping expiry reminder for: %s (album: %s)", email, albumTitle)
+		return nil
+	}
+
+	var extendURL string
+	if extendToken != "" {
+		extendURL = fmt.Sprintf("%s/extend-album?token=%s", s.frontendURL, extendToken)
+	}
+	subject := "PicShare - 影集即将过期"
+	if deleting {
+		subject = "PicShare - 影集即将被删除"
+	}
+
+	body := s.buildExpiryReminderHTML(userName, albumTitle, deadline, deleting, extendURL, extendHours)
+
+	return s.send(email, subject, body)
+}
+
+// SendAlbumTransferEmail asks a user to accept an album handed over by another user
+func (s *EmailService) SendAlbumTransferEmail(email, userName, fromName, albumTitle, token string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping album transfer email for: %s (album: %s)", email, albumTitle)
+		return nil
+	}
+
+	acceptURL := fmt.Sprintf("%s/album-transfer?token=%s", s.frontendURL, token)
+	subject := "PicShare - 影集转移请求"
+	message := fmt.Sprintf("%s 希望将影集「%s」转移给您。接收后，影集及其中的所有照片将归您所有。此请求将于 %s 失效。",
+		html.EscapeString(fromName), html.EscapeString(albumTitle), util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 影集转移", userName, message, "接收影集", acceptURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendWorkspaceInviteEmail invites an email address to join a workspace
+func (s *EmailService) SendWorkspaceInviteEmail(email, inviterName, workspaceName, roleName, token string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping workspace invite for: %s (workspace: %s)", email, workspaceName)
+		return nil
+	}
+
+	acceptURL := fmt.Sprintf("%s/workspace-invite?token=%s", s.frontendURL, token)
+	subject := "PicShare - 工作区邀请"
+	message := fmt.Sprintf("%s 邀请您以「%s」身份加入工作区「%s」，共同管理其中的影集。此邀请将于 %s 失效。",
+		html.EscapeString(inviterName), html.EscapeString(roleName), html.EscapeString(workspaceName), util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 工作区邀请", email, message, "加入工作区", acceptURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendAlbumCollaboratorInviteEmail invites a user to collaborate on an album
+func (s *EmailService) SendAlbumCollaboratorInviteEmail(email, userName, inviterName, albumTitle string, permissions []string, token string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping collaborator invite for: %s (album: %s)", email, albumTitle)
+		return nil
+	}
+
+	acceptURL := fmt.Sprintf("%s/album-invite?token=%s", s.frontendURL, token)
+	subject := "PicShare - 影集协作邀请"
+	message := fmt.Sprintf("%s 邀请您协作影集「%s」。", html.EscapeString(inviterName), html.EscapeString(albumTitle))
+	if len(permissions) > 0 {
+		message += "您将可以：" + html.EscapeString(strings.Join(permissions, "、")) + "。"
+	}
+	message += fmt.Sprintf("此邀请将于 %s 失效。", util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 影集协作", userName, message, "接受邀请", acceptURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendAccountLockedEmail tells a user that sign-in to their account was
+// temporarily locked after repeated failed attempts
+func (s *EmailService) SendAccountLockedEmail(email, userName, ip string, lockedUntil time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping account locked notice for: %s", email)
+		return nil
+	}
+
+	resetURL := fmt.Sprintf("%s/forgot-password", s.frontendURL)
+	subject := "PicShare - 账号已临时锁定"
+	message := fmt.Sprintf("您的账号因多次登录失败（最近一次来自 IP %s）已被临时锁定，将于 %s 自动解锁。如果这不是您本人的操作，说明有人正在尝试登录您的账号，建议立即重置密码。",
+		html.EscapeString(ip), util.FormatDate(lockedUntil))
+
+	body := s.buildActionHTML("PicShare 账号安全提醒", userName, message, "重置密码", resetURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendDataExportReadyEmail sends the signed download link of a finished data export
+func (s *EmailService) SendDataExportReadyEmail(email, userName, downloadURL string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping data export link for: %s", email)
+		return nil
+	}
+
+	subject := "PicShare - 您的数据导出已完成"
+	message := fmt.Sprintf("您申请的数据导出已经打包完成，包含账号资料、全部相册与照片信息、访问记录以及照片原图。下载链接将于 %s 失效，请及时下载。如果这不是您本人的操作，请立即修改密码。",
+		util.FormatDate(expiresAt))
+
+	body := s.buildActionHTML("PicShare 数据导出", userName, message, "下载数据", downloadURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendAccountDeletionScheduledEmail confirms a deletion request and tells
+// the user how long they have to cancel it
+func (s *EmailService) SendAccountDeletionScheduledEmail(email, userName string, scheduledAt time.Time) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping account deletion notice for: %s", email)
+		return nil
+	}
+
+	profileURL := fmt.Sprintf("%s/profile", s.frontendURL)
+	subject := "PicShare - 账号将被注销"
+	message := fmt.Sprintf("我们已收到您注销账号的申请。您的账号以及全部相册、照片将于 %s 被永久删除，且无法恢复。在此之前，您可以登录并在个人中心撤销注销。如果这不是您本人的操作，请立即登录撤销并修改密码。",
+		util.FormatDate(scheduledAt))
+
+	body := s.buildActionHTML("PicShare 账号注销", userName, message, "撤销注销", profileURL)
+
+	return s.send(email, subject, body)
+}
+
+// SendModerationNoticeEmail tells an album owner that an admin has acted on
+// their album. notice describes the action, e.g. "已被下架".
+func (s *EmailService) SendModerationNoticeEmail(email, userName string, albumID int, albumTitle, notice, reason string) error {
+	if !s.IsConfigured() {
+		util.Log("SMTP not configured, skipping moderation notice for: %s", email)
+		return nil
+	}
+
+	albumURL := fmt.Sprintf("%s/albums/%d", s.frontendURL, albumID)
+	subject := fmt.Sprintf("PicShare - 您的影集「%s」%s", albumTitle, notice)
+	message := fmt.Sprintf("经管理员审核，您的影集「%s」%s。", html.EscapeString(albumTitle), html.EscapeString(notice))
+	if reason != "" {
+		message += "原因：" + html.EscapeString(reason) + "。"
+	}
+	message += "如有疑问，请回复此邮件或通过意见反馈联系我们。"
+
+	body := s.buildActionHTML("PicShare 内容审核通知", userName, message, "查看影集", albumURL)
+
+	return s.send(email, subject, body)
+}
+
 // send sends an email using SMTP
 func (s *EmailService) send(to, subject, htmlBody string) error {
 	// Parse port
@@ -235,7 +479,7 @@ func (s *EmailService) buildFeedbackHTML(userName, content, contactInfo string,
 	<style>
 		body { font-family: Arial, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
 		.container { max-width: 600px; margin: 0 auto; background: white; border-radius: 8px; overflow: hidden; }
-		.header { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); padding: 30px; text-align: center; }
+		.header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); padding: 30px; text-align: center; }
 		.header h1 { color: white; margin: 0; font-size: 24px; }
 		.content { padding: 30px; }
 		.footer { background: #f9f9f9; padding: 20px; text-align: center; color: #666; font-size: 12px; }
@@ -260,3 +504,98 @@ func (s *EmailService) buildFeedbackHTML(userName, content, contactInfo string,
 </body>
 </html>`, userInfo, contactHTML, strings.ReplaceAll(content, "\n", "<br>"), imagesHTML)
 }
+
+// buildExpiryReminderHTML builds the HTML for expiry reminder email
+func (s *EmailService) buildExpiryReminderHTML(userName, albumTitle string, deadline time.Time, deleting bool, extendURL string, extendHours int) string {
+	notice := fmt.Sprintf("您的影集「%s」将于 %s 过期，过期后访客将无法查看。", html.EscapeString(albumTitle), util.FormatDate(deadline))
+	if deleting {
+		notice = fmt.Sprintf("您的影集「%s」已过期，将于 %s 被永久删除，所有照片将无法恢复。", html.EscapeString(albumTitle), util.FormatDate(deadline))
+	}
+
+	extendText := fmt.Sprintf(" %d 小时", extendHours)
+	if extendHours%24 == 0 {
+		extendText = fmt.Sprintf(" %d 天", extendHours/24)
+	}
+
+	action := `<p>影集有效期已达上限，无法继续延长，如需保留请及时下载照片。</p>`
+	if extendURL != "" {
+		action = `<p>如需继续保留，请点击下方按钮将有效期延长` + extendText + `：</p>
+			<center><a href="` + extendURL + `" class="button">延长有效期</a></center>
+			<p style="color: #666; font-size: 12px; margin-top: 30px;">
+				如果按钮无法点击，请复制以下链接到浏览器打开：<br>
+				` + extendURL + `
+			</p>`
+	}
+
+	return `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<style>
+		body { font-family: Arial, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
+		.container { max-width: 600px; margin: 0 auto; background: white; border-radius: 8px; overflow: hidden; }
+		.header { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); padding: 30px; text-align: center; }
+		.header h1 { color: white; margin: 0; font-size: 24px; }
+		.content { padding: 30px; }
+		.button { display: inline-block; background: #667eea; color: white; padding: 12px 30px;
+			text-decoration: none; border-radius: 5px; margin-top: 20px; font-weight: bold; }
+		.footer { background: #f9f9f9; padding: 20px; text-align: center; color: #666; font-size: 12px; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<div class="header">
+			<h1>PicShare 影集过期提醒</h1>
+		</div>
+		<div class="content">
+			<p>` + html.EscapeString(userName) + ` 您好！</p>
+			<p>` + notice + `</p>
+			` + action + `
+		</div>
+		<div class="footer">
+			© 2024 PicShare. All rights reserved.
+		</div>
+	</div>
+</body>
+</html>`
+}
+
+// buildActionHTML builds the HTML for a notification email with a single
+// call-to-action button. message must already be HTML-escaped.
+func (s *EmailService) buildActionHTML(heading, userName, message, actionText, actionURL string) string {
+	return `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<style>
+		body { font-family: Arial, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
+		.container { max-width: 600px; margin: 0 auto; background: white; border-radius: 8px; overflow: hidden; }
+		.header { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); padding: 30px; text-align: center; }
+		.header h1 { color: white; margin: 0; font-size: 24px; }
+		.content { padding: 30px; }
+		.button { display: inline-block; background: #667eea; color: white; padding: 12px 30px;
+			text-decoration: none; border-radius: 5px; margin-top: 20px; font-weight: bold; }
+		.footer { background: #f9f9f9; padding: 20px; text-align: center; color: #666; font-size: 12px; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<div class="header">
+			<h1>` + html.EscapeString(heading) + `</h1>
+		</div>
+		<div class="content">
+			<p>` + html.EscapeString(userName) + ` 您好！</p>
+			<p>` + message + `</p>
+			<center><a href="` + actionURL + `" class="button">` + html.EscapeString(actionText) + `</a></center>
+			<p style="color: #666; font-size: 12px; margin-top: 30px;">
+				如果按钮无法点击，请复制以下链接到浏览器打开：<br>
+				` + actionURL + `
+			</p>
+		</div>
+		<div class="footer">
+			© 2024 PicShare. All rights reserved.
+		</div>
+	</div>
+</body>
+</html>`
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"time"
+)
+
+// Expiry history actions
+const (
+	ExpiryActionCreate     = "create"
+	ExpiryActionSet        = "set"
+	ExpiryActionExtend     = "extend"
+	ExpiryActionNever      = "never"
+	ExpiryActionExtendLink = "extend_link"
+)
+
+// ExpiryError is a user-facing validation error for an expiry change
+type ExpiryError struct {
+	Message string
+}
+
+func (e *ExpiryError) Error() string {
+	return e.Message
+}
+
+// AlbumExpiryRequest describes a requested album expiry. At most one field may be set.
+type AlbumExpiryRequest struct {
+	ExpiresInHours *int       // from now
+	ExtendByHours  *int       // from the current expiry (or now, if already expired)
+	ExpiresAt      *time.Time // absolute
+	NeverExpires   bool       // privileged roles only
+}
+
+// IsEmpty returns true if no expiry change was requested
+func (r AlbumExpiryRequest) IsEmpty() bool {
+	return r.ExpiresInHours == nil && r.ExtendByHours == nil && r.ExpiresAt == nil && !r.NeverExpires
+}
+
+// ResolveAlbumExpiry validates a requested expiry against the configured
+// lifetime bounds and returns the new expiry and its history action.
+// current is the album's expiry (zero for new albums). Both bounds are
+// relative to now, so an old album can always be extended or revived.
+func ResolveAlbumExpiry(current time.Time, req AlbumExpiryRequest, privileged bool) (time.Time, string, error) {
+	set := 0
+	for _, ok := range []bool{req.ExpiresInHours != nil, req.ExtendByHours != nil, req.ExpiresAt != nil, req.NeverExpires} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return time.Time{}, "", &ExpiryError{"请只指定一种有效期设置方式"}
+	}
+
+	if req.NeverExpires {
+		if !privileged {
+			return time.Time{}, "", &ExpiryError{"无权设置永不过期"}
+		}
+		return util.NeverExpiresAt, ExpiryActionNever, nil
+	}
+
+	now := time.Now()
+	var expiresAt time.Time
+	action := ExpiryActionSet
+
+	switch {
+	case req.ExpiresInHours != nil:
+		if *req.ExpiresInHours <= 0 {
+			return time.Time{}, "", &ExpiryError{"有效期必须大于0"}
+		}
+		expiresAt = now.Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+	case req.ExtendByHours != nil:
+		if *req.ExtendByHours <= 0 {
+			return time.Time{}, "", &ExpiryError{"延长时间必须大于0"}
+		}
+		if util.IsNeverExpires(current) {
+			return time.Time{}, "", &ExpiryError{"影集已设置为永不过期"}
+		}
+		base := now
+		if current.After(base) {
+			base = current
+		}
+		expiresAt = base.Add(time.Duration(*req.ExtendByHours) * time.Hour)
+		action = ExpiryActionExtend
+	default:
+		expiresAt = *req.ExpiresAt
+	}
+
+	cfg := config.Get()
+	if expiresAt.Before(now.Add(cfg.Upload.MinAlbumLifetime)) {
+		return time.Time{}, "", &ExpiryError{fmt.Sprintf("过期时间至少需要在 %s 之后", formatLifetime(cfg.Upload.MinAlbumLifetime))}
+	}
+	if !privileged && expiresAt.After(now.Add(cfg.Upload.MaxAlbumLifetime)) {
+		return time.Time{}, "", &ExpiryError{fmt.Sprintf("影集有效期最长为 %s", formatLifetime(cfg.Upload.MaxAlbumLifetime))}
+	}
+
+	return expiresAt, action, nil
+}
+
+// ChangeAlbumExpiry applies a resolved expiry to an album, reviving it if it
+// has expired or been archived, and records the change in the expiry history
+func ChangeAlbumExpiry(ctx context.Context, album *model.Album, expiresAt time.Time, action string, actorID *int) error {
+	// Reviving counts against the owner's active album quota
+	if album.IsExpired || album.ExpiresAt.Before(time.Now()) {
+		count, err := repository.CountActiveAlbumsByUser(ctx, album.UserID)
+		if err != nil {
+			return err
+		}
+		if maxAlbums := GetUserLimits(ctx, album.UserID).MaxAlbums; count >= maxAlbums {
+			return &ExpiryError{fmt.Sprintf("每个用户最多只能有 %d 个未过期影集", maxAlbums)}
+		}
+	}
+
+	if album.IsArchived() {
+		if err := RestoreArchivedAlbum(ctx, album); err != nil {
+			return fmt.Errorf("failed to restore archived album: %w", err)
+		}
+	}
+
+	return repository.SetAlbumExpiry(ctx, album.ID, expiresAt, &model.AlbumExpiryChange{
+		ActorID: actorID,
+		Action:  action,
+	})
+}
+
+// CanExtendByLink reports whether the one-click extend link would succeed
+// for an album with the given expiry
+func CanExtendByLink(current time.Time) bool {
+	extendBy := config.Get().Reminder.ExtendHours
+	_, _, err := ResolveAlbumExpiry(current, AlbumExpiryRequest{ExtendByHours: &extendBy}, false)
+	return err == nil
+}
+
+// formatLifetime renders a lifetime bound in days or hours
+func formatLifetime(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours >= 24 && hours%24 == 0 {
+		return fmt.Sprintf("%d 天", hours/24)
+	}
+	if hours >= 1 {
+		return fmt.Sprintf("%d 小时", hours)
+	}
+	return fmt.Sprintf("%d 分钟", int(d.Minutes()))
+}
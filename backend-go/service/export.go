@@ -0,0 +1,374 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// exportSlots limits how many data exports are built at once, since each
+// one streams every original of its user through the server
+var exportSlots = make(chan struct{}, 2)
+
+// exportStaleAfter is how long an export may stay unfinished before the
+// cleanup job gives up on it
+const exportStaleAfter = 6 * time.Hour
+
+// exportProfile is profile.json in an export
+type exportProfile struct {
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"emailVerified"`
+	AvatarURL     *string   `json:"avatarUrl,omitempty"`
+	ExpiryPolicy  string    `json:"expiryPolicy"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	ExportedAt    time.Time `json:"exportedAt"`
+}
+
+// exportAlbum is an album in albums.json, with its photos
+type exportAlbum struct {
+	model.Album
+	Photos []exportPhoto `json:"photos"`
+}
+
+// exportPhoto is a photo in albums.json. File is the original's path in
+// the ZIP, empty if it could not be read (e.g. in cold archive storage).
+type exportPhoto struct {
+	ID            int       `json:"id"`
+	OriginalName  string    `json:"originalName"`
+	FileSize      int64     `json:"fileSize"`
+	Width         int       `json:"width"`
+	Height        int       `json:"height"`
+	MimeType      string    `json:"mimeType"`
+	DownloadCount int       `json:"downloadCount"`
+	SortOrder     int       `json:"sortOrder"`
+	UploadedBy    *int      `json:"uploadedBy,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	File          string    `json:"file,omitempty"`
+}
+
+// RequestDataExport returns the user's export if one is still being built
+// or can still be downloaded; otherwise it queues a new export and builds
+// it in the background. The download link is emailed when it is ready.
+func RequestDataExport(ctx context.Context, user *model.User, ip, userAgent string) (*model.DataExport, error) {
+	latest, err := repository.FindLatestDataExport(ctx, user.ID)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		switch latest.Status {
+		case model.DataExportPending, model.DataExportProcessing:
+			return latest, nil
+		case model.DataExportReady:
+			if latest.ExpiresAt != nil && latest.ExpiresAt.After(time.Now()) {
+				if err := setExportDownloadURL(ctx, latest); err != nil {
+					return nil, err
+				}
+				return latest, nil
+			}
+		}
+	}
+
+	export := &model.DataExport{
+		UserID: user.ID,
+		Status: model.DataExportPending,
+	}
+	if err := repository.CreateDataExport(ctx, export); err != nil {
+		return nil, err
+	}
+	logSecurityEvent(ctx, model.SecurityEventDataExport, &user.ID, user.Email, ip, userAgent, "")
+
+	go runDataExport(export.ID, user.ID)
+
+	return export, nil
+}
+
+// setExportDownloadURL signs a download URL valid until the export expires
+func setExportDownloadURL(ctx context.Context, export *model.DataExport) error {
+	ossService := GetOSSService()
+	if ossService == nil || export.OSSKey == nil || export.ExpiresAt == nil {
+		return fmt.Errorf("export %d cannot be downloaded", export.ID)
+	}
+
+	url, err := ossService.GeneratePresignedURL(ctx, *export.OSSKey, time.Until(*export.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	export.DownloadURL = url
+	return nil
+}
+
+// runDataExport builds one export and emails its download link
+func runDataExport(exportID, userID int) {
+	exportSlots <- struct{}{}
+	defer func() { <-exportSlots }()
+
+	ctx := context.Background()
+	if err := repository.StartDataExport(ctx, exportID); err != nil {
+		util.Log("Failed to start data export %d: %v", exportID, err)
+		return
+	}
+
+	user, err := repository.FindUserByID(ctx, userID)
+	if err != nil {
+		failDataExport(ctx, exportID, fmt.Errorf("failed to load user: %w", err))
+		return
+	}
+
+	ossKey, fileSize, err := buildDataExport(ctx, exportID, user)
+	if err != nil {
+		failDataExport(ctx, exportID, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(config.Get().Account.ExportRetention)
+	if err := repository.CompleteDataExport(ctx, exportID, ossKey, fileSize, expiresAt); err != nil {
+		failDataExport(ctx, exportID, fmt.Errorf("failed to save export: %w", err))
+		return
+	}
+
+	export := &model.DataExport{ID: exportID, OSSKey: &ossKey, ExpiresAt: &expiresAt}
+	if err := setExportDownloadURL(ctx, export); err != nil {
+		util.Log("Failed to sign data export %d: %v", exportID, err)
+		return
+	}
+	if err := GetEmailService().SendDataExportReadyEmail(user.Email, user.Name, export.DownloadURL, expiresAt); err != nil {
+		util.Log("Failed to send data export email to %s: %v", user.Email, err)
+	}
+
+	util.Log("Data export %d for user %d ready (%s)", exportID, userID, util.FormatFileSize(fileSize))
+}
+
+func failDataExport(ctx context.Context, exportID int, cause error) {
+	util.Log("Data export %d failed: %v", exportID, cause)
+	if err := repository.FailDataExport(ctx, exportID, cause.Error()); err != nil {
+		util.Log("Failed to mark data export %d failed: %v", exportID, err)
+	}
+}
+
+// buildDataExport writes the ZIP to a temporary file, uploads it as a
+// private object and returns its key and size
+func buildDataExport(ctx context.Context, exportID int, user *model.User) (string, int64, error) {
+	ossService := GetOSSService()
+	if ossService == nil {
+		return "", 0, fmt.Errorf("OSS service not available")
+	}
+
+	tmp, err := os.CreateTemp("", "picshare-export-*.zip")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	if err := writeDataExport(ctx, zw, user); err != nil {
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to finish zip: %w", err)
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+
+	// The key is unguessable as well as private, in case the bucket is public-read
+	ossKey := fmt.Sprintf("exports/%d/%d_%s.zip", user.ID, exportID, util.GenerateRandomToken()[:16])
+	if err := ossService.UploadPrivateFile(ctx, ossKey, tmp.Name(), "application/zip"); err != nil {
+		return "", 0, err
+	}
+
+	return ossKey, info.Size(), nil
+}
+
+// writeDataExport adds the profile, albums with photo metadata, access
+// logs, sign-in history, avatar and photo originals to the ZIP
+func writeDataExport(ctx context.Context, zw *zip.Writer, user *model.User) error {
+	profile := exportProfile{
+		ID:            user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		AvatarURL:     user.AvatarURL,
+		ExpiryPolicy:  user.ExpiryPolicy,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		ExportedAt:    time.Now(),
+	}
+	if err := writeExportJSON(zw, "profile.json", profile); err != nil {
+		return err
+	}
+
+	albums, err := repository.GetAllAlbumsByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load albums: %w", err)
+	}
+
+	exportAlbums := make([]exportAlbum, 0, len(albums))
+	for _, album := range albums {
+		photos, err := repository.GetPhotosByAlbum(ctx, album.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load photos of album %d: %w", album.ID, err)
+		}
+
+		entry := exportAlbum{Album: album, Photos: make([]exportPhoto, 0, len(photos))}
+		for _, p := range photos {
+			name := fmt.Sprintf("photos/%d/%d_%s", album.ID, p.ID, exportFileName(p.OriginalName))
+			if err := copyExportObject(ctx, zw, p.OSSKey, name); err != nil {
+				util.Log("Data export: skipping original of photo %d: %v", p.ID, err)
+				name = ""
+			}
+			entry.Photos = append(entry.Photos, exportPhoto{
+				ID:            p.ID,
+				OriginalName:  p.OriginalName,
+				FileSize:      p.FileSize,
+				Width:         p.Width,
+				Height:        p.Height,
+				MimeType:      p.MimeType,
+				DownloadCount: p.DownloadCount,
+				SortOrder:     p.SortOrder,
+				UploadedBy:    p.UploadedBy,
+				CreatedAt:     p.CreatedAt,
+				File:          name,
+			})
+		}
+		exportAlbums = append(exportAlbums, entry)
+	}
+	if err := writeExportJSON(zw, "albums.json", exportAlbums); err != nil {
+		return err
+	}
+
+	logs, err := repository.GetAccessLogsByOwner(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load access logs: %w", err)
+	}
+	if logs == nil {
+		logs = []model.AlbumAccessLog{}
+	}
+	if err := writeExportJSON(zw, "access_logs.json", logs); err != nil {
+		return err
+	}
+
+	events, err := repository.GetSecurityEventsByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load security log: %w", err)
+	}
+	if events == nil {
+		events = []model.SecurityEvent{}
+	}
+	if err := writeExportJSON(zw, "security_log.json", events); err != nil {
+		return err
+	}
+
+	if user.AvatarURL != nil {
+		if key, ok := GetOSSService().KeyFromURL(*user.AvatarURL); ok {
+			if err := copyExportObject(ctx, zw, key, "avatar.jpg"); err != nil {
+				util.Log("Data export: skipping avatar of user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeExportJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// copyExportObject streams an OSS object into the ZIP uncompressed, as
+// photos are already compressed. The object is opened before its entry is
+// added, so an unreadable object leaves no empty file behind.
+func copyExportObject(ctx context.Context, zw *zip.Writer, ossKey, name string) error {
+	body, err := GetOSSService().GetObject(ctx, ossKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// exportFileName makes an uploaded file name safe to use inside the ZIP
+func exportFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "photo"
+	}
+	return name
+}
+
+// runDataExportCleanup deletes expired export files and gives up on exports
+// interrupted by a restart
+func runDataExportCleanup(ctx context.Context) {
+	count, err := repository.FailStaleDataExports(ctx, time.Now().Add(-exportStaleAfter))
+	if err != nil {
+		fmt.Printf("[Cron] Failed to fail stale data exports: %v\n", err)
+	} else if count > 0 {
+		fmt.Printf("[Cron] Marked %d interrupted data exports as failed\n", count)
+	}
+
+	exports, err := repository.GetExpiredDataExports(ctx)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to get expired data exports: %v\n", err)
+		return
+	}
+
+	deleted := 0
+	for _, export := range exports {
+		if export.OSSKey != nil {
+			ossService := GetOSSService()
+			if ossService == nil {
+				continue
+			}
+			if err := ossService.DeleteSingle(ctx, *export.OSSKey); err != nil {
+				fmt.Printf("[Cron] Failed to delete data export %d from OSS: %v\n", export.ID, err)
+				continue
+			}
+		}
+		if err := repository.DeleteDataExport(ctx, export.ID); err != nil {
+			fmt.Printf("[Cron] Failed to delete data export %d: %v\n", export.ID, err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		fmt.Printf("[Cron] Deleted %d expired data exports\n", deleted)
+	}
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+
+	"github.com/google/uuid"
+)
+
+// maxFeedbackImages limits the images stored with one feedback message
+const maxFeedbackImages = 5
+
+// SubmitFeedback stores feedback with its images and forwards it by email.
+// The feedback is saved first, so a mail outage no longer loses it.
+func SubmitFeedback(ctx context.Context, feedback *model.Feedback, files []*multipart.FileHeader) error {
+	if err := repository.CreateFeedback(ctx, feedback); err != nil {
+		return err
+	}
+
+	if len(files) > maxFeedbackImages {
+		files = files[:maxFeedbackImages]
+	}
+	var imageURLs []string
+	for _, fileHeader := range files {
+		image, err := storeFeedbackImage(ctx, feedback.ID, fileHeader)
+		if err != nil {
+			util.Log("Failed to store image of feedback %d: %v", feedback.ID, err)
+			continue
+		}
+		imageURLs = append(imageURLs, image.URL)
+	}
+
+	userName := ""
+	if feedback.UserName != nil {
+		userName = *feedback.UserName
+	}
+	contact := ""
+	if feedback.Contact != nil {
+		contact = *feedback.Contact
+	}
+	if err := GetEmailService().SendFeedbackEmail("", userName, feedback.Content, contact, imageURLs); err != nil {
+		util.Log("Failed to send feedback %d by email: %v", feedback.ID, err)
+	}
+	return nil
+}
+
+// storeFeedbackImage uploads one image and links it to its feedback. An
+// upload that cannot be linked is deleted again rather than orphaned.
+func storeFeedbackImage(ctx context.Context, feedbackID int, fileHeader *multipart.FileHeader) (*model.FeedbackImage, error) {
+	ossService := GetOSSService()
+	if ossService == nil {
+		return nil, fmt.Errorf("OSS service not available")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	imageURL, ossKey, err := ossService.UploadFeedbackImage(ctx, uuid.New().String(), file, fileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &model.FeedbackImage{FeedbackID: feedbackID, OSSKey: ossKey, URL: imageURL}
+	if err := repository.CreateFeedbackImage(ctx, image); err != nil {
+		_ = ossService.DeleteSingle(ctx, ossKey)
+		return nil, err
+	}
+	return image, nil
+}
+
+// findFeedback loads the feedback an admin is acting on
+func findFeedback(ctx context.Context, feedbackID int) (*model.Feedback, error) {
+	feedback, err := repository.FindFeedback(ctx, feedbackID)
+	if err != nil {
+		return nil, &AdminError{"反馈不存在"}
+	}
+	return feedback, nil
+}
+
+// UpdateFeedback changes the status and internal note of feedback
+func UpdateFeedback(ctx context.Context, actor AdminActor, feedbackID int, status string, adminNote *string) error {
+	if !model.IsValidFeedbackStatus(status) {
+		return &AdminError{"无效的处理状态"}
+	}
+	feedback, err := findFeedback(ctx, feedbackID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.UpdateFeedback(ctx, feedback.ID, status, adminNote); err != nil {
+		return err
+	}
+
+	if feedback.Status != status {
+		LogAdminAction(ctx, actor, model.AdminActionUpdateFeedback, model.AuditTargetFeedback, feedback.ID, map[string]any{
+			"from": feedback.Status,
+			"to":   status,
+		})
+	}
+	return nil
+}
+
+// feedbackReplyAddress returns where to send a reply: the author's account
+// email, or the contact they left if it is an email address
+func feedbackReplyAddress(feedback *model.Feedback) string {
+	if feedback.UserEmail != nil {
+		return *feedback.UserEmail
+	}
+	if feedback.Contact != nil && !util.NewValidator().Email("contact", *feedback.Contact).HasErrors() {
+		return *feedback.Contact
+	}
+	return ""
+}
+
+// ReplyToFeedback emails an answer to the author of feedback and records
+// it. New feedback moves to triaged once it has been answered.
+func ReplyToFeedback(ctx context.Context, actor AdminActor, feedbackID int, message string) (*model.FeedbackReply, error) {
+	if message == "" {
+		return nil, &AdminError{"请填写回复内容"}
+	}
+	feedback, err := findFeedback(ctx, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := feedbackReplyAddress(feedback)
+	if to == "" {
+		return nil, &AdminError{"该反馈没有留下可回复的邮箱"}
+	}
+	emailService := GetEmailService()
+	if !emailService.IsConfigured() {
+		return nil, &AdminError{"邮件服务未配置，无法发送回复"}
+	}
+
+	userName := ""
+	if feedback.UserName != nil {
+		userName = *feedback.UserName
+	}
+	if err := emailService.SendFeedbackReplyEmail(to, userName, feedback.Content, message); err != nil {
+		util.Log("Failed to send reply to feedback %d: %v", feedback.ID, err)
+		return nil, &AdminError{"回复邮件发送失败，请稍后重试"}
+	}
+
+	reply := &model.FeedbackReply{
+		FeedbackID: feedback.ID,
+		AdminID:    &actor.ID,
+		Message:    message,
+		SentTo:     to,
+	}
+	if err := repository.CreateFeedbackReply(ctx, reply); err != nil {
+		return nil, err
+	}
+	if feedback.Status == model.FeedbackStatusNew {
+		if err := repository.UpdateFeedback(ctx, feedback.ID, model.FeedbackStatusTriaged, feedback.AdminNote); err != nil {
+			util.Log("Failed to triage feedback %d: %v", feedback.ID, err)
+		}
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionReplyFeedback, model.AuditTargetFeedback, feedback.ID, map[string]any{
+		"sentTo": to,
+	})
+	return reply, nil
+}
+
+// DeleteFeedback deletes feedback and its images
+func DeleteFeedback(ctx context.Context, actor AdminActor, feedbackID int) error {
+	feedback, err := findFeedback(ctx, feedbackID)
+	if err != nil {
+		return err
+	}
+
+	images, err := repository.GetFeedbackImages(ctx, feedback.ID)
+	if err != nil {
+		return err
+	}
+	if len(images) > 0 {
+		ossService := GetOSSService()
+		if ossService == nil {
+			return fmt.Errorf("OSS service not available")
+		}
+		keys := make([]string, len(images))
+		for i, img := range images {
+			keys[i] = img.OSSKey
+		}
+		if err := ossService.DeletePhotos(ctx, keys); err != nil {
+			return err
+		}
+	}
+
+	if err := repository.DeleteFeedback(ctx, feedback.ID); err != nil {
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionDeleteFeedback, model.AuditTargetFeedback, feedback.ID, map[string]any{
+		"status": feedback.Status,
+		"images": len(images),
+	})
+	return nil
+}
+
+// runFeedbackImageCleanup deletes the images of feedback resolved longer
+// ago than the retention period; the feedback text is kept
+func runFeedbackImageCleanup(ctx context.Context) {
+	before := time.Now().Add(-config.Get().Moderation.FeedbackImageRetention)
+	images, err := repository.GetResolvedFeedbackImages(ctx, before)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to get images of resolved feedback: %v\n", err)
+		return
+	}
+	if len(images) == 0 {
+		return
+	}
+
+	ossService := GetOSSService()
+	if ossService == nil {
+		return
+	}
+	keys := make([]string, len(images))
+	ids := make([]int, len(images))
+	for i, img := range images {
+		keys[i] = img.OSSKey
+		ids[i] = img.ID
+	}
+	if err := ossService.DeletePhotos(ctx, keys); err != nil {
+		fmt.Printf("[Cron] Failed to delete feedback images from OSS: %v\n", err)
+		return
+	}
+	if err := repository.DeleteFeedbackImages(ctx, ids); err != nil {
+		fmt.Printf("[Cron] Failed to delete feedback image links: %v\n", err)
+		return
+	}
+	fmt.Printf("[Cron] Deleted %d images of resolved feedback\n", len(images))
+}
@@ -107,6 +107,28 @@ func (s *ImageService) GenerateThumbnailFromBuffer(data []byte, mimeType string)
 	return buf.Bytes(), width, height, nil
 }
 
+// GenerateAvatars center-crops an image to a square and encodes it as JPEG
+// at each of AvatarSizes, keyed by size
+func (s *ImageService) GenerateAvatars(data []byte) (map[int][]byte, error) {
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, err
+	}
+
+	avatars := make(map[int][]byte, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		avatar := imaging.Fill(img, size, size, imaging.Center, imaging.Lanczos)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, avatar, &jpeg.Options{Quality: avatarQuality}); err != nil {
+			return nil, err
+		}
+		avatars[size] = buf.Bytes()
+	}
+
+	return avatars, nil
+}
+
 // GetImageDimensions returns the dimensions of an image
 func (s *ImageService) GetImageDimensions(file multipart.File) (int, int, error) {
 	_, err := file.Seek(0, 0)
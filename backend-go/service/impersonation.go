@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+)
+
+// ImpersonationSession is the token an admin uses to act as a user
+type ImpersonationSession struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	SessionID   int
+	User        *model.User
+}
+
+// ImpersonateUser opens a short session in which the admin sees the site as
+// the user does. The token carries the admin's ID, cannot be refreshed, and
+// the session is recorded in the audit log.
+func ImpersonateUser(ctx context.Context, actor AdminActor, userID int, reason string) (*ImpersonationSession, error) {
+	user, err := findManagedUser(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == "admin" {
+		return nil, &AdminError{"不能模拟登录其他管理员账号"}
+	}
+	suspendedAt, _, err := repository.FindUserSuspension(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if suspendedAt != nil {
+		return nil, &AdminError{"该用户已被停用，无法模拟登录"}
+	}
+
+	ttl := config.Get().Admin.ImpersonationTTL
+	session := &model.Session{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if actor.IP != "" {
+		session.IPAddress = &actor.IP
+	}
+	if actor.UserAgent != "" {
+		session.UserAgent = &actor.UserAgent
+	}
+	if err := repository.CreateImpersonationSession(ctx, session, actor.ID); err != nil {
+		return nil, err
+	}
+
+	token, err := util.GenerateImpersonationJWT(user.ID, session.ID, user.Email, user.Role, user.Name, actor.ID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionImpersonateUser, model.AuditTargetUser, user.ID, map[string]any{
+		"email":     user.Email,
+		"sessionId": session.ID,
+		"expiresAt": session.ExpiresAt,
+		"reason":    reason,
+	})
+
+	return &ImpersonationSession{
+		AccessToken: token,
+		ExpiresAt:   session.ExpiresAt,
+		SessionID:   session.ID,
+		User:        user,
+	}, nil
+}
+
+// EndImpersonation closes an impersonation session before it expires
+func EndImpersonation(ctx context.Context, actor AdminActor, sessionID, userID int) error {
+	err := repository.RevokeSession(ctx, sessionID, userID, model.SessionRevokedLogout)
+	if err != nil && err != repository.ErrNoRowsUpdated {
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionEndImpersonation, model.AuditTargetUser, userID, map[string]any{
+		"sessionId": sessionID,
+	})
+	return nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Longest wait imposed between attempts before an account is locked
+	maxLoginDelay = 5 * time.Minute
+
+	// How long the security log is kept
+	securityEventRetention = 180 * 24 * time.Hour
+)
+
+// LoginThrottleError is returned when a sign-in attempt is refused because of
+// earlier failures; RetryAfter tells the client when to try again
+type LoginThrottleError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottleError) Error() string {
+	return e.Message
+}
+
+// loginAccountKey normalizes an email for counting failures, so that unknown
+// addresses are throttled exactly like registered ones
+func loginAccountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginDelay returns how long an account must wait after its nth failure;
+// it doubles with every failure past the configured threshold
+func loginDelay(failures int) time.Duration {
+	over := failures - config.Get().LoginGuard.DelayAfter
+	if over < 0 {
+		return 0
+	}
+	if over > 10 {
+		return maxLoginDelay
+	}
+	delay := time.Second << uint(over)
+	if delay > maxLoginDelay {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+// formatRetryAfter renders a wait in whole seconds or minutes, rounded up
+func formatRetryAfter(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d 秒", int(math.Ceil(d.Seconds())))
+	}
+	return fmt.Sprintf("%d 分钟", int(math.Ceil(d.Minutes())))
+}
+
+// LoginAttempt is a sign-in attempt that has been counted against the IP and
+// the account before its credentials are checked
+type LoginAttempt struct {
+	ip      *model.LoginThrottle
+	account *model.LoginThrottle
+}
+
+// ReserveLoginAttempt counts a sign-in attempt against the IP and the account,
+// refusing it while either is locked out or the account still has to wait
+// after its last failure. It must run before the password is checked, so a
+// locked account does not confirm guesses, and it counts the attempt up
+// front so concurrent guesses cannot all pass the check. Attempts with the
+// right credentials are handed back with ReleaseLoginAttempt.
+func ReserveLoginAttempt(ctx context.Context, email, ip string) (*LoginAttempt, error) {
+	cfg := config.Get().LoginGuard
+	windowStart := time.Now().Add(-cfg.FailureWindow)
+
+	ipThrottle, err := repository.ReserveLoginThrottle(ctx, model.ThrottleScopeIP, ip,
+		windowStart, cfg.IPLockAfter, nil, maxLoginDelay)
+	if err == pgx.ErrNoRows {
+		return nil, loginThrottleError(ctx, model.ThrottleScopeIP, ip)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accountKey := loginAccountKey(email)
+	account, err := repository.ReserveLoginThrottle(ctx, model.ThrottleScopeAccount, accountKey,
+		windowStart, cfg.AccountLockAfter, &cfg.DelayAfter, maxLoginDelay)
+	if err != nil {
+		releaseLoginThrottle(ctx, ipThrottle)
+		if err == pgx.ErrNoRows {
+			return nil, loginThrottleError(ctx, model.ThrottleScopeAccount, accountKey)
+		}
+		return nil, err
+	}
+
+	return &LoginAttempt{ip: ipThrottle, account: account}, nil
+}
+
+// loginThrottleError explains why an attempt was refused
+func loginThrottleError(ctx context.Context, scope, key string) error {
+	now := time.Now()
+	throttle, err := repository.FindLoginThrottle(ctx, scope, key)
+	if err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+
+	if throttle != nil && throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+		wait := throttle.LockedUntil.Sub(now)
+		message := "登录失败次数过多，账号已临时锁定，请 " + formatRetryAfter(wait) + "后再试，或通过忘记密码重置"
+		if scope == model.ThrottleScopeIP {
+			message = "当前网络登录失败次数过多，请 " + formatRetryAfter(wait) + "后再试"
+		}
+		return &LoginThrottleError{Message: message, RetryAfter: wait}
+	}
+
+	// Cooling down after a failure, or other attempts are still being checked
+	wait := time.Second
+	if throttle != nil && scope == model.ThrottleScopeAccount {
+		if delay := throttle.LastFailureAt.Add(loginDelay(throttle.Failures)).Sub(now); delay > wait {
+			wait = delay
+		}
+	}
+	return &LoginThrottleError{
+		Message:    "登录尝试过于频繁，请 " + formatRetryAfter(wait) + "后再试",
+		RetryAfter: wait,
+	}
+}
+
+// ReleaseLoginAttempt takes back an attempt whose credentials were correct,
+// so it does not count as a failure
+func ReleaseLoginAttempt(ctx context.Context, attempt *LoginAttempt) {
+	releaseLoginThrottle(ctx, attempt.ip)
+	releaseLoginThrottle(ctx, attempt.account)
+}
+
+func releaseLoginThrottle(ctx context.Context, throttle *model.LoginThrottle) {
+	if err := repository.ReleaseLoginThrottle(ctx, throttle.ID); err != nil {
+		util.Log("Failed to release login attempt for %s: %v", throttle.Key, err)
+	}
+}
+
+// RecordLoginFailure keeps a failed attempt counted and locks the account or
+// the IP once it reaches its limit. The account owner is told by email when
+// their account gets locked.
+func RecordLoginFailure(ctx context.Context, attempt *LoginAttempt, userAgent string) {
+	cfg := config.Get().LoginGuard
+	ip := attempt.ip.Key
+
+	if attempt.account.Failures >= cfg.AccountLockAfter {
+		lockAccount(ctx, attempt.account, ip, userAgent)
+	}
+
+	if attempt.ip.Failures >= cfg.IPLockAfter {
+		lockedUntil := time.Now().Add(cfg.LockDuration)
+		locked, err := repository.LockLoginThrottle(ctx, attempt.ip.ID, lockedUntil)
+		if err != nil {
+			util.Log("Failed to lock IP %s: %v", ip, err)
+			return
+		}
+		if !locked {
+			return
+		}
+		util.Log("Security: IP %s locked out until %s after %d failed logins", ip, util.FormatDate(lockedUntil), attempt.ip.Failures)
+		logSecurityEvent(ctx, model.SecurityEventIPLocked, nil, "", ip, userAgent,
+			fmt.Sprintf("%d failed logins", attempt.ip.Failures))
+	}
+}
+
+// lockAccount locks an account that reached the failure limit
+func lockAccount(ctx context.Context, account *model.LoginThrottle, ip, userAgent string) {
+	lockedUntil := time.Now().Add(config.Get().LoginGuard.LockDuration)
+	locked, err := repository.LockLoginThrottle(ctx, account.ID, lockedUntil)
+	if err != nil {
+		util.Log("Failed to lock account %s: %v", account.Key, err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	util.Log("Security: account %s locked until %s after %d failed logins", account.Key, util.FormatDate(lockedUntil), account.Failures)
+
+	var userID *int
+	user, err := repository.FindUserByEmail(ctx, account.Key)
+	if err == nil {
+		userID = &user.ID
+	}
+	logSecurityEvent(ctx, model.SecurityEventAccountLocked, userID, account.Key, ip, userAgent,
+		fmt.Sprintf("%d failed logins", account.Failures))
+
+	if user != nil {
+		if err := GetEmailService().SendAccountLockedEmail(user.Email, user.Name, ip, lockedUntil); err != nil {
+			util.Log("Failed to send account locked email to %s: %v", user.Email, err)
+		}
+	}
+}
+
+// RecordLoginSuccess clears the account's failure count and writes the
+// sign-in to the security log; method is how the user signed in
+func RecordLoginSuccess(ctx context.Context, user *model.User, ip, userAgent, method string) {
+	if err := repository.ResetLoginThrottle(ctx, model.ThrottleScopeAccount, loginAccountKey(user.Email)); err != nil {
+		util.Log("Failed to reset login failures for %s: %v", user.Email, err)
+	}
+	logSecurityEvent(ctx, model.SecurityEventLoginSuccess, &user.ID, user.Email, ip, userAgent, method)
+}
+
+// logSecurityEvent appends an entry to the security log
+func logSecurityEvent(ctx context.Context, eventType string, userID *int, email, ip, userAgent, details string) {
+	event := &model.SecurityEvent{
+		UserID:    userID,
+		EventType: eventType,
+	}
+	if email != "" {
+		event.Email = &email
+	}
+	if ip != "" {
+		event.IPAddress = &ip
+	}
+	if userAgent != "" {
+		event.UserAgent = &userAgent
+	}
+	if details != "" {
+		event.Details = &details
+	}
+
+	if err := repository.CreateSecurityEvent(ctx, event); err != nil {
+		util.Log("Failed to write security event %s: %v", eventType, err)
+	}
+}
+
+// runLoginGuardCleanup purges old failure counters and security log entries
+func runLoginGuardCleanup(ctx context.Context) {
+	count, err := repository.DeleteStaleLoginThrottles(ctx, time.Now().Add(-config.Get().LoginGuard.FailureWindow))
+	if err != nil {
+		fmt.Printf("[Cron] Failed to delete stale login throttles: %v\n", err)
+	} else if count > 0 {
+		fmt.Printf("[Cron] Deleted %d stale login throttles\n", count)
+	}
+
+	count, err = repository.DeleteSecurityEventsBefore(ctx, time.Now().Add(-securityEventRetention))
+	if err != nil {
+		fmt.Printf("[Cron] Failed to delete old security events: %v\n", err)
+	} else if count > 0 {
+		fmt.Printf("[Cron] Deleted %d old security events\n", count)
+	}
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+)
+
+// findModeratedAlbum loads the album an admin is moderating
+func findModeratedAlbum(ctx context.Context, albumID int) (*model.Album, error) {
+	album, err := repository.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return nil, &AdminError{"影集不存在"}
+	}
+	return album, nil
+}
+
+// findModeratedPhoto loads a photo of the album an admin is moderating
+func findModeratedPhoto(ctx context.Context, albumID, photoID int) (*model.Album, *model.Photo, error) {
+	album, err := findModeratedAlbum(ctx, albumID)
+	if err != nil {
+		return nil, nil, err
+	}
+	photo, err := repository.FindPhotoByIDAndAlbum(ctx, photoID, album.ID)
+	if err != nil {
+		return nil, nil, &AdminError{"照片不存在"}
+	}
+	return album, photo, nil
+}
+
+// notifyAlbumOwner emails the owner of an album about a moderation action
+func notifyAlbumOwner(ctx context.Context, album *model.Album, notice, reason string) {
+	owner, err := repository.FindUserByID(ctx, album.UserID)
+	if err != nil {
+		util.Log("Failed to find owner of album %d for moderation notice: %v", album.ID, err)
+		return
+	}
+	if err := GetEmailService().SendModerationNoticeEmail(owner.Email, owner.Name, album.ID, album.Title, notice, reason); err != nil {
+		util.Log("Failed to send moderation notice to %s: %v", owner.Email, err)
+	}
+}
+
+// TakedownAlbum disables the public share link of an album. Visitors see
+// the reason instead of the photos; the owner keeps the album. Open
+// reports on the album are closed as resolved.
+func TakedownAlbum(ctx context.Context, actor AdminActor, albumID int, reason string) error {
+	if reason == "" {
+		return &AdminError{"请填写下架原因"}
+	}
+	album, err := findModeratedAlbum(ctx, albumID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.CreateAlbumTakedown(ctx, album.ID, reason, &actor.ID); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			return &AdminError{"该影集已被下架"}
+		}
+		return err
+	}
+	if _, err := repository.ResolveOpenAlbumReports(ctx, album.ID, actor.ID); err != nil {
+		util.Log("Failed to resolve reports of album %d: %v", album.ID, err)
+	}
+
+	notifyAlbumOwner(ctx, album, "已被下架", reason)
+	LogAdminAction(ctx, actor, model.AdminActionTakedownAlbum, model.AuditTargetAlbum, album.ID, map[string]any{
+		"title":  album.Title,
+		"reason": reason,
+	})
+	return nil
+}
+
+// RestoreAlbum lifts a takedown so the share link works again. Open
+// reports on the album itself are dismissed, so they cannot immediately
+// take it down again.
+func RestoreAlbum(ctx context.Context, actor AdminActor, albumID int) error {
+	album, err := findModeratedAlbum(ctx, albumID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.DeleteAlbumTakedown(ctx, album.ID); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			return &AdminError{"该影集未被下架"}
+		}
+		return err
+	}
+	if _, err := repository.DismissOpenReports(ctx, album.ID, nil, actor.ID); err != nil {
+		util.Log("Failed to dismiss reports of album %d: %v", album.ID, err)
+	}
+
+	notifyAlbumOwner(ctx, album, "已恢复公开访问", "")
+	LogAdminAction(ctx, actor, model.AdminActionRestoreAlbum, model.AuditTargetAlbum, album.ID, map[string]any{
+		"title": album.Title,
+	})
+	return nil
+}
+
+// HidePhoto hides a photo from the public view of its album. The owner
+// still sees it, marked as hidden.
+func HidePhoto(ctx context.Context, actor AdminActor, albumID, photoID int, reason string) error {
+	album, photo, err := findModeratedPhoto(ctx, albumID, photoID)
+	if err != nil {
+		return err
+	}
+
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+	if err := repository.HidePhoto(ctx, photo.ID, album.ID, reasonPtr); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			return &AdminError{"该照片已被隐藏"}
+		}
+		return err
+	}
+
+	notifyAlbumOwner(ctx, album, "中的一张照片已被隐藏", reason)
+	LogAdminAction(ctx, actor, model.AdminActionHidePhoto, model.AuditTargetPhoto, photo.ID, map[string]any{
+		"albumId":  album.ID,
+		"fileName": photo.OriginalName,
+		"reason":   reason,
+	})
+	return nil
+}
+
+// UnhidePhoto makes a hidden photo public again, dismissing its open reports
+func UnhidePhoto(ctx context.Context, actor AdminActor, albumID, photoID int) error {
+	album, photo, err := findModeratedPhoto(ctx, albumID, photoID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.UnhidePhoto(ctx, photo.ID, album.ID); err != nil {
+		if err == repository.ErrNoRowsUpdated {
+			return &AdminError{"该照片未被隐藏"}
+		}
+		return err
+	}
+	if _, err := repository.DismissOpenReports(ctx, album.ID, &photo.ID, actor.ID); err != nil {
+		util.Log("Failed to dismiss reports of photo %d: %v", photo.ID, err)
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionUnhidePhoto, model.AuditTargetPhoto, photo.ID, map[string]any{
+		"albumId":  album.ID,
+		"fileName": photo.OriginalName,
+	})
+	return nil
+}
+
+// AdminDeletePhoto permanently deletes a photo and its files
+func AdminDeletePhoto(ctx context.Context, actor AdminActor, albumID, photoID int, reason string) error {
+	album, photo, err := findModeratedPhoto(ctx, albumID, photoID)
+	if err != nil {
+		return err
+	}
+
+	if ossService := GetOSSService(); ossService != nil {
+		if err := ossService.DeletePhotos(ctx, []string{photo.OSSKey, photo.ThumbnailOSSKey}); err != nil {
+			util.Log("Failed to delete files of photo %d: %v", photo.ID, err)
+		}
+	}
+	if err := repository.DeletePhoto(ctx, photo.ID, album.ID); err != nil {
+		return err
+	}
+	if err := repository.IncrementAlbumPhotoCount(ctx, album.ID, -1); err != nil {
+		util.Log("Failed to update photo count of album %d: %v", album.ID, err)
+	}
+
+	notifyAlbumOwner(ctx, album, "中的一张照片已被删除", reason)
+	LogAdminAction(ctx, actor, model.AdminActionDeletePhoto, model.AuditTargetPhoto, photo.ID, map[string]any{
+		"albumId":  album.ID,
+		"fileName": photo.OriginalName,
+		"reason":   reason,
+	})
+	return nil
+}
+
+// ReviewReport moves a report in the moderation queue to a new status
+func ReviewReport(ctx context.Context, actor AdminActor, reportID int, status string) error {
+	if status != model.ReportStatusOpen && status != model.ReportStatusResolved && status != model.ReportStatusDismissed {
+		return &AdminError{"无效的处理状态"}
+	}
+	report, err := repository.FindAlbumReport(ctx, reportID)
+	if err != nil {
+		return &AdminError{"举报不存在"}
+	}
+	if report.Status == status {
+		return nil
+	}
+
+	if err := repository.UpdateAlbumReportStatus(ctx, report.ID, status, actor.ID); err != nil {
+		return err
+	}
+
+	LogAdminAction(ctx, actor, model.AdminActionReviewReport, model.AuditTargetReport, report.ID, map[string]any{
+		"albumId": report.AlbumID,
+		"from":    report.Status,
+		"to":      status,
+	})
+	return nil
+}
+
+// reportCooldown is how long an IP or a user must wait before reporting the
+// same album or photo again
+const reportCooldown = 24 * time.Hour
+
+// autoTakedownReason is shown to visitors of an album taken down by reports
+const autoTakedownReason = "该影集收到多次举报，已暂停公开访问，等待管理员审核"
+
+// ReportError is a user-facing error for a rejected abuse report
+type ReportError struct {
+	Message string
+}
+
+func (e *ReportError) Error() string {
+	return e.Message
+}
+
+// SubmitAlbumReport stores a visitor's report on a shared album or one of
+// its photos, then takes the content out of public view if enough
+// different signed-in users have reported it
+func SubmitAlbumReport(ctx context.Context, album *model.Album, report *model.AlbumReport) error {
+	if !model.IsValidReportCategory(report.Category) {
+		return &ReportError{"请选择举报类型"}
+	}
+	report.AlbumID = album.ID
+
+	if report.ReporterIP != nil {
+		reported, err := repository.HasRecentAlbumReport(ctx, album.ID, report.PhotoID, report.ReporterID, *report.ReporterIP, time.Now().Add(-reportCooldown))
+		if err != nil {
+			return err
+		}
+		if reported {
+			return &ReportError{"您已举报过该内容，我们会尽快处理"}
+		}
+	}
+
+	if err := repository.CreateAlbumReport(ctx, report); err != nil {
+		return err
+	}
+
+	// Anonymous reports wait for an admin; anyone can send them from many IPs
+	if report.ReporterID != nil {
+		applyReportThreshold(ctx, album, report.PhotoID)
+	}
+	return nil
+}
+
+// applyReportThreshold hides a reported album or photo once open reports
+// from enough different signed-in users have come in. Counting verified
+// accounts rather than reports or IPs keeps a single visitor from hiding
+// content on their own.
+func applyReportThreshold(ctx context.Context, album *model.Album, photoID *int) {
+	threshold := config.Get().Moderation.AutoHideThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	reporters, err := repository.CountOpenReporters(ctx, album.ID, photoID)
+	if err != nil {
+		util.Log("Failed to count reporters of album %d: %v", album.ID, err)
+		return
+	}
+	if reporters < threshold {
+		return
+	}
+
+	if photoID == nil {
+		err := repository.CreateAlbumTakedown(ctx, album.ID, autoTakedownReason, nil)
+		if err == repository.ErrNoRowsUpdated {
+			return
+		}
+		if err != nil {
+			util.Log("Failed to take down reported album %d: %v", album.ID, err)
+			return
+		}
+		notifyAlbumOwner(ctx, album, "已暂停公开访问", autoTakedownReason)
+		LogAdminAction(ctx, systemActor, model.AdminActionTakedownAlbum, model.AuditTargetAlbum, album.ID, map[string]any{
+			"title":     album.Title,
+			"automatic": true,
+			"reporters": reporters,
+		})
+		return
+	}
+
+	reason := fmt.Sprintf("收到 %d 位用户举报，等待管理员审核", reporters)
+	err = repository.HidePhoto(ctx, *photoID, album.ID, &reason)
+	if err == repository.ErrNoRowsUpdated {
+		return
+	}
+	if err != nil {
+		util.Log("Failed to hide reported photo %d: %v", *photoID, err)
+		return
+	}
+	notifyAlbumOwner(ctx, album, "中的一张照片已被暂时隐藏", reason)
+	LogAdminAction(ctx, systemActor, model.AdminActionHidePhoto, model.AuditTargetPhoto, *photoID, map[string]any{
+		"albumId":   album.ID,
+		"automatic": true,
+		"reporters": reporters,
+	})
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/oauth2"
+)
+
+// How long a user has to complete the login at the identity provider
+const OIDCLoginTTL = 10 * time.Minute
+
+// Client for discovery, key and token requests to the identity provider
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDCError is a user-facing error for an OIDC login
+type OIDCError struct {
+	Message string
+}
+
+func (e *OIDCError) Error() string {
+	return e.Message
+}
+
+type OIDCService struct {
+	cfg         config.OIDCConfig
+	redirectURL string
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+var oidcService *OIDCService
+
+// InitOIDC initializes the OIDC login service if an identity provider is configured.
+// Discovery happens on first use so an unreachable provider does not stop startup.
+func InitOIDC() {
+	cfg := config.Get()
+	if !cfg.OIDC.Enabled() {
+		return
+	}
+	oidcService = newOIDCService(cfg.OIDC, cfg.Frontend.URL+"/auth/oidc/callback")
+}
+
+// newOIDCService builds the OIDC service for an identity provider
+func newOIDCService(cfg config.OIDCConfig, redirectURL string) *OIDCService {
+	return &OIDCService{cfg: cfg, redirectURL: redirectURL}
+}
+
+// GetOIDCService returns the OIDC service instance, or nil when not configured
+func GetOIDCService() *OIDCService {
+	return oidcService
+}
+
+// getProvider runs discovery against the issuer once and caches the result
+func (s *OIDCService) getProvider() (*oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	// The provider keeps this context for fetching signing keys later on
+	provider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), oidcHTTPClient), s.cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *OIDCService) oauthConfig(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  s.redirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+}
+
+// BeginLogin returns the identity provider URL to send the browser to and
+// the state value, which the caller must bind to the browser so that the
+// callback cannot be replayed in someone else's browser
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, string, error) {
+	provider, err := s.getProvider()
+	if err != nil {
+		return "", "", err
+	}
+
+	state := util.GenerateRandomToken()
+	nonce := util.GenerateRandomToken()
+	verifier := oauth2.GenerateVerifier()
+
+	err = repository.CreateOIDCState(ctx, util.HashToken(state), verifier, nonce, time.Now().Add(OIDCLoginTTL))
+	if err != nil {
+		return "", "", err
+	}
+
+	return s.authCodeURL(provider, state, nonce, verifier), state, nil
+}
+
+// authCodeURL builds the authorization request with PKCE and a nonce
+func (s *OIDCService) authCodeURL(provider *oidc.Provider, state, nonce, verifier string) string {
+	return s.oauthConfig(provider).AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oidc.Nonce(nonce),
+	)
+}
+
+// oidcClaims are the ID token claims used to find or create the user
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// oidcIdentity is the account at the identity provider that a login proved
+type oidcIdentity struct {
+	Issuer  string
+	Subject string
+	Claims  oidcClaims
+	IsAdmin bool
+}
+
+// FinishLogin exchanges the authorization code, validates the ID token and
+// returns the linked (or newly created) user
+func (s *OIDCService) FinishLogin(ctx context.Context, code, state string) (*model.User, error) {
+	verifier, nonce, err := repository.ConsumeOIDCState(ctx, util.HashToken(state))
+	if err != nil {
+		return nil, &OIDCError{"登录已超时，请重试"}
+	}
+
+	identity, err := s.exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveOIDCUser(ctx, identity.Issuer, identity.Subject, &identity.Claims, identity.IsAdmin)
+}
+
+// exchange redeems the authorization code with the PKCE verifier and checks
+// the ID token's signature, audience and nonce
+func (s *OIDCService) exchange(ctx context.Context, code, verifier, nonce string) (*oidcIdentity, error) {
+	provider, err := s.getProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.oauthConfig(provider).Exchange(oidc.ClientContext(ctx, oidcHTTPClient), code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		util.Log("OIDC code exchange failed: %v", err)
+		return nil, &OIDCError{"登录失败，请重试"}
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, &OIDCError{"身份提供方未返回 ID Token"}
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		util.Log("OIDC ID token verification failed: %v", err)
+		return nil, &OIDCError{"身份验证失败，请重试"}
+	}
+	if idToken.Nonce != nonce {
+		return nil, &OIDCError{"身份验证失败，请重试"}
+	}
+
+	identity := &oidcIdentity{Issuer: idToken.Issuer, Subject: idToken.Subject}
+	if err := idToken.Claims(&identity.Claims); err != nil {
+		return nil, err
+	}
+	var rawClaims map[string]interface{}
+	if err := idToken.Claims(&rawClaims); err != nil {
+		return nil, err
+	}
+	identity.IsAdmin = s.isAdmin(rawClaims)
+
+	return identity, nil
+}
+
+// isAdmin reports whether the groups claim contains a configured admin group
+func (s *OIDCService) isAdmin(claims map[string]interface{}) bool {
+	var groups []string
+	switch v := claims[s.cfg.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if name, ok := g.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+	case string:
+		groups = strings.Fields(v)
+	}
+
+	for _, g := range groups {
+		for _, admin := range s.cfg.AdminGroups {
+			if g == admin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveOIDCUser finds the user linked to an IdP account, linking an
+// existing account by verified email or creating a photographer account
+func resolveOIDCUser(ctx context.Context, issuer, subject string, claims *oidcClaims, isAdmin bool) (*model.User, error) {
+	var user *model.User
+
+	identity, err := repository.FindUserIdentity(ctx, issuer, subject)
+	switch {
+	case err == nil:
+		user, err = repository.FindUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+	case err == pgx.ErrNoRows:
+		// Only a verified email may claim an account
+		if claims.Email == "" || !claims.EmailVerified {
+			return nil, &OIDCError{"身份提供方未返回已验证的邮箱，无法登录"}
+		}
+
+		user, err = repository.FindUserByEmail(ctx, claims.Email)
+		if err == nil {
+			// Someone could have registered the address here without owning it
+			if !user.EmailVerified {
+				return nil, &OIDCError{"该邮箱已注册但尚未验证，请先使用密码登录并完成邮箱验证"}
+			}
+		} else if err == pgx.ErrNoRows {
+			user, err = provisionOIDCUser(ctx, claims)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+
+		identity = &model.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  issuer,
+			Subject: subject,
+			Email:   claims.Email,
+		}
+		if err := repository.CreateUserIdentity(ctx, identity); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, err
+	}
+
+	grantedAdmin := identity.GrantedAdmin
+	if len(config.Get().OIDC.AdminGroups) > 0 {
+		switch {
+		case isAdmin && user.Role != "admin":
+			if err := repository.UpdateUserRole(ctx, user.ID, "admin"); err != nil {
+				return nil, err
+			}
+			user.Role = "admin"
+			grantedAdmin = true
+		case !isAdmin && user.Role == "admin" && identity.GrantedAdmin:
+			// Only take back admin rights that the group granted
+			if err := repository.UpdateUserRole(ctx, user.ID, "photographer"); err != nil {
+				return nil, err
+			}
+			user.Role = "photographer"
+			grantedAdmin = false
+		}
+	}
+
+	email := identity.Email
+	if claims.Email != "" {
+		email = claims.Email
+	}
+	if err := repository.UpdateUserIdentityLogin(ctx, identity.ID, email, grantedAdmin); err != nil {
+		util.Log("Failed to record OIDC login for user %d: %v", user.ID, err)
+	}
+
+	return user, nil
+}
+
+// provisionOIDCUser creates a photographer account for a first-time SSO user.
+// The account gets an unknown random password; the user can set one through
+// the forgot-password flow.
+func provisionOIDCUser(ctx context.Context, claims *oidcClaims) (*model.User, error) {
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+	if utf8.RuneCountInString(name) > 50 {
+		name = string([]rune(name)[:50])
+	}
+
+	passwordHash, err := util.HashPassword(util.GenerateRandomToken())
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:         claims.Email,
+		PasswordHash:  passwordHash,
+		Name:          name,
+		Role:          "photographer",
+		EmailVerified: true,
+	}
+	if err := repository.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// runOIDCStateCleanup removes OIDC logins that were never completed
+func runOIDCStateCleanup(ctx context.Context) {
+	count, err := repository.DeleteExpiredOIDCStates(ctx)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to delete expired OIDC login states: %v\n", err)
+		return
+	}
+	if count > 0 {
+		fmt.Printf("[Cron] Deleted %d expired OIDC login states\n", count)
+	}
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"picshare/config"
+)
+
+const (
+	testClientID     = "picshare"
+	testClientSecret = "secret"
+	testRedirectURL  = "https://www.picshare.test/auth/oidc/callback"
+)
+
+// mockIdP is a minimal OIDC provider: discovery, signing keys, an
+// authorization endpoint that signs the user straight in, and a token
+// endpoint that enforces PKCE
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// Signs ID tokens instead of the published key when set
+	signingKey *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]mockGrant
+
+	// Claims put into the next ID token; nonce and audience can be overridden
+	claims map[string]interface{}
+}
+
+type mockGrant struct {
+	challenge string
+	nonce     string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{key: key, grants: map[string]mockGrant{}, claims: map[string]interface{}{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (p *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := p.server.URL
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize signs the user in and redirects back with a code
+func (p *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != testClientID || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := base64.RawURLEncoding.EncodeToString(big.NewInt(time.Now().UnixNano()).Bytes())
+	p.mu.Lock()
+	p.grants[code] = mockGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != testClientID || clientSecret != testClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	p.mu.Lock()
+	grant, found := p.grants[r.PostFormValue("code")]
+	delete(p.grants, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !found || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"aud":   testClientID,
+		"sub":   "user-1",
+		"nonce": grant.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.sign(claims),
+	})
+}
+
+// sign issues an RS256 JWT
+func (p *mockIdP) sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	key := p.key
+	if p.signingKey != nil {
+		key = p.signingKey
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// login runs the browser side of a login: it follows the authorization URL
+// and returns the code and state the identity provider redirected back with
+func (p *mockIdP) login(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization returned %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func newTestOIDCService(idp *mockIdP) *OIDCService {
+	return newOIDCService(config.OIDCConfig{
+		IssuerURL:    idp.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		GroupsClaim:  "groups",
+		AdminGroups:  []string{"picshare-admins"},
+	}, testRedirectURL)
+}
+
+// startTestLogin plays the part of BeginLogin without the state table
+func startTestLogin(t *testing.T, svc *OIDCService) (authURL, state, nonce, verifier string) {
+	t.Helper()
+	provider, err := svc.getProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, nonce, verifier = "state-1", "nonce-1", oauth2.GenerateVerifier()
+	return svc.authCodeURL(provider, state, nonce, verifier), state, nonce, verifier
+}
+
+func expectOIDCError(t *testing.T, err error) {
+	t.Helper()
+	var oidcErr *OIDCError
+	if !errors.As(err, &oidcErr) {
+		t.Fatalf("expected an OIDCError, got %v", err)
+	}
+}
+
+func TestOIDCLoginWithMockIdP(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = map[string]interface{}{
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"name":           "Alice",
+		"groups":         []string{"staff", "picshare-admins"},
+	}
+	svc := newTestOIDCService(idp)
+
+	authURL, state, nonce, verifier := startTestLogin(t, svc)
+	code, returnedState := idp.login(t, authURL)
+	if returnedState != state {
+		t.Fatalf("state %q, want %q", returnedState, state)
+	}
+
+	identity, err := svc.exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if identity.Issuer != idp.server.URL || identity.Subject != "user-1" {
+		t.Fatalf("unexpected identity %s / %s", identity.Issuer, identity.Subject)
+	}
+	if identity.Claims.Email != "alice@example.com" || !identity.Claims.EmailVerified {
+		t.Fatalf("unexpected claims %+v", identity.Claims)
+	}
+	if !identity.IsAdmin {
+		t.Fatal("member of the admin group was not made an admin")
+	}
+}
+
+func TestOIDCLoginWithoutAdminGroup(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = map[string]interface{}{"email": "bob@example.com", "groups": "staff"}
+	svc := newTestOIDCService(idp)
+
+	authURL, _, nonce, verifier := startTestLogin(t, svc)
+	code, _ := idp.login(t, authURL)
+
+	identity, err := svc.exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if identity.IsAdmin {
+		t.Fatal("user outside the admin group was made an admin")
+	}
+}
+
+func TestOIDCLoginRejectsWrongVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	svc := newTestOIDCService(idp)
+
+	authURL, _, nonce, _ := startTestLogin(t, svc)
+	code, _ := idp.login(t, authURL)
+
+	_, err := svc.exchange(context.Background(), code, oauth2.GenerateVerifier(), nonce)
+	expectOIDCError(t, err)
+}
+
+func TestOIDCLoginRejectsNonceMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = map[string]interface{}{"nonce": "replayed"}
+	svc := newTestOIDCService(idp)
+
+	authURL, _, nonce, verifier := startTestLogin(t, svc)
+	code, _ := idp.login(t, authURL)
+
+	_, err := svc.exchange(context.Background(), code, verifier, nonce)
+	expectOIDCError(t, err)
+}
+
+func TestOIDCLoginRejectsOtherAudience(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = map[string]interface{}{"aud": "another-client"}
+	svc := newTestOIDCService(idp)
+
+	authURL, _, nonce, verifier := startTestLogin(t, svc)
+	code, _ := idp.login(t, authURL)
+
+	_, err := svc.exchange(context.Background(), code, verifier, nonce)
+	expectOIDCError(t, err)
+}
+
+func TestOIDCLoginRejectsForgedSignature(t *testing.T) {
+	idp := newMockIdP(t)
+	svc := newTestOIDCService(idp)
+
+	authURL, _, nonce, verifier := startTestLogin(t, svc)
+	code, _ := idp.login(t, authURL)
+
+	// Sign with a key the provider does not publish
+	forger, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.signingKey = forger
+
+	_, err = svc.exchange(context.Background(), code, verifier, nonce)
+	expectOIDCError(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"path"
 	"picshare/config"
 	"picshare/util"
+	"strings"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -107,26 +108,18 @@ func (s *OSSService) UploadPhoto(ctx context.Context, userID, albumID int, fileI
 	return originalURL, thumbnailURL, ossKey, thumbOSSKey, nil
 }
 
-// UploadAvatar uploads an avatar image
-func (s *OSSService) UploadAvatar(ctx context.Context, userID int, fileID string,
-	file multipart.File, header *multipart.FileHeader, ext string) (string, string, error) {
-
-	// Read file content
-	fileContent, err := io.ReadAll(file)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read file: %w", err)
-	}
-
+// UploadAvatar uploads one size of a user's avatar, already encoded as JPEG
+func (s *OSSService) UploadAvatar(ctx context.Context, userID int, fileID string, size int, data []byte) (string, string, error) {
 	// Generate OSS key
-	ossKey := fmt.Sprintf("avatars/%d/%s.%s", userID, fileID, ext)
+	ossKey := AvatarOSSKey(userID, fileID, size)
 
 	// Upload
 	options := []oss.Option{
-		oss.ContentType(header.Header.Get("Content-Type")),
+		oss.ContentType("image/jpeg"),
 		oss.CacheControl("max-age=31536000"),
 	}
 
-	err = s.bucket.PutObject(ossKey, bytes.NewReader(fileContent), options...)
+	err := s.bucket.PutObject(ossKey, bytes.NewReader(data), options...)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to upload avatar: %w", err)
 	}
@@ -190,16 +183,102 @@ func (s *OSSService) DeletePhotos(ctx context.Context, ossKeys []string) error {
 	return nil
 }
 
+// DeletePrefix deletes every object whose key starts with prefix and
+// returns how many were removed
+func (s *OSSService) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+	token := ""
+	for {
+		options := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000)}
+		if token != "" {
+			options = append(options, oss.ContinuationToken(token))
+		}
+
+		result, err := s.bucket.ListObjectsV2(options...)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		keys := make([]string, 0, len(result.Objects))
+		for _, obj := range result.Objects {
+			keys = append(keys, obj.Key)
+		}
+		if err := s.DeletePhotos(ctx, keys); err != nil {
+			return deleted, err
+		}
+		deleted += len(keys)
+
+		if !result.IsTruncated {
+			return deleted, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
 // DeleteSingle deletes a single file from OSS
 func (s *OSSService) DeleteSingle(ctx context.Context, ossKey string) error {
 	return s.bucket.DeleteObject(ossKey)
 }
 
+// SetStorageClass rewrites an object in place with a different storage class
+func (s *OSSService) SetStorageClass(ctx context.Context, ossKey, storageClass string) error {
+	_, err := s.bucket.CopyObject(ossKey, ossKey,
+		oss.ObjectStorageClass(oss.StorageClassType(storageClass)),
+		oss.MetadataDirective(oss.MetaCopy),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to change storage class: %w", err)
+	}
+	return nil
+}
+
+// CopyObject copies an object within the bucket in standard storage and
+// returns the URL of the copy
+func (s *OSSService) CopyObject(ctx context.Context, srcKey, destKey string) (string, error) {
+	_, err := s.bucket.CopyObject(srcKey, destKey,
+		oss.ObjectStorageClass(oss.StorageStandard),
+		oss.MetadataDirective(oss.MetaCopy),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy object: %w", err)
+	}
+	return s.GenerateURL(destKey), nil
+}
+
+// GetObjectBytes reads a whole object from OSS into memory
+func (s *OSSService) GetObjectBytes(ctx context.Context, ossKey string) ([]byte, error) {
+	body, err := s.bucket.GetObject(ossKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// GetObject opens an object for streaming; the caller must close it
+func (s *OSSService) GetObject(ctx context.Context, ossKey string) (io.ReadCloser, error) {
+	body, err := s.bucket.GetObject(ossKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	return body, nil
+}
+
 // GenerateURL generates the full URL for an OSS object
 func (s *OSSService) GenerateURL(ossKey string) string {
 	return fmt.Sprintf("https://%s.%s/%s", s.bucketName, s.region + ".aliyuncs.com", ossKey)
 }
 
+// KeyFromURL returns the OSS key of an object URL made by GenerateURL
+func (s *OSSService) KeyFromURL(rawURL string) (string, bool) {
+	prefix := s.GenerateURL("")
+	if !strings.HasPrefix(rawURL, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(rawURL, prefix), true
+}
+
 // GeneratePresignedURL generates a presigned URL for download
 func (s *OSSService) GeneratePresignedURL(ctx context.Context, ossKey string, expiry time.Duration) (string, error) {
 	return s.bucket.SignURL(ossKey, oss.HTTPGet, int64(expiry.Seconds()))
@@ -231,6 +310,19 @@ func (s *OSSService) UploadBytes(ctx context.Context, ossKey string, data []byte
 	return s.GenerateURL(ossKey), nil
 }
 
+// UploadPrivateFile uploads a local file that can only be read through
+// presigned URLs
+func (s *OSSService) UploadPrivateFile(ctx context.Context, ossKey, localPath, contentType string) error {
+	err := s.bucket.PutObjectFromFile(ossKey, localPath,
+		oss.ContentType(contentType),
+		oss.ObjectACL(oss.ACLPrivate),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
 // DownloadFile downloads a file from OSS to local temp path
 func (s *OSSService) DownloadFile(ctx context.Context, ossKey, localPath string) error {
 	// Ensure directory exists
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"picshare/model"
+	"picshare/repository"
+)
+
+// Album actions checked by AuthorizeAlbum
+const (
+	AlbumActionView         = "view"          // see the album, its photos and originals
+	AlbumActionEdit         = "edit"          // change title, description and expiry policy
+	AlbumActionShare        = "share"         // change how long the share link works
+	AlbumActionUpload       = "upload"        // add photos
+	AlbumActionDeletePhotos = "delete_photos" // remove photos
+	AlbumActionManage       = "manage"        // delete the album, move it between workspaces, manage collaborators
+)
+
+// Album authorization errors
+var (
+	ErrAlbumNotFound  = errors.New("album not found")
+	ErrAlbumForbidden = errors.New("album action forbidden")
+)
+
+// workspaceRoleActions lists what each workspace role may do on the
+// workspace's albums. Owners may do everything.
+var workspaceRoleActions = map[string][]string{
+	model.WorkspaceRoleEditor: {AlbumActionView, AlbumActionEdit, AlbumActionShare, AlbumActionUpload, AlbumActionDeletePhotos},
+	model.WorkspaceRoleViewer: {AlbumActionView},
+}
+
+// collaboratorActions lists what an album collaborator may do given their permissions
+func collaboratorActions(c *model.AlbumCollaborator) []string {
+	actions := []string{AlbumActionView}
+	if c.CanUpload {
+		actions = append(actions, AlbumActionUpload)
+	}
+	if c.CanDelete {
+		actions = append(actions, AlbumActionDeletePhotos)
+	}
+	if c.CanManageSharing {
+		actions = append(actions, AlbumActionShare)
+	}
+	return actions
+}
+
+// AuthorizeAlbum loads an album and checks that the user may perform the
+// action on it. Creators may do everything with personal albums; albums in
+// a workspace are governed by workspace roles alone, so a creator who left
+// the workspace loses access. Collaborators are limited by their granted
+// permissions; admins may view any album.
+// Returns ErrAlbumNotFound when the album does not exist or the user cannot
+// even view it, and ErrAlbumForbidden when the user can view but not act.
+func AuthorizeAlbum(ctx context.Context, albumID, userID int, isAdmin bool, action string) (*model.Album, error) {
+	album, err := repository.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return nil, ErrAlbumNotFound
+	}
+
+	if album.UserID == userID && album.WorkspaceID == nil {
+		return album, nil
+	}
+
+	canView := isAdmin
+	if album.WorkspaceID != nil {
+		role, err := repository.GetWorkspaceRole(ctx, *album.WorkspaceID, userID)
+		if err == nil {
+			if role == model.WorkspaceRoleOwner || hasAction(workspaceRoleActions[role], action) {
+				return album, nil
+			}
+			canView = true
+		}
+	}
+
+	collaborator, err := repository.FindAlbumCollaborator(ctx, album.ID, userID)
+	if err == nil && collaborator.IsAccepted() {
+		if hasAction(collaboratorActions(collaborator), action) {
+			return album, nil
+		}
+		canView = true
+	}
+
+	if canView {
+		if action == AlbumActionView {
+			return album, nil
+		}
+		return nil, ErrAlbumForbidden
+	}
+	return nil, ErrAlbumNotFound
+}
+
+// CanCreateInWorkspace checks that the user may add albums to a workspace
+func CanCreateInWorkspace(ctx context.Context, workspaceID, userID int) bool {
+	role, err := repository.GetWorkspaceRole(ctx, workspaceID, userID)
+	return err == nil && (role == model.WorkspaceRoleOwner || role == model.WorkspaceRoleEditor)
+}
+
+func hasAction(actions []string, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"picshare/config"
+	"picshare/repository"
+	"picshare/util"
+)
+
+// UserLimits are the upload limits that apply to one user: the global
+// defaults with any per-user overrides set by an admin
+type UserLimits struct {
+	MaxAlbums         int
+	MaxPhotosPerAlbum int
+	// 0 for unlimited
+	MaxStorage int64
+}
+
+// GetUserLimits returns the limits for a user, falling back to the
+// defaults if the overrides cannot be loaded
+func GetUserLimits(ctx context.Context, userID int) UserLimits {
+	cfg := config.Get()
+	limits := UserLimits{
+		MaxAlbums:         cfg.Upload.MaxAlbumsPerUser,
+		MaxPhotosPerAlbum: cfg.Upload.MaxPhotosPerAlbum,
+		MaxStorage:        cfg.Upload.MaxStoragePerUser,
+	}
+
+	quota, err := repository.FindUserQuota(ctx, userID)
+	if err != nil {
+		util.Log("Failed to load quota overrides for user %d: %v", userID, err)
+		return limits
+	}
+	if quota.MaxAlbums != nil {
+		limits.MaxAlbums = *quota.MaxAlbums
+	}
+	if quota.MaxPhotosPerAlbum != nil {
+		limits.MaxPhotosPerAlbum = *quota.MaxPhotosPerAlbum
+	}
+	if quota.StorageQuotaBytes != nil {
+		limits.MaxStorage = *quota.StorageQuotaBytes
+	}
+	return limits
+}
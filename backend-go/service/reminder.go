@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"sort"
+	"time"
+)
+
+// runExpiryReminders emails album owners ahead of expiry and deletion.
+// Each reminder is recorded before sending so it goes out only once per expiry.
+func runExpiryReminders(ctx context.Context) {
+	emailService := GetEmailService()
+	if emailService == nil {
+		return
+	}
+
+	cfg := config.Get()
+	sent := 0
+
+	// Smallest offset first, so an album that is due for several reminders at
+	// once (e.g. after downtime) gets only the most urgent one
+	offsets := append([]time.Duration(nil), cfg.Reminder.Offsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	notified := make(map[int]bool)
+	for _, offset := range offsets {
+		kind := "before_expiry_" + offset.String()
+		candidates, err := repository.GetAlbumsDueForExpiryReminder(ctx, offset, kind)
+		if err != nil {
+			fmt.Printf("[Cron] Failed to get albums for %s reminder: %v\n", kind, err)
+			continue
+		}
+
+		for _, candidate := range candidates {
+			if notified[candidate.Album.ID] {
+				_, _ = repository.RecordExpiryReminder(ctx, candidate.Album.ID, kind, candidate.Album.ExpiresAt)
+				continue
+			}
+			if sendExpiryReminder(ctx, candidate, kind, candidate.Album.ExpiresAt, false) {
+				notified[candidate.Album.ID] = true
+				sent++
+			}
+		}
+	}
+
+	// Final notice before the cleanup job deletes an expired album
+	candidates, err := repository.GetAlbumsDueForDeletionNotice(ctx, cfg.Reminder.BeforeDeletion)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to get albums for deletion notice: %v\n", err)
+	} else {
+		for _, candidate := range candidates {
+			deleteAt := candidate.Album.ExpiresAt.Add(24 * time.Hour)
+			if sendExpiryReminder(ctx, candidate, repository.ReminderKindBeforeDeletion, deleteAt, true) {
+				sent++
+			}
+		}
+	}
+
+	if sent > 0 {
+		fmt.Printf("[Cron] Sent %d expiry reminders\n", sent)
+	}
+}
+
+// sendExpiryReminder records and sends a single reminder, releasing the
+// record again if the email could not be sent so the next run retries it
+func sendExpiryReminder(ctx context.Context, candidate model.ExpiryReminderCandidate, kind string, deadline time.Time, deleting bool) bool {
+	album := candidate.Album
+
+	claimed, err := repository.RecordExpiryReminder(ctx, album.ID, kind, album.ExpiresAt)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to record %s reminder for album %d: %v\n", kind, album.ID, err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	cfg := config.Get()
+	// The link stays valid until the album would be deleted. It is left out
+	// when the extension would exceed the maximum lifetime.
+	var token string
+	if CanExtendByLink(album.ExpiresAt) {
+		validFor := time.Until(album.ExpiresAt.Add(24 * time.Hour))
+		token, err = util.GenerateAlbumExtendToken(album.ID, album.UserID, album.ExpiresAt, validFor)
+	}
+	if err == nil {
+		err = GetEmailService().SendExpiryReminderEmail(candidate.OwnerEmail, candidate.OwnerName,
+			album.Title, deadline, deleting, token, cfg.Reminder.ExtendHours)
+	}
+	if err != nil {
+		fmt.Printf("[Cron] Failed to send %s reminder for album %d: %v\n", kind, album.ID, err)
+		_ = repository.DeleteExpiryReminder(ctx, album.ID, kind, album.ExpiresAt)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"time"
+)
+
+// Revoked and expired sessions are kept this long so users can still see
+// why they were signed out, then purged by the cleanup job
+const staleSessionRetention = 7 * 24 * time.Hour
+
+// AuthTokens is the token pair handed to a client after signing in
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	SessionID    int
+}
+
+// IssueSession starts a new session for a user who has just proven their
+// identity and returns its first access and refresh tokens. Every sign-in
+// method goes through here.
+func IssueSession(ctx context.Context, user *model.User, ip, userAgent string) (*AuthTokens, error) {
+	cfg := config.Get()
+
+	refreshToken := util.GenerateRandomToken()
+	session := &model.Session{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	}
+	if ip != "" {
+		session.IPAddress = &ip
+	}
+	if userAgent != "" {
+		session.UserAgent = &userAgent
+	}
+
+	if err := repository.CreateSession(ctx, session, util.HashToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := util.GenerateJWT(user.ID, session.ID, user.Email, user.Role, user.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(cfg.JWT.AccessTokenTTL.Seconds()),
+		SessionID:    session.ID,
+	}, nil
+}
+
+// RefreshSession rotates a refresh token and issues a new access token for
+// the same session. A refresh token that was already used revokes the session.
+func RefreshSession(ctx context.Context, refreshToken string) (*AuthTokens, error) {
+	cfg := config.Get()
+
+	newRefreshToken := util.GenerateRandomToken()
+	sessionID, userID, err := repository.RotateRefreshToken(ctx,
+		util.HashToken(refreshToken),
+		util.HashToken(newRefreshToken),
+		time.Now().Add(cfg.JWT.RefreshTokenTTL),
+	)
+	if err == repository.ErrRefreshTokenReused {
+		util.Log("Refresh token reuse detected for user %d, session %d revoked", userID, sessionID)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Claims carry the user's current role and name, not those at sign-in
+	user, err := repository.FindSessionUser(ctx, sessionID, userID)
+	if err != nil {
+		return nil, repository.ErrRefreshTokenInvalid
+	}
+
+	accessToken, err := util.GenerateJWT(user.ID, sessionID, user.Email, user.Role, user.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int(cfg.JWT.AccessTokenTTL.Seconds()),
+		SessionID:    sessionID,
+	}, nil
+}
+
+// runSessionCleanup purges sessions that ended more than a week ago
+func runSessionCleanup(ctx context.Context) {
+	count, err := repository.DeleteStaleSessions(ctx, time.Now().Add(-staleSessionRetention))
+	if err != nil {
+		fmt.Printf("[Cron] Failed to delete stale sessions: %v\n", err)
+		return
+	}
+	if count > 0 {
+		fmt.Printf("[Cron] Deleted %d stale sessions\n", count)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"time"
+)
+
+// TransferError is a user-facing error for an album transfer
+type TransferError struct {
+	Message string
+}
+
+func (e *TransferError) Error() string {
+	return e.Message
+}
+
+// AcceptAlbumTransfer hands the album of a pending transfer to its recipient.
+// With RekeyStorage the photos are first copied under photos/<newUser>/ and
+// the old objects are removed once the database change has committed.
+func AcceptAlbumTransfer(ctx context.Context, t *model.AlbumTransfer) error {
+	if !t.IsPending() {
+		return &TransferError{"转移请求已失效"}
+	}
+
+	// The recipient may have been suspended or scheduled for deletion since
+	// the transfer was sent
+	suspendedAt, _, err := repository.FindUserSuspension(ctx, t.ToUserID)
+	if err != nil {
+		return err
+	}
+	if suspendedAt != nil {
+		return &TransferError{"账号已被停用，无法接收影集"}
+	}
+	deletionAt, err := repository.FindAccountDeletion(ctx, t.ToUserID)
+	if err != nil {
+		return err
+	}
+	if deletionAt != nil {
+		return &TransferError{"账号正在注销中，无法接收影集"}
+	}
+
+	// The sender must still be the creator and still allowed to manage the
+	// album, e.g. not demoted in or removed from its workspace
+	album, err := AuthorizeAlbum(ctx, t.AlbumID, t.FromUserID, false, AlbumActionManage)
+	if err != nil || album.UserID != t.FromUserID {
+		return &TransferError{"影集不存在或发起人已无权转移"}
+	}
+
+	// Active albums count against the recipient's quota
+	limits := GetUserLimits(ctx, t.ToUserID)
+	if !album.IsExpired && album.ExpiresAt.After(time.Now()) {
+		count, err := repository.CountActiveAlbumsByUser(ctx, t.ToUserID)
+		if err != nil {
+			return err
+		}
+		if count >= limits.MaxAlbums {
+			return &TransferError{fmt.Sprintf("每个用户最多只能有 %d 个未过期影集，请先清理后再接收", limits.MaxAlbums)}
+		}
+	}
+
+	// So do the album's photos against the recipient's storage
+	if limits.MaxStorage > 0 {
+		used, err := repository.GetStorageUsedByUser(ctx, t.ToUserID)
+		if err != nil {
+			return err
+		}
+		size, err := repository.GetAlbumStorageSize(ctx, album.ID)
+		if err != nil {
+			return err
+		}
+		if used+size > limits.MaxStorage {
+			return &TransferError{fmt.Sprintf("存储空间不足（上限 %s），请先清理后再接收", util.FormatFileSize(limits.MaxStorage))}
+		}
+	}
+
+	var moves []model.PhotoKeyMove
+	var oldKeys, newKeys []string
+	var coverURL *string
+	if t.RekeyStorage {
+		if album.IsArchived() {
+			return &TransferError{"已归档影集无法迁移存储路径，请先延长有效期以重新激活"}
+		}
+		moves, oldKeys, newKeys, coverURL, err = copyAlbumObjects(ctx, album, t.ToUserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := repository.CompleteAlbumTransfer(ctx, t, album.WorkspaceID, moves, coverURL); err != nil {
+		if len(newKeys) > 0 {
+			_ = GetOSSService().DeletePhotos(ctx, newKeys)
+		}
+		if err == repository.ErrNoRowsUpdated {
+			return &TransferError{"转移请求已失效"}
+		}
+		return err
+	}
+
+	if len(oldKeys) > 0 {
+		if err := GetOSSService().DeletePhotos(ctx, oldKeys); err != nil {
+			util.Log("Failed to delete old objects of transferred album %d: %v", album.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// copyAlbumObjects copies an album's photos under photos/<userID>/<albumID>/.
+// It returns the key moves to record, the old and new keys, and the new
+// cover URL if the cover pointed at one of the copied thumbnails.
+func copyAlbumObjects(ctx context.Context, album *model.Album, userID int) ([]model.PhotoKeyMove, []string, []string, *string, error) {
+	photos, err := repository.GetPhotosByAlbum(ctx, album.ID)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	ossService := GetOSSService()
+	if ossService == nil {
+		return nil, nil, nil, nil, fmt.Errorf("OSS not initialized")
+	}
+
+	prefix := fmt.Sprintf("photos/%d/%d/", userID, album.ID)
+	var moves []model.PhotoKeyMove
+	var oldKeys, newKeys []string
+	var coverURL *string
+	for _, p := range photos {
+		move := model.PhotoKeyMove{
+			PhotoID: p.ID,
+			OSSKey:  prefix + path.Base(p.OSSKey),
+		}
+
+		move.OriginalURL, err = ossService.CopyObject(ctx, p.OSSKey, move.OSSKey)
+		if err != nil {
+			_ = ossService.DeletePhotos(ctx, newKeys)
+			return nil, nil, nil, nil, fmt.Errorf("failed to copy photo %d: %w", p.ID, err)
+		}
+		oldKeys = append(oldKeys, p.OSSKey)
+		newKeys = append(newKeys, move.OSSKey)
+
+		if p.ThumbnailOSSKey != "" {
+			move.ThumbnailOSSKey = prefix + path.Base(p.ThumbnailOSSKey)
+			move.ThumbnailURL, err = ossService.CopyObject(ctx, p.ThumbnailOSSKey, move.ThumbnailOSSKey)
+			if err != nil {
+				_ = ossService.DeletePhotos(ctx, newKeys)
+				return nil, nil, nil, nil, fmt.Errorf("failed to copy thumbnail of photo %d: %w", p.ID, err)
+			}
+			oldKeys = append(oldKeys, p.ThumbnailOSSKey)
+			newKeys = append(newKeys, move.ThumbnailOSSKey)
+
+			if album.CoverURL != nil && *album.CoverURL == p.ThumbnailURL {
+				url := move.ThumbnailURL
+				coverURL = &url
+			}
+		}
+
+		moves = append(moves, move)
+	}
+
+	return moves, oldKeys, newKeys, coverURL, nil
+}
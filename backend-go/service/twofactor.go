@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strings"
+	"time"
+)
+
+// Number of recovery codes handed out when 2FA is enabled or codes are regenerated
+const recoveryCodeCount = 10
+
+// How long the password step of a login stays valid while waiting for the second factor
+const TwoFactorLoginWindow = 5 * time.Minute
+
+// ErrInvalidTwoFactorCode is returned for a wrong, reused or expired code
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// IsTwoFactorRequired reports whether the user must enroll in 2FA before
+// using admin features
+func IsTwoFactorRequired(user *model.User) bool {
+	return user.Role == "admin" && config.Get().TwoFactor.RequireForAdmins
+}
+
+// VerifyTwoFactorCode checks a 6-digit authenticator code or, failing that,
+// consumes a recovery code. Each code can only be used once.
+func VerifyTwoFactorCode(ctx context.Context, userID int, code string) error {
+	totp, err := repository.FindUserTOTP(ctx, userID)
+	if err != nil || !totp.IsEnabled() {
+		return ErrInvalidTwoFactorCode
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := util.ValidateTOTP(totp.Secret, code, time.Now()); ok {
+		if err := repository.MarkTOTPStepUsed(ctx, userID, step); err != nil {
+			if err == repository.ErrNoRowsUpdated {
+				return ErrInvalidTwoFactorCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	err = repository.UseRecoveryCode(ctx, userID, util.HashToken(util.NormalizeRecoveryCode(code)))
+	if err == repository.ErrNoRowsUpdated {
+		return ErrInvalidTwoFactorCode
+	}
+	return err
+}
+
+// NewRecoveryCodes generates recovery codes, returning them for display
+// along with the hashes to store
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = util.HashToken(code)
+	}
+	return codes, hashes, nil
+}
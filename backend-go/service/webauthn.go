@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"picshare/config"
+	"picshare/model"
+	"picshare/repository"
+	"picshare/util"
+	"strconv"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// Passkey ceremony purposes
+const (
+	passkeyPurposeRegister = "register"
+	passkeyPurposeLogin    = "login"
+)
+
+// How long the browser has to answer a passkey challenge
+const passkeyChallengeTTL = 5 * time.Minute
+
+// PasskeyError is a user-facing error for a passkey ceremony
+type PasskeyError struct {
+	Message string
+}
+
+func (e *PasskeyError) Error() string {
+	return e.Message
+}
+
+type WebAuthnService struct {
+	webAuthn *webauthn.WebAuthn
+}
+
+var webAuthnService *WebAuthnService
+
+// InitWebAuthn initializes the passkey service
+func InitWebAuthn() error {
+	svc, err := newWebAuthnService(config.Get().WebAuthn)
+	if err != nil {
+		return err
+	}
+
+	webAuthnService = svc
+	return nil
+}
+
+// newWebAuthnService builds the passkey service for a relying party
+func newWebAuthnService(cfg config.WebAuthnConfig) (*WebAuthnService, error) {
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    passkeyChallengeTTL,
+		TimeoutUVD: passkeyChallengeTTL,
+	}
+
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		// A passkey sign-in skips the TOTP step, so the authenticator must
+		// verify the user (PIN or biometrics) as well as prove possession
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure WebAuthn: %w", err)
+	}
+
+	return &WebAuthnService{webAuthn: wa}, nil
+}
+
+// GetWebAuthnService returns the passkey service instance
+func GetWebAuthnService() *WebAuthnService {
+	return webAuthnService
+}
+
+// passkeyUser adapts a user and their passkeys to webauthn.User
+type passkeyUser struct {
+	user        *model.User
+	credentials []model.WebAuthnCredential
+}
+
+// passkeyUserHandle is the user handle stored in the authenticator, which
+// identifies the account during a username-less sign-in
+func passkeyUserHandle(userID int) []byte {
+	return []byte(strconv.Itoa(userID))
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return passkeyUserHandle(u.user.ID)
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u *passkeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+	for i, c := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, len(c.Transports))
+		for j, t := range c.Transports {
+			transports[j] = protocol.AuthenticatorTransport(t)
+		}
+
+		credentials[i] = webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: uint32(c.SignCount),
+			},
+		}
+	}
+	return credentials
+}
+
+// saveChallenge stores the session data of a ceremony and returns the token
+// the client sends back to finish it
+func saveChallenge(ctx context.Context, userID *int, purpose string, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	token := util.GenerateRandomToken()
+	err = repository.CreateWebAuthnChallenge(ctx, util.HashToken(token), userID, purpose, data, time.Now().Add(passkeyChallengeTTL))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeChallenge loads and removes a pending ceremony
+func consumeChallenge(ctx context.Context, token, purpose string) (*int, *webauthn.SessionData, error) {
+	userID, data, err := repository.ConsumeWebAuthnChallenge(ctx, util.HashToken(token), purpose)
+	if err != nil {
+		return nil, nil, &PasskeyError{"操作已超时，请重试"}
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, nil, err
+	}
+	return userID, &session, nil
+}
+
+// BeginRegistration starts registering a new passkey for a signed-in user
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, user *model.User) (*protocol.CredentialCreation, string, error) {
+	credentials, err := repository.GetWebAuthnCredentialsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	creation, session, err := s.beginRegistration(user, credentials)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := saveChallenge(ctx, &user.ID, passkeyPurposeRegister, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, token, nil
+}
+
+// beginRegistration creates the registration challenge, excluding the
+// user's existing passkeys so the same authenticator is not registered twice
+func (s *WebAuthnService) beginRegistration(user *model.User, credentials []model.WebAuthnCredential) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	pu := &passkeyUser{user: user, credentials: credentials}
+
+	var exclusions []protocol.CredentialDescriptor
+	for _, c := range pu.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	return s.webAuthn.BeginRegistration(pu, webauthn.WithExclusions(exclusions))
+}
+
+// FinishRegistration verifies the authenticator's response and stores the passkey
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, user *model.User, challengeToken, name string, response io.Reader) (*model.WebAuthnCredential, error) {
+	userID, session, err := consumeChallenge(ctx, challengeToken, passkeyPurposeRegister)
+	if err != nil {
+		return nil, err
+	}
+	if userID == nil || *userID != user.ID {
+		return nil, &PasskeyError{"操作已超时，请重试"}
+	}
+
+	c, err := s.verifyRegistration(user, session, response)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Name = name
+	if err := repository.CreateWebAuthnCredential(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// verifyRegistration checks the authenticator's response to a registration
+// challenge and returns the passkey to store
+func (s *WebAuthnService) verifyRegistration(user *model.User, session *webauthn.SessionData, response io.Reader) (*model.WebAuthnCredential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(response)
+	if err != nil {
+		return nil, &PasskeyError{"通行密钥数据无效"}
+	}
+
+	credential, err := s.webAuthn.CreateCredential(&passkeyUser{user: user}, *session, parsed)
+	if err != nil {
+		util.Log("Passkey registration failed for user %d: %v", user.ID, err)
+		return nil, &PasskeyError{"通行密钥验证失败"}
+	}
+
+	transports := make([]string, len(credential.Transport))
+	for i, t := range credential.Transport {
+		transports[i] = string(t)
+	}
+
+	return &model.WebAuthnCredential{
+		UserID:          user.ID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}, nil
+}
+
+// BeginLogin starts a username-less passkey sign-in
+func (s *WebAuthnService) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := saveChallenge(ctx, nil, passkeyPurposeLogin, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, token, nil
+}
+
+// FinishLogin verifies a passkey assertion and returns the user it belongs to
+func (s *WebAuthnService) FinishLogin(ctx context.Context, challengeToken string, response io.Reader) (*model.User, error) {
+	_, session, err := consumeChallenge(ctx, challengeToken, passkeyPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	findPasskey := func(credentialID []byte) (*model.WebAuthnCredential, *model.User, error) {
+		c, err := repository.FindWebAuthnCredentialByCredentialID(ctx, credentialID)
+		if err != nil {
+			return nil, nil, err
+		}
+		u, err := repository.FindUserByID(ctx, c.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, u, nil
+	}
+
+	stored, user, credential, err := s.verifyLogin(session, response, findPasskey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.UpdateWebAuthnCredentialUsage(ctx, stored.ID, int64(credential.Authenticator.SignCount), credential.Flags.BackupState)
+	if err != nil {
+		util.Log("Failed to update passkey %d usage: %v", stored.ID, err)
+	}
+
+	return user, nil
+}
+
+// verifyLogin checks a passkey assertion against the stored passkey that
+// findPasskey returns for its credential ID
+func (s *WebAuthnService) verifyLogin(session *webauthn.SessionData, response io.Reader,
+	findPasskey func(credentialID []byte) (*model.WebAuthnCredential, *model.User, error),
+) (*model.WebAuthnCredential, *model.User, *webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(response)
+	if err != nil {
+		return nil, nil, nil, &PasskeyError{"通行密钥数据无效"}
+	}
+
+	var stored *model.WebAuthnCredential
+	var user *model.User
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		c, u, err := findPasskey(rawID)
+		if err != nil {
+			return nil, err
+		}
+		stored, user = c, u
+		return &passkeyUser{user: u, credentials: []model.WebAuthnCredential{*c}}, nil
+	}
+
+	credential, err := s.webAuthn.ValidateDiscoverableLogin(findUser, *session, parsed)
+	if err != nil {
+		return nil, nil, nil, &PasskeyError{"通行密钥验证失败，请确认该通行密钥已在本站注册"}
+	}
+
+	// A signature counter going backwards means the key may have been copied
+	if credential.Authenticator.CloneWarning {
+		util.Log("Passkey %d of user %d failed the signature counter check", stored.ID, user.ID)
+		return nil, nil, nil, &PasskeyError{"该通行密钥存在安全风险，请使用密码登录并重新注册"}
+	}
+
+	return stored, user, credential, nil
+}
+
+// runPasskeyChallengeCleanup removes passkey ceremonies that were never finished
+func runPasskeyChallengeCleanup(ctx context.Context) {
+	count, err := repository.DeleteExpiredWebAuthnChallenges(ctx)
+	if err != nil {
+		fmt.Printf("[Cron] Failed to delete expired passkey challenges: %v\n", err)
+		return
+	}
+	if count > 0 {
+		fmt.Printf("[Cron] Deleted %d expired passkey challenges\n", count)
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"picshare/config"
+	"picshare/model"
+)
+
+const (
+	testRPID   = "picshare.test"
+	testOrigin = "https://www.picshare.test"
+)
+
+// softAuthenticator is an in-memory ES256 authenticator holding one
+// discoverable credential, standing in for a browser and security key
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+	// Whether the authenticator reports that it verified the user
+	userVerified bool
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, credentialID: credentialID, origin: testOrigin, userVerified: true}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony, challenge string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    a.origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// authData builds authenticator data with user presence set
+func (a *softAuthenticator) authData(attestedCredential []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	flags := byte(0x01) // UP
+	if a.userVerified {
+		flags |= 0x04 // UV
+	}
+	if attestedCredential != nil {
+		flags |= 0x40 // AT
+	}
+
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attestedCredential...)
+}
+
+// register answers a registration challenge with "none" attestation
+func (a *softAuthenticator) register(t *testing.T, session *webauthn.SessionData) []byte {
+	t.Helper()
+	a.userHandle = session.UserID
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(attested),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.response(t, map[string]string{
+		"clientDataJSON":    b64(a.clientData(t, "webauthn.create", session.Challenge)),
+		"attestationObject": b64(attestationObject),
+	})
+}
+
+// login answers a sign-in challenge, bumping the signature counter
+func (a *softAuthenticator) login(t *testing.T, session *webauthn.SessionData) []byte {
+	t.Helper()
+	a.signCount++
+
+	clientData := a.clientData(t, "webauthn.get", session.Challenge)
+	authData := a.authData(nil)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.response(t, map[string]string{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) response(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func newTestWebAuthnService(t *testing.T) *WebAuthnService {
+	t.Helper()
+	svc, err := newWebAuthnService(config.WebAuthnConfig{
+		RPID:          testRPID,
+		RPDisplayName: "PicShare",
+		RPOrigins:     []string{testOrigin},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+// registerTestPasskey runs a registration ceremony and returns the stored passkey
+func registerTestPasskey(t *testing.T, svc *WebAuthnService, user *model.User, auth *softAuthenticator) *model.WebAuthnCredential {
+	t.Helper()
+	_, session, err := svc.beginRegistration(user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := svc.verifyRegistration(user, session, bytes.NewReader(auth.register(t, session)))
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	credential.ID = 7
+	return credential
+}
+
+func expectPasskeyError(t *testing.T, err error) {
+	t.Helper()
+	var passkeyErr *PasskeyError
+	if !errors.As(err, &passkeyErr) {
+		t.Fatalf("expected a PasskeyError, got %v", err)
+	}
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	svc := newTestWebAuthnService(t)
+	user := &model.User{ID: 42, Email: "alice@example.com", Name: "Alice"}
+	auth := newSoftAuthenticator(t)
+
+	stored := registerTestPasskey(t, svc, user, auth)
+	if stored.UserID != user.ID || !bytes.Equal(stored.CredentialID, auth.credentialID) {
+		t.Fatalf("unexpected passkey %+v", stored)
+	}
+	if !bytes.Equal(auth.userHandle, passkeyUserHandle(user.ID)) {
+		t.Fatalf("user handle %q, want %q", auth.userHandle, passkeyUserHandle(user.ID))
+	}
+
+	_, session, err := svc.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	findPasskey := func(credentialID []byte) (*model.WebAuthnCredential, *model.User, error) {
+		if !bytes.Equal(credentialID, stored.CredentialID) {
+			return nil, nil, errors.New("unknown passkey")
+		}
+		return stored, user, nil
+	}
+
+	gotPasskey, gotUser, credential, err := svc.verifyLogin(session, bytes.NewReader(auth.login(t, session)), findPasskey)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if gotUser.ID != user.ID || gotPasskey.ID != stored.ID {
+		t.Fatalf("signed in as user %d with passkey %d", gotUser.ID, gotPasskey.ID)
+	}
+	if credential.Authenticator.SignCount != 1 {
+		t.Fatalf("sign count %d, want 1", credential.Authenticator.SignCount)
+	}
+}
+
+func TestPasskeyRegistrationRejectsWrongChallenge(t *testing.T) {
+	svc := newTestWebAuthnService(t)
+	user := &model.User{ID: 42, Email: "alice@example.com", Name: "Alice"}
+	auth := newSoftAuthenticator(t)
+
+	_, answered, err := svc.beginRegistration(user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, session, err := svc.beginRegistration(user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.verifyRegistration(user, session, bytes.NewReader(auth.register(t, answered)))
+	expectPasskeyError(t, err)
+}
+
+func TestPasskeyRegistrationRejectsOtherOrigin(t *testing.T) {
+	svc := newTestWebAuthnService(t)
+	user := &model.User{ID: 42, Email: "alice@example.com", Name: "Alice"}
+	auth := newSoftAuthenticator(t)
+	auth.origin = "https://evil.example"
+
+	_, session, err := svc.beginRegistration(user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.verifyRegistration(user, session, bytes.NewReader(auth.register(t, session)))
+	expectPasskeyError(t, err)
+}
+
+func TestPasskeyLoginRejectsUnregisteredKey(t *testing.T) {
+	svc := newTestWebAuthnService(t)
+	user := &model.User{ID: 42, Email: "alice@example.com", Name: "Alice"}
+	stored := registerTestPasskey(t, svc, user, newSoftAuthenticator(t))
+
+	// Another authenticator claiming the registered credential ID
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialID = stored.CredentialID
+	impostor.userHandle = passkeyUserHandle(user.ID)
+
+	_, session, err := svc.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	findPasskey := func([]byte) (*model.WebAuthnCredential, *model.User, error) {
+		return stored, user, nil
+	}
+
+	_, _, _, err = svc.verifyLogin(session, bytes.NewReader(impostor.login(t, session)), findPasskey)
+	expectPasskeyError(t, err)
+}
+
+func TestPasskeyLoginRejectsClonedKey(t *testing.T) {
+	svc := newTestWebAuthnService(t)
+	user := &model.User{ID: 42, Email: "alice@example.com", Name: "Alice"}
+	auth := newSoftAuthenticator(t)
+	stored := registerTestPasskey(t, svc, user, auth)
+
+	// The server has already seen a higher counter than this copy sends
+	stored.SignCount = 10
+
+	_, session, err := svc.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	findPasskey := func([]byte) (*model.WebAuthnCredential, *model.User, error) {
+		return stored, user, nil
+	}
+
+	_, _, _, err = svc.verifyLogin(session, bytes.NewReader(auth.login(t, session)), findPasskey)
+	expectPasskeyError(t, err)
+}
+
+func TestPasskeyLoginRequiresUserVerification(t *testing.T) {
+	svc := newTestWebAuthnService(t)
+	user := &model.User{ID: 42, Email: "alice@example.com", Name: "Alice"}
+	auth := newSoftAuthenticator(t)
+	stored := registerTestPasskey(t, svc, user, auth)
+
+	// Possession alone, e.g. a security key without a PIN
+	auth.userVerified = false
+
+	_, session, err := svc.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	findPasskey := func([]byte) (*model.WebAuthnCredential, *model.User, error) {
+		return stored, user, nil
+	}
+
+	_, _, _, err = svc.verifyLogin(session, bytes.NewReader(auth.login(t, session)), findPasskey)
+	expectPasskeyError(t, err)
+}
@@ -11,24 +11,39 @@ import (
 
 // JWTClaims represents the JWT claims structure
 type JWTClaims struct {
-	ID    int    `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
-	Name  string `json:"name"`
+	ID        int    `json:"id"`
+	SessionID int    `json:"sid"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Name      string `json:"name"`
+	// ImpersonatedBy is the ID of the admin signed in as this user, 0 otherwise
+	ImpersonatedBy int `json:"impersonatedBy,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT creates a JWT token for a user
-func GenerateJWT(id int, email, role, name string) (string, error) {
+// GenerateJWT creates a short-lived access token for a user session
+func GenerateJWT(id, sessionID int, email, role, name string) (string, error) {
+	return generateAccessToken(id, sessionID, email, role, name, 0, config.Get().JWT.AccessTokenTTL)
+}
+
+// GenerateImpersonationJWT creates an access token that lets an admin act
+// as a user. It is flagged with the admin's ID and cannot be refreshed.
+func GenerateImpersonationJWT(id, sessionID int, email, role, name string, adminID int, validFor time.Duration) (string, error) {
+	return generateAccessToken(id, sessionID, email, role, name, adminID, validFor)
+}
+
+func generateAccessToken(id, sessionID int, email, role, name string, impersonatedBy int, validFor time.Duration) (string, error) {
 	cfg := config.Get()
 
 	claims := JWTClaims{
-		ID:    id,
-		Email: email,
-		Role:  role,
-		Name:  name,
+		ID:             id,
+		SessionID:      sessionID,
+		Email:          email,
+		Role:           role,
+		Name:           name,
+		ImpersonatedBy: impersonatedBy,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(validFor)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
@@ -53,7 +68,112 @@ func VerifyToken(tokenString string) (*JWTClaims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	// ID is checked so that other signed tokens (e.g. album extend links) are not accepted as logins;
+	// SessionID rejects tokens issued before sessions existed, which cannot be revoked
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.ID != 0 && claims.SessionID != 0 {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
+
+// AlbumExtendClaims is carried by the one-click "extend album" link in expiry reminders
+type AlbumExtendClaims struct {
+	AlbumID   int    `json:"albumId"`
+	UserID    int    `json:"userId"`
+	ExpiresAt int64  `json:"albumExpiresAt"` // album expiry the link was issued for
+	Purpose   string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+const albumExtendPurpose = "album_extend"
+
+// GenerateAlbumExtendToken signs an extend link for an album. The token is
+// bound to the album's current expiry, so it stops working once used.
+func GenerateAlbumExtendToken(albumID, userID int, albumExpiresAt time.Time, validFor time.Duration) (string, error) {
+	cfg := config.Get()
+
+	claims := AlbumExtendClaims{
+		AlbumID:   albumID,
+		UserID:    userID,
+		ExpiresAt: albumExpiresAt.Unix(),
+		Purpose:   albumExtendPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(validFor)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.JWT.Secret))
+}
+
+// VerifyAlbumExtendToken validates an extend link token and returns its claims
+func VerifyAlbumExtendToken(tokenString string) (*AlbumExtendClaims, error) {
+	cfg := config.Get()
+
+	token, err := jwt.ParseWithClaims(tokenString, &AlbumExtendClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(cfg.JWT.Secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*AlbumExtendClaims); ok && token.Valid && claims.Purpose == albumExtendPurpose {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
+
+// TwoFactorClaims is carried by the token that links the password step of a
+// login to its second-factor step
+type TwoFactorClaims struct {
+	UserID  int    `json:"userId"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+const twoFactorPurpose = "login_2fa"
+
+// GenerateTwoFactorToken signs a short-lived token proving the user passed
+// the password check
+func GenerateTwoFactorToken(userID int, validFor time.Duration) (string, error) {
+	cfg := config.Get()
+
+	claims := TwoFactorClaims{
+		UserID:  userID,
+		Purpose: twoFactorPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(validFor)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.JWT.Secret))
+}
+
+// VerifyTwoFactorToken validates a second-factor token and returns its claims
+func VerifyTwoFactorToken(tokenString string) (*TwoFactorClaims, error) {
+	cfg := config.Get()
+
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(cfg.JWT.Secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*TwoFactorClaims); ok && token.Valid && claims.Purpose == twoFactorPurpose {
 		return claims, nil
 	}
 
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -20,6 +21,26 @@ func GenerateRandomToken() string {
 	return hex.EncodeToString(b)
 }
 
+// HashToken returns the SHA-256 hex digest of a token, for storing
+// single-use tokens without keeping them in plain text
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix marks personal API keys, telling them apart from login tokens
+const APIKeyPrefix = "psk_"
+
+// GenerateAPIKey generates a personal API key
+func GenerateAPIKey() string {
+	return APIKeyPrefix + GenerateRandomToken()
+}
+
+// IsAPIKey checks if a credential looks like a personal API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
 // GenerateShareCode generates a 16-character hex string (8 bytes)
 func GenerateShareCode() string {
 	b := make([]byte, 8)
@@ -39,6 +60,15 @@ func GetDefaultExpiry() time.Time {
 	return time.Now().Add(24 * time.Hour)
 }
 
+// NeverExpiresAt is the expires_at sentinel stored for albums that never expire.
+// It keeps every expiry query working without special cases.
+var NeverExpiresAt = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// IsNeverExpires checks if an expiry time is the never-expires sentinel
+func IsNeverExpires(t time.Time) bool {
+	return !t.Before(NeverExpiresAt)
+}
+
 // GenerateAlbumTitle generates a default album title based on current time
 func GenerateAlbumTitle() string {
 	return time.Now().Format("2006-01-02 15:04")
@@ -218,33 +218,6 @@ func ParsePagination(pageStr, limitStr string) (page, limit int) {
 	return page, limit
 }
 
-// GetClientIP extracts the client IP from request
-func GetClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (for requests through proxy/load balancer)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP if multiple
-		if i := strings.Index(xff, ","); i != -1 {
-			return strings.TrimSpace(xff[:i])
-		}
-		return xff
-	}
-
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// Fall back to RemoteAddr
-	if ip := r.RemoteAddr; ip != "" {
-		if i := strings.LastIndex(ip, ":"); i != -1 {
-			return ip[:i]
-		}
-		return ip
-	}
-
-	return ""
-}
-
 // GetUserAgent extracts the user agent from request
 func GetUserAgent(r *http.Request) string {
 	return r.Header.Get("User-Agent")
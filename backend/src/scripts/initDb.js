@@ -74,6 +74,440 @@ const SQL_STATEMENTS = [
     FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE
   )`,
 
+  // Album expiry policy (delete, archive or keep after expiry)
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS expiry_policy VARCHAR(20) NOT NULL DEFAULT 'delete'
+    CHECK (expiry_policy IN ('delete', 'archive', 'keep'))`,
+  `ALTER TABLE albums ADD COLUMN IF NOT EXISTS expiry_policy VARCHAR(20) DEFAULT NULL
+    CHECK (expiry_policy IN ('delete', 'archive', 'keep'))`,
+  `ALTER TABLE albums ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP DEFAULT NULL`,
+
+  // Expiry reminders sent to album owners (one per kind per expiry)
+  `CREATE TABLE IF NOT EXISTS album_expiry_reminders (
+    id SERIAL PRIMARY KEY,
+    album_id INTEGER NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (album_id, kind, expires_at),
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE
+  )`,
+
+  // Audit history of album expiry changes
+  `CREATE TABLE IF NOT EXISTS album_expiry_history (
+    id SERIAL PRIMARY KEY,
+    album_id INTEGER NOT NULL,
+    actor_id INTEGER DEFAULT NULL,
+    action VARCHAR(20) NOT NULL,
+    old_expires_at TIMESTAMP DEFAULT NULL,
+    new_expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  // Collections group several albums under one share link
+  `CREATE TABLE IF NOT EXISTS collections (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    share_code VARCHAR(32) NOT NULL UNIQUE,
+    description TEXT DEFAULT NULL,
+    cover_url VARCHAR(500) DEFAULT NULL,
+    view_count INTEGER DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  `CREATE TABLE IF NOT EXISTS collection_albums (
+    collection_id INTEGER NOT NULL,
+    album_id INTEGER NOT NULL,
+    sort_order INTEGER DEFAULT 0,
+    PRIMARY KEY (collection_id, album_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE
+  )`,
+
+  // Album templates hold reusable settings for new albums
+  `CREATE TABLE IF NOT EXISTS album_templates (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    title VARCHAR(255) DEFAULT NULL,
+    description TEXT DEFAULT NULL,
+    expires_in_hours INTEGER DEFAULT NULL,
+    expiry_policy VARCHAR(20) DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Album transfers hand an album over to another user once accepted
+  `CREATE TABLE IF NOT EXISTS album_transfers (
+    id SERIAL PRIMARY KEY,
+    album_id INTEGER NOT NULL,
+    from_user_id INTEGER NOT NULL,
+    to_user_id INTEGER NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    rekey_storage BOOLEAN DEFAULT false,
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Workspaces let several users share ownership of albums
+  `CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_by INTEGER DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  // A workspace outlives its creator; databases created before this kept
+  // created_by NOT NULL with ON DELETE CASCADE
+  `ALTER TABLE workspaces ALTER COLUMN created_by DROP NOT NULL`,
+  `ALTER TABLE workspaces DROP CONSTRAINT IF EXISTS workspaces_created_by_fkey`,
+  `ALTER TABLE workspaces ADD CONSTRAINT workspaces_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL`,
+
+  `CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  `CREATE TABLE IF NOT EXISTS workspace_invites (
+    id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Albums in a workspace return to their creator if the workspace is deleted
+  `ALTER TABLE albums ADD COLUMN IF NOT EXISTS workspace_id INTEGER DEFAULT NULL
+    REFERENCES workspaces(id) ON DELETE SET NULL`,
+
+  // Album collaborators get access to a single album with granular permissions
+  `CREATE TABLE IF NOT EXISTS album_collaborators (
+    album_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    can_upload BOOLEAN DEFAULT false,
+    can_delete BOOLEAN DEFAULT false,
+    can_manage_sharing BOOLEAN DEFAULT false,
+    invited_by INTEGER NOT NULL,
+    token_hash VARCHAR(64) DEFAULT NULL UNIQUE,
+    expires_at TIMESTAMP DEFAULT NULL,
+    accepted_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (album_id, user_id),
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Photos record who uploaded them (the owner or a collaborator)
+  `ALTER TABLE photos ADD COLUMN IF NOT EXISTS uploaded_by INTEGER DEFAULT NULL
+    REFERENCES users(id) ON DELETE SET NULL`,
+
+  // Login sessions, kept alive by rotating refresh tokens
+  `CREATE TABLE IF NOT EXISTS auth_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    ip_address VARCHAR(45) DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    revoked_reason VARCHAR(20) DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Sessions an admin opened to act as the user for support
+  `ALTER TABLE auth_sessions ADD COLUMN IF NOT EXISTS impersonated_by INTEGER DEFAULT NULL
+    REFERENCES users(id) ON DELETE CASCADE`,
+
+  // Refresh tokens (SHA-256 hashes); used ones are kept to detect reuse
+  `CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    used_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES auth_sessions(id) ON DELETE CASCADE
+  )`,
+
+  // Authenticator app (TOTP) enrollment; enabled_at is set once the first code is confirmed
+  `CREATE TABLE IF NOT EXISTS user_totp (
+    user_id INTEGER PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMP DEFAULT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // One-time 2FA recovery codes (SHA-256 hashes)
+  `CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Passkeys (WebAuthn credentials)
+  `CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(32) NOT NULL DEFAULT '',
+    transports TEXT[] NOT NULL DEFAULT '{}',
+    aaguid BYTEA DEFAULT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    backup_eligible BOOLEAN DEFAULT false,
+    backup_state BOOLEAN DEFAULT false,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Pending passkey registrations and sign-ins; each challenge is single-use
+  `CREATE TABLE IF NOT EXISTS webauthn_challenges (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    user_id INTEGER DEFAULT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    session_data JSONB NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Accounts at the OIDC identity provider linked to users
+  `CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    granted_admin BOOLEAN DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // OIDC logins in progress (state, PKCE verifier and nonce); each is single-use
+  `CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id SERIAL PRIMARY KEY,
+    state_hash VARCHAR(64) NOT NULL UNIQUE,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+  )`,
+
+  // Personal API keys for scripts; only the SHA-256 hash of the key is stored
+  `CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    key_prefix VARCHAR(16) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP DEFAULT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    last_used_ip VARCHAR(45) DEFAULT NULL,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Recent failed sign-ins per account (email) and per IP, for progressive
+  // delays and temporary lockouts
+  `CREATE TABLE IF NOT EXISTS login_throttles (
+    id SERIAL PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP DEFAULT NULL,
+    UNIQUE (scope, key)
+  )`,
+
+  // Security log: successful sign-ins and lockouts
+  `CREATE TABLE IF NOT EXISTS security_events (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER DEFAULT NULL,
+    email VARCHAR(255) DEFAULT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    ip_address VARCHAR(45) DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL,
+    details TEXT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  // Magic-link sign-in; only the SHA-256 hash of the link token is stored
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS login_token VARCHAR(64) DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS login_token_expires TIMESTAMP DEFAULT NULL`,
+
+  // Pending email change, confirmed from a link sent to the new address;
+  // only the SHA-256 hash of the link token is stored
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255) DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_token VARCHAR(64) DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_expires TIMESTAMP DEFAULT NULL`,
+
+  // Data exports requested by users; the ZIP stays in OSS until expires_at
+  `CREATE TABLE IF NOT EXISTS data_exports (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    oss_key VARCHAR(500) DEFAULT NULL,
+    file_size BIGINT DEFAULT NULL,
+    error TEXT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP DEFAULT NULL,
+    expires_at TIMESTAMP DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+  )`,
+
+  // Self-service account deletion; the account and all its files are
+  // purged once deletion_scheduled_at has passed
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP DEFAULT NULL`,
+
+  // Admin account controls: suspension, and per-user limits overriding
+  // the global defaults (NULL uses the default)
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason TEXT DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS max_albums INTEGER DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS max_photos_per_album INTEGER DEFAULT NULL`,
+  `ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_quota_bytes BIGINT DEFAULT NULL`,
+
+  // Admin actions are recorded in the hash-chained audit_events table
+  // below; the separate admin log it replaced is retired
+  `DROP TABLE IF EXISTS admin_audit_logs`,
+
+  // Moderation: admin takedowns of public albums, photos hidden from the
+  // public view, and abuse reports from visitors feeding the review queue
+  `CREATE TABLE IF NOT EXISTS album_takedowns (
+    album_id INTEGER PRIMARY KEY,
+    reason TEXT NOT NULL,
+    admin_id INTEGER DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  `ALTER TABLE photos ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP DEFAULT NULL`,
+  `ALTER TABLE photos ADD COLUMN IF NOT EXISTS hidden_reason TEXT DEFAULT NULL`,
+
+  `CREATE TABLE IF NOT EXISTS album_reports (
+    id SERIAL PRIMARY KEY,
+    album_id INTEGER NOT NULL,
+    photo_id INTEGER DEFAULT NULL,
+    reason TEXT NOT NULL,
+    reporter_ip VARCHAR(45) DEFAULT NULL,
+    reporter_user_agent TEXT DEFAULT NULL,
+    status VARCHAR(20) DEFAULT 'open',
+    resolved_by INTEGER DEFAULT NULL,
+    resolved_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE,
+    FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE SET NULL,
+    FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  // Report categories; takedowns without an admin were made automatically
+  // once enough visitors reported an album
+  `ALTER TABLE album_reports ADD COLUMN IF NOT EXISTS category VARCHAR(20) NOT NULL DEFAULT 'other'`,
+
+  // Signed-in reporters; only their reports count toward hiding content
+  // without an admin, since anonymous ones can come from anywhere
+  `ALTER TABLE album_reports ADD COLUMN IF NOT EXISTS reporter_id INTEGER DEFAULT NULL
+    REFERENCES users(id) ON DELETE SET NULL`,
+
+  // Feedback from users, kept so it survives mail outages and can be
+  // triaged; images link the uploaded OSS objects so they can be removed
+  `CREATE TABLE IF NOT EXISTS feedback (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER DEFAULT NULL,
+    user_name VARCHAR(100) DEFAULT NULL,
+    content TEXT NOT NULL,
+    contact VARCHAR(100) DEFAULT NULL,
+    status VARCHAR(20) DEFAULT 'new',
+    admin_note TEXT DEFAULT NULL,
+    ip_address VARCHAR(45) DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL,
+    resolved_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  `CREATE TABLE IF NOT EXISTS feedback_images (
+    id SERIAL PRIMARY KEY,
+    feedback_id INTEGER NOT NULL,
+    oss_key VARCHAR(500) NOT NULL,
+    url VARCHAR(1000) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (feedback_id) REFERENCES feedback(id) ON DELETE CASCADE
+  )`,
+
+  `CREATE TABLE IF NOT EXISTS feedback_replies (
+    id SERIAL PRIMARY KEY,
+    feedback_id INTEGER NOT NULL,
+    admin_id INTEGER DEFAULT NULL,
+    message TEXT NOT NULL,
+    sent_to VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (feedback_id) REFERENCES feedback(id) ON DELETE CASCADE,
+    FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE SET NULL
+  )`,
+
+  // Tamper-evident audit trail of security and admin events. Rows are never
+  // changed: each hash covers the row and the previous hash, and actors are
+  // plain IDs so deleting a user does not rewrite history
+  `CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER DEFAULT NULL,
+    actor_email VARCHAR(255) DEFAULT NULL,
+    impersonated_by INTEGER DEFAULT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INTEGER NOT NULL,
+    details TEXT DEFAULT NULL,
+    ip_address VARCHAR(45) DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL
+  )`,
+
   // Create indexes
   `CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)`,
   `CREATE INDEX IF NOT EXISTS idx_users_role ON users(role)`,
@@ -86,6 +520,36 @@ const SQL_STATEMENTS = [
   `CREATE INDEX IF NOT EXISTS idx_album_access_logs_album_id ON album_access_logs(album_id)`,
   `CREATE INDEX IF NOT EXISTS idx_album_access_logs_action ON album_access_logs(action)`,
   `CREATE INDEX IF NOT EXISTS idx_album_access_logs_created_at ON album_access_logs(created_at)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_expiry_history_album_id ON album_expiry_history(album_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_collections_user_id ON collections(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_collections_share_code ON collections(share_code)`,
+  `CREATE INDEX IF NOT EXISTS idx_collection_albums_album_id ON collection_albums(album_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_transfers_album_id ON album_transfers(album_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_transfers_to_user_id ON album_transfers(to_user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_transfers_from_user_id ON album_transfers(from_user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_albums_workspace_id ON albums(workspace_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_workspace_invites_workspace_id ON workspace_invites(workspace_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_collaborators_user_id ON album_collaborators(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_id ON auth_sessions(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events(created_at)`,
+  `CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_reports_status ON album_reports(status, created_at)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_reports_album_id ON album_reports(album_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_album_reports_reporter ON album_reports(album_id, reporter_ip)`,
+  `CREATE INDEX IF NOT EXISTS idx_feedback_status ON feedback(status, created_at)`,
+  `CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at)`,
+  `CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_feedback_images_feedback_id ON feedback_images(feedback_id)`,
+  `CREATE INDEX IF NOT EXISTS idx_feedback_replies_feedback_id ON feedback_replies(feedback_id)`,
 
   // Create function to update updated_at timestamp
   `CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -104,6 +568,38 @@ const SQL_STATEMENTS = [
   `DROP TRIGGER IF EXISTS update_albums_updated_at ON albums;
    CREATE TRIGGER update_albums_updated_at BEFORE UPDATE ON albums
    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column()`,
+
+  `DROP TRIGGER IF EXISTS update_collections_updated_at ON collections;
+   CREATE TRIGGER update_collections_updated_at BEFORE UPDATE ON collections
+   FOR EACH ROW EXECUTE FUNCTION update_updated_at_column()`,
+
+  `DROP TRIGGER IF EXISTS update_album_templates_updated_at ON album_templates;
+   CREATE TRIGGER update_album_templates_updated_at BEFORE UPDATE ON album_templates
+   FOR EACH ROW EXECUTE FUNCTION update_updated_at_column()`,
+
+  `DROP TRIGGER IF EXISTS update_workspaces_updated_at ON workspaces;
+   CREATE TRIGGER update_workspaces_updated_at BEFORE UPDATE ON workspaces
+   FOR EACH ROW EXECUTE FUNCTION update_updated_at_column()`,
+
+  `DROP TRIGGER IF EXISTS update_feedback_updated_at ON feedback;
+   CREATE TRIGGER update_feedback_updated_at BEFORE UPDATE ON feedback
+   FOR EACH ROW EXECUTE FUNCTION update_updated_at_column()`,
+
+  // audit_events is append-only
+  `CREATE OR REPLACE FUNCTION reject_audit_event_change()
+  RETURNS TRIGGER AS $$
+  BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+  END;
+  $$ language 'plpgsql'`,
+
+  `DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+   CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+   FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change()`,
+
+  `DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+   CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+   FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_event_change()`,
 ];
 
 async function initDatabase() {
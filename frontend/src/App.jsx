@@ -8,6 +8,13 @@ import RegisterPage from './pages/RegisterPage';
 import VerifyEmailPage from './pages/VerifyEmailPage';
 import ForgotPasswordPage from './pages/ForgotPasswordPage';
 import ResetPasswordPage from './pages/ResetPasswordPage';
+import OIDCCallbackPage from './pages/OIDCCallbackPage';
+import MagicLinkPage from './pages/MagicLinkPage';
+import ConfirmEmailChangePage from './pages/ConfirmEmailChangePage';
+import ExtendAlbumPage from './pages/ExtendAlbumPage';
+import AlbumTransferPage from './pages/AlbumTransferPage';
+import WorkspaceInvitePage from './pages/WorkspaceInvitePage';
+import AlbumInvitePage from './pages/AlbumInvitePage';
 import ProfilePage from './pages/ProfilePage';
 import DashboardPage from './pages/DashboardPage';
 import AlbumDetailPage from './pages/AlbumDetailPage';
@@ -27,6 +34,13 @@ function AppRoutes() {
       <Route path="/verify-email" element={<VerifyEmailPage />} />
       <Route path="/forgot-password" element={user ? <Navigate to="/dashboard" /> : <ForgotPasswordPage />} />
       <Route path="/reset-password" element={user ? <Navigate to="/dashboard" /> : <ResetPasswordPage />} />
+      <Route path="/magic-link" element={<MagicLinkPage />} />
+      <Route path="/confirm-email-change" element={<ConfirmEmailChangePage />} />
+      <Route path="/auth/oidc/callback" element={<OIDCCallbackPage />} />
+      <Route path="/extend-album" element={<ExtendAlbumPage />} />
+      <Route path="/album-transfer" element={<AlbumTransferPage />} />
+      <Route path="/workspace-invite" element={<WorkspaceInvitePage />} />
+      <Route path="/album-invite" element={<AlbumInvitePage />} />
       <Route path="/feedback" element={<FeedbackPage />} />
       <Route path="/s/:shareCode" element={<PublicAlbumPage />} />
 
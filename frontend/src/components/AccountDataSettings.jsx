@@ -0,0 +1,142 @@
+import { useState } from 'react';
+import { useAuth } from '../contexts/AuthContext';
+import { authAPI } from '../utils/api';
+import { Download, Trash2, AlertTriangle } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+export default function AccountDataSettings() {
+  const { user, refreshProfile } = useAuth();
+  const [exportLoading, setExportLoading] = useState(false);
+  const [showDelete, setShowDelete] = useState(false);
+  const [password, setPassword] = useState('');
+  const [deleteLoading, setDeleteLoading] = useState(false);
+
+  const handleExport = async () => {
+    setExportLoading(true);
+    try {
+      const res = await authAPI.exportData();
+      const { export: dataExport } = res.data;
+      if (dataExport.status === 'ready' && dataExport.downloadUrl) {
+        window.location.href = dataExport.downloadUrl;
+      } else {
+        toast.success(res.data.message, { duration: 5000 });
+      }
+    } catch (err) {
+      toast.error(err.response?.data?.error || '导出数据失败');
+    } finally {
+      setExportLoading(false);
+    }
+  };
+
+  const handleDelete = async (e) => {
+    e.preventDefault();
+    if (!password) {
+      toast.error('请输入密码以确认注销');
+      return;
+    }
+
+    setDeleteLoading(true);
+    try {
+      const res = await authAPI.deleteAccount({ password });
+      toast.success(res.data.message, { duration: 5000 });
+      setPassword('');
+      setShowDelete(false);
+      refreshProfile();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '注销失败');
+    } finally {
+      setDeleteLoading(false);
+    }
+  };
+
+  const handleRestore = async () => {
+    try {
+      const res = await authAPI.restoreAccount();
+      toast.success(res.data.message);
+      refreshProfile();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '撤销注销失败');
+    }
+  };
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 p-6">
+      <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center">
+        <Download className="w-5 h-5 mr-2 text-indigo-600" />
+        数据与账号
+      </h2>
+
+      <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-3 pb-5 border-b border-gray-100">
+        <p className="text-sm text-gray-600">
+          导出账号资料、全部相册与照片信息、访问记录以及照片原图。打包完成后，下载链接会发送到您的邮箱。
+        </p>
+        <button
+          onClick={handleExport}
+          disabled={exportLoading}
+          className="px-4 py-2 text-sm font-medium text-indigo-600 bg-indigo-50 rounded-xl hover:bg-indigo-100 transition-colors whitespace-nowrap disabled:opacity-50"
+        >
+          {exportLoading ? '请求中...' : '导出我的数据'}
+        </button>
+      </div>
+
+      <div className="pt-5">
+        {user.deletionScheduledAt ? (
+          <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-3 p-4 bg-red-50 rounded-xl">
+            <p className="text-sm text-red-700 flex items-start">
+              <AlertTriangle className="w-4 h-4 mr-1.5 mt-0.5 flex-shrink-0" />
+              账号将于 {new Date(user.deletionScheduledAt).toLocaleString('zh-CN')} 被永久删除，所有相册和照片都将无法恢复。
+            </p>
+            <button
+              onClick={handleRestore}
+              className="px-4 py-2 text-sm font-medium text-gray-700 bg-white rounded-xl hover:bg-gray-100 transition-colors whitespace-nowrap"
+            >
+              撤销注销
+            </button>
+          </div>
+        ) : showDelete ? (
+          <form onSubmit={handleDelete} className="space-y-3">
+            <p className="text-sm text-red-600">
+              注销后账号会在宽限期结束时永久删除，包括全部个人相册、照片和头像，工作区中的相册会转交给其他成员。宽限期内可以随时撤销，其他设备会立即退出登录。
+            </p>
+            <div className="flex flex-col sm:flex-row gap-3">
+              <input
+                type="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                placeholder="当前密码"
+                autoComplete="current-password"
+                className="flex-1 px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-red-500 focus:border-transparent outline-none transition-all"
+                style={{ fontSize: '16px' }}
+              />
+              <button
+                type="submit"
+                disabled={deleteLoading}
+                className="inline-flex items-center justify-center px-6 py-2.5 bg-red-600 text-white rounded-xl font-medium hover:bg-red-700 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                {deleteLoading ? '提交中...' : '确认注销'}
+              </button>
+              <button
+                type="button"
+                onClick={() => { setShowDelete(false); setPassword(''); }}
+                className="px-4 py-2.5 text-sm font-medium text-gray-700 bg-gray-100 rounded-xl hover:bg-gray-200 transition-colors"
+              >
+                取消
+              </button>
+            </div>
+          </form>
+        ) : (
+          <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-3">
+            <p className="text-sm text-gray-600">永久删除账号以及其中的全部相册和照片。</p>
+            <button
+              onClick={() => setShowDelete(true)}
+              className="inline-flex items-center px-4 py-2 text-sm font-medium text-red-600 bg-red-50 rounded-xl hover:bg-red-100 transition-colors whitespace-nowrap"
+            >
+              <Trash2 className="w-4 h-4 mr-1.5" />
+              注销账号
+            </button>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
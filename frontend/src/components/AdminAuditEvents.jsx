@@ -0,0 +1,261 @@
+import { useState, useEffect, useCallback } from 'react';
+import { adminAPI } from '../utils/api';
+import { ShieldCheck, ShieldAlert, Download, Search, Fingerprint } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const ACTION_LABELS = {
+  login: '登录',
+  logout: '退出登录',
+  password_change: '修改密码',
+  password_reset: '重置密码',
+  email_change: '更改邮箱',
+  two_factor_enable: '启用两步验证',
+  two_factor_disable: '关闭两步验证',
+  api_key_create: '创建 API 密钥',
+  api_key_revoke: '撤销 API 密钥',
+  account_deletion_request: '申请注销账号',
+  album_create: '创建影集',
+  album_delete: '删除影集',
+  photo_upload: '上传照片',
+  photo_delete: '删除照片',
+  view_user_albums: '查看用户影集',
+  view_album_logs: '查看影集访问日志',
+  view_album_photos: '查看影集照片',
+  export_audit_events: '导出审计日志',
+  impersonate_user: '模拟登录',
+  end_impersonation: '结束模拟登录',
+  suspend_user: '停用账号',
+  unsuspend_user: '恢复账号',
+  change_role: '修改角色',
+  set_quota: '设置存储配额',
+  force_password_reset: '强制重置密码',
+  delete_user: '删除用户',
+  takedown_album: '下架影集',
+  restore_album: '恢复影集',
+  hide_photo: '隐藏照片',
+  unhide_photo: '取消隐藏照片',
+  delete_photo: '管理员删除照片',
+  review_report: '处理举报',
+  update_feedback: '更新反馈状态',
+  reply_feedback: '回复反馈',
+  delete_feedback: '删除反馈',
+};
+
+const EMPTY_FILTERS = { actorId: '', action: '', targetType: '', targetId: '', ip: '', from: '', to: '' };
+
+// Tamper-evident audit trail with filters, CSV export and a chain check
+export default function AdminAuditEvents() {
+  const [filters, setFilters] = useState(EMPTY_FILTERS);
+  const [applied, setApplied] = useState(EMPTY_FILTERS);
+  const [page, setPage] = useState(1);
+  const [events, setEvents] = useState([]);
+  const [pagination, setPagination] = useState(null);
+  const [loading, setLoading] = useState(true);
+  const [verifying, setVerifying] = useState(false);
+  const [report, setReport] = useState(null);
+
+  const fetchEvents = useCallback(async () => {
+    try {
+      const res = await adminAPI.getAuditEvents(page, applied);
+      setEvents(res.data.events);
+      setPagination(res.data.pagination);
+    } catch (err) {
+      toast.error(err.response?.data?.error || '获取审计日志失败');
+    } finally {
+      setLoading(false);
+    }
+  }, [page, applied]);
+
+  useEffect(() => {
+    fetchEvents();
+  }, [fetchEvents]);
+
+  const handleSearch = (e) => {
+    e.preventDefault();
+    setPage(1);
+    setApplied(filters);
+  };
+
+  const handleExport = async () => {
+    try {
+      const res = await adminAPI.exportAuditEvents(applied);
+      const url = URL.createObjectURL(res.data);
+      const link = document.createElement('a');
+      link.href = url;
+      link.download = `audit-events-${new Date().toISOString().slice(0, 10)}.csv`;
+      link.click();
+      URL.revokeObjectURL(url);
+    } catch {
+      toast.error('导出审计日志失败');
+    }
+  };
+
+  const handleVerify = async () => {
+    setVerifying(true);
+    try {
+      const res = await adminAPI.verifyAuditEvents();
+      setReport(res.data);
+    } catch (err) {
+      toast.error(err.response?.data?.error || '校验审计日志失败');
+    } finally {
+      setVerifying(false);
+    }
+  };
+
+  const inputClass = 'px-2 py-1.5 text-xs border border-gray-200 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none';
+  const buttonClass = 'inline-flex items-center px-3 py-1.5 text-xs font-medium rounded-lg transition-colors disabled:opacity-50';
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 overflow-hidden">
+      <form onSubmit={handleSearch} className="flex flex-wrap items-center gap-2 p-4 border-b border-gray-100">
+        <input
+          type="number"
+          min="1"
+          placeholder="用户ID"
+          value={filters.actorId}
+          onChange={(e) => setFilters({ ...filters, actorId: e.target.value })}
+          className={`${inputClass} w-24`}
+        />
+        <select
+          value={filters.action}
+          onChange={(e) => setFilters({ ...filters, action: e.target.value })}
+          className={inputClass}
+        >
+          <option value="">全部操作</option>
+          {Object.entries(ACTION_LABELS).map(([key, label]) => (
+            <option key={key} value={key}>{label}</option>
+          ))}
+        </select>
+        <select
+          value={filters.targetType}
+          onChange={(e) => setFilters({ ...filters, targetType: e.target.value })}
+          className={inputClass}
+        >
+          <option value="">全部对象</option>
+          <option value="user">用户</option>
+          <option value="album">影集</option>
+          <option value="photo">照片</option>
+          <option value="api_key">API 密钥</option>
+          <option value="report">举报</option>
+          <option value="feedback">反馈</option>
+        </select>
+        <input
+          type="number"
+          min="1"
+          placeholder="对象ID"
+          value={filters.targetId}
+          onChange={(e) => setFilters({ ...filters, targetId: e.target.value })}
+          className={`${inputClass} w-24`}
+        />
+        <input
+          placeholder="IP 地址"
+          value={filters.ip}
+          onChange={(e) => setFilters({ ...filters, ip: e.target.value })}
+          className={`${inputClass} w-32`}
+        />
+        <input
+          type="date"
+          value={filters.from}
+          onChange={(e) => setFilters({ ...filters, from: e.target.value })}
+          className={inputClass}
+        />
+        <span className="text-xs text-gray-400">至</span>
+        <input
+          type="date"
+          value={filters.to}
+          onChange={(e) => setFilters({ ...filters, to: e.target.value })}
+          className={inputClass}
+        />
+        <button type="submit" className={`${buttonClass} bg-indigo-50 text-indigo-600 hover:bg-indigo-100`}>
+          <Search className="w-3.5 h-3.5 mr-1" />
+          查询
+        </button>
+        <div className="ml-auto flex gap-2">
+          <button type="button" onClick={handleExport} className={`${buttonClass} bg-gray-100 text-gray-600 hover:bg-gray-200`}>
+            <Download className="w-3.5 h-3.5 mr-1" />
+            导出 CSV
+          </button>
+          <button
+            type="button"
+            disabled={verifying}
+            onClick={handleVerify}
+            className={`${buttonClass} bg-gray-100 text-gray-600 hover:bg-gray-200`}
+          >
+            <Fingerprint className="w-3.5 h-3.5 mr-1" />
+            {verifying ? '校验中...' : '校验完整性'}
+          </button>
+        </div>
+      </form>
+
+      {report && (
+        <div className={`flex items-center gap-2 px-4 py-3 text-sm ${report.valid ? 'bg-green-50 text-green-700' : 'bg-red-50 text-red-700'}`}>
+          {report.valid ? <ShieldCheck className="w-4 h-4" /> : <ShieldAlert className="w-4 h-4" />}
+          {report.valid
+            ? `已校验 ${report.checked} 条记录，哈希链完整`
+            : `记录 #${report.brokenAt} 校验失败：${report.reason}`}
+        </div>
+      )}
+
+      <div className="overflow-x-auto">
+        <table className="w-full">
+          <thead className="bg-gray-50 border-b border-gray-100">
+            <tr>
+              <th className="text-left px-4 py-3 text-xs font-medium text-gray-500 uppercase">时间</th>
+              <th className="text-left px-4 py-3 text-xs font-medium text-gray-500 uppercase">操作者</th>
+              <th className="text-left px-4 py-3 text-xs font-medium text-gray-500 uppercase">操作</th>
+              <th className="text-left px-4 py-3 text-xs font-medium text-gray-500 uppercase">对象</th>
+              <th className="text-left px-4 py-3 text-xs font-medium text-gray-500 uppercase hidden md:table-cell">IP</th>
+              <th className="text-left px-4 py-3 text-xs font-medium text-gray-500 uppercase hidden lg:table-cell">详情</th>
+            </tr>
+          </thead>
+          <tbody className="divide-y divide-gray-50">
+            {events.map((event) => (
+              <tr key={event.id}>
+                <td className="px-4 py-3 text-xs text-gray-500 whitespace-nowrap">
+                  {new Date(event.createdAt).toLocaleString('zh-CN')}
+                </td>
+                <td className="px-4 py-3 text-sm text-gray-600">
+                  {event.actorEmail || (event.actorId ? `#${event.actorId}` : '系统')}
+                  {event.impersonatedBy && (
+                    <span className="block text-xs text-amber-600">管理员 #{event.impersonatedBy} 模拟</span>
+                  )}
+                </td>
+                <td className="px-4 py-3 text-sm text-gray-900">{ACTION_LABELS[event.action] || event.action}</td>
+                <td className="px-4 py-3 text-sm text-gray-600">{event.targetType} #{event.targetId}</td>
+                <td className="px-4 py-3 text-xs text-gray-400 hidden md:table-cell">{event.ipAddress}</td>
+                <td className="px-4 py-3 text-xs text-gray-400 hidden lg:table-cell break-all">{event.details}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+
+      {!loading && events.length === 0 && (
+        <div className="text-center py-10">
+          <Fingerprint className="w-12 h-12 text-gray-300 mx-auto mb-3" />
+          <p className="text-gray-500">暂无审计记录</p>
+        </div>
+      )}
+
+      {pagination && pagination.totalPages > 1 && (
+        <div className="flex items-center justify-center gap-3 p-4 border-t border-gray-100 text-sm">
+          <button
+            disabled={page <= 1}
+            onClick={() => setPage(page - 1)}
+            className="px-3 py-1 rounded-lg text-gray-600 hover:bg-gray-100 disabled:opacity-40"
+          >
+            上一页
+          </button>
+          <span className="text-gray-500">{page} / {pagination.totalPages}</span>
+          <button
+            disabled={page >= pagination.totalPages}
+            onClick={() => setPage(page + 1)}
+            className="px-3 py-1 rounded-lg text-gray-600 hover:bg-gray-100 disabled:opacity-40"
+          >
+            下一页
+          </button>
+        </div>
+      )}
+    </div>
+  );
+}
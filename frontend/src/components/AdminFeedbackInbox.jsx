@@ -0,0 +1,230 @@
+import { useState, useEffect, useCallback } from 'react';
+import { adminAPI } from '../utils/api';
+import { MessageSquare, Send, Trash2, X } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const STATUS_LABELS = {
+  new: '新反馈',
+  triaged: '处理中',
+  resolved: '已解决',
+};
+
+// Feedback sent by users, with triage, email replies and deletion
+export default function AdminFeedbackInbox() {
+  const [status, setStatus] = useState('new');
+  const [feedback, setFeedback] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [selected, setSelected] = useState(null);
+  const [note, setNote] = useState('');
+  const [reply, setReply] = useState('');
+  const [busy, setBusy] = useState(false);
+
+  const fetchFeedback = useCallback(async () => {
+    try {
+      const res = await adminAPI.getFeedback(1, status);
+      setFeedback(res.data.feedback);
+    } catch {
+      toast.error('获取反馈列表失败');
+    } finally {
+      setLoading(false);
+    }
+  }, [status]);
+
+  useEffect(() => {
+    fetchFeedback();
+  }, [fetchFeedback]);
+
+  const openDetail = async (id) => {
+    try {
+      const res = await adminAPI.getFeedbackDetail(id);
+      setSelected(res.data.feedback);
+      setNote(res.data.feedback.adminNote || '');
+      setReply('');
+    } catch (err) {
+      toast.error(err.response?.data?.error || '获取反馈详情失败');
+    }
+  };
+
+  const run = async (action) => {
+    setBusy(true);
+    try {
+      const res = await action();
+      toast.success(res.data.message);
+      fetchFeedback();
+      return true;
+    } catch (err) {
+      toast.error(err.response?.data?.error || '操作失败');
+      return false;
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const handleStatus = async (newStatus) => {
+    if (await run(() => adminAPI.updateFeedback(selected.id, { status: newStatus, adminNote: note }))) {
+      openDetail(selected.id);
+    }
+  };
+
+  const handleReply = async () => {
+    if (!reply.trim()) {
+      toast.error('请填写回复内容');
+      return;
+    }
+    if (await run(() => adminAPI.replyFeedback(selected.id, { message: reply.trim() }))) {
+      openDetail(selected.id);
+    }
+  };
+
+  const handleDelete = async () => {
+    if (!window.confirm('删除这条反馈及其图片？此操作无法撤销。')) return;
+    if (await run(() => adminAPI.deleteFeedback(selected.id))) {
+      setSelected(null);
+    }
+  };
+
+  const buttonClass = 'inline-flex items-center px-3 py-1.5 text-xs font-medium rounded-lg transition-colors disabled:opacity-50';
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 overflow-hidden">
+      <div className="flex items-center gap-2 p-4 border-b border-gray-100">
+        {['new', 'triaged', 'resolved', ''].map((key) => (
+          <button
+            key={key || 'all'}
+            onClick={() => setStatus(key)}
+            className={`px-3 py-1.5 text-xs font-medium rounded-lg ${
+              status === key ? 'bg-indigo-50 text-indigo-600' : 'text-gray-500 hover:bg-gray-50'
+            }`}
+          >
+            {STATUS_LABELS[key] || '全部'}
+          </button>
+        ))}
+      </div>
+
+      <div className="divide-y divide-gray-50">
+        {feedback.map((item) => (
+          <button
+            key={item.id}
+            onClick={() => openDetail(item.id)}
+            className="w-full text-left p-4 hover:bg-gray-50"
+          >
+            <div className="flex flex-wrap items-center gap-2">
+              <span className="text-sm font-medium text-gray-900">{item.userName || '访客'}</span>
+              {item.userEmail && <span className="text-xs text-gray-400">{item.userEmail}</span>}
+              <span className="text-xs px-2 py-0.5 rounded-full bg-gray-100 text-gray-600">
+                {STATUS_LABELS[item.status]}
+              </span>
+              {item.imageCount > 0 && <span className="text-xs text-gray-400">{item.imageCount} 张图片</span>}
+              {item.replyCount > 0 && <span className="text-xs text-green-600">已回复 {item.replyCount} 次</span>}
+              <span className="ml-auto text-xs text-gray-400">{new Date(item.createdAt).toLocaleString('zh-CN')}</span>
+            </div>
+            <p className="text-sm text-gray-700 mt-1 line-clamp-2 break-words">{item.content}</p>
+          </button>
+        ))}
+      </div>
+
+      {!loading && feedback.length === 0 && (
+        <div className="text-center py-10">
+          <MessageSquare className="w-12 h-12 text-gray-300 mx-auto mb-3" />
+          <p className="text-gray-500">暂无反馈</p>
+        </div>
+      )}
+
+      {selected && (
+        <div className="fixed inset-0 bg-black/50 flex items-center justify-center z-50 p-4" onClick={() => setSelected(null)}>
+          <div
+            onClick={(e) => e.stopPropagation()}
+            className="bg-white rounded-2xl w-full max-w-lg max-h-[90vh] overflow-y-auto p-6"
+          >
+            <div className="flex items-center justify-between mb-4">
+              <h3 className="text-lg font-semibold text-gray-900">反馈 #{selected.id}</h3>
+              <button onClick={() => setSelected(null)} className="p-1 text-gray-400 hover:text-gray-600">
+                <X className="w-5 h-5" />
+              </button>
+            </div>
+            <p className="text-xs text-gray-400 mb-2">
+              {selected.userName || '访客'}
+              {selected.userEmail && ` · ${selected.userEmail}`}
+              {selected.contact && ` · 联系方式：${selected.contact}`}
+              {' · '}{new Date(selected.createdAt).toLocaleString('zh-CN')}
+            </p>
+            <p className="text-sm text-gray-800 whitespace-pre-wrap break-words">{selected.content}</p>
+
+            {selected.images?.length > 0 && (
+              <div className="flex flex-wrap gap-2 mt-3">
+                {selected.images.map((img) => (
+                  <a key={img.id} href={img.url} target="_blank" rel="noreferrer">
+                    <img src={img.url} alt="" className="w-20 h-20 object-cover rounded-lg" />
+                  </a>
+                ))}
+              </div>
+            )}
+
+            <div className="flex flex-wrap gap-2 mt-4">
+              {Object.entries(STATUS_LABELS).map(([key, label]) => (
+                <button
+                  key={key}
+                  disabled={busy}
+                  onClick={() => handleStatus(key)}
+                  className={`${buttonClass} ${
+                    selected.status === key ? 'bg-indigo-600 text-white' : 'bg-gray-100 text-gray-600 hover:bg-gray-200'
+                  }`}
+                >
+                  {label}
+                </button>
+              ))}
+              <button
+                disabled={busy}
+                onClick={handleDelete}
+                className={`${buttonClass} ml-auto bg-red-50 text-red-600 hover:bg-red-100`}
+              >
+                <Trash2 className="w-3.5 h-3.5 mr-1" />
+                删除
+              </button>
+            </div>
+            <textarea
+              value={note}
+              onChange={(e) => setNote(e.target.value)}
+              maxLength={1000}
+              rows={2}
+              placeholder="内部备注（用户不可见，修改状态时一并保存）"
+              className="w-full mt-3 px-3 py-2 border border-gray-200 rounded-lg text-sm focus:outline-none focus:ring-2 focus:ring-indigo-500"
+            />
+
+            {selected.replies?.length > 0 && (
+              <div className="mt-4 space-y-2">
+                {selected.replies.map((r) => (
+                  <div key={r.id} className="p-3 bg-green-50 rounded-lg">
+                    <p className="text-xs text-gray-500 mb-1">
+                      {r.adminName || '管理员'} 回复至 {r.sentTo} · {new Date(r.createdAt).toLocaleString('zh-CN')}
+                    </p>
+                    <p className="text-sm text-gray-800 whitespace-pre-wrap break-words">{r.message}</p>
+                  </div>
+                ))}
+              </div>
+            )}
+
+            <textarea
+              value={reply}
+              onChange={(e) => setReply(e.target.value)}
+              maxLength={2000}
+              rows={4}
+              placeholder="通过邮件回复用户"
+              className="w-full mt-4 px-3 py-2 border border-gray-200 rounded-lg text-sm focus:outline-none focus:ring-2 focus:ring-indigo-500"
+            />
+            <div className="flex justify-end mt-2">
+              <button
+                disabled={busy}
+                onClick={handleReply}
+                className="inline-flex items-center px-4 py-2 text-sm text-white bg-indigo-600 hover:bg-indigo-700 rounded-lg disabled:opacity-50"
+              >
+                <Send className="w-4 h-4 mr-1" />
+                发送回复
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
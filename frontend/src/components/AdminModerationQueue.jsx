@@ -0,0 +1,291 @@
+import { useState, useEffect, useCallback } from 'react';
+import { adminAPI } from '../utils/api';
+import { Flag, Ban, RotateCcw, EyeOff, Trash2, CheckCircle, XCircle } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const STATUS_LABELS = {
+  open: '待处理',
+  resolved: '已处理',
+  dismissed: '已忽略',
+};
+
+const CATEGORY_LABELS = {
+  copyright: '版权',
+  explicit: '不适宜内容',
+  spam: '垃圾广告',
+  other: '其他',
+};
+
+// Abuse reports from visitors, with the moderation actions an admin can take
+export default function AdminModerationQueue() {
+  const [status, setStatus] = useState('open');
+  const [category, setCategory] = useState('');
+  const [byAlbum, setByAlbum] = useState(false);
+  const [reports, setReports] = useState([]);
+  const [reportedAlbums, setReportedAlbums] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [busy, setBusy] = useState(false);
+
+  const fetchReports = useCallback(async () => {
+    try {
+      if (byAlbum) {
+        const res = await adminAPI.getReportedAlbums(1, status === 'open' ? 'open' : 'all');
+        setReportedAlbums(res.data.albums);
+      } else {
+        const res = await adminAPI.getReports(1, status, category);
+        setReports(res.data.reports);
+      }
+    } catch {
+      toast.error('获取举报列表失败');
+    } finally {
+      setLoading(false);
+    }
+  }, [status, category, byAlbum]);
+
+  useEffect(() => {
+    fetchReports();
+  }, [fetchReports]);
+
+  const run = async (action, confirmText) => {
+    if (confirmText && !window.confirm(confirmText)) return;
+    setBusy(true);
+    try {
+      const res = await action();
+      toast.success(res.data.message);
+      fetchReports();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '操作失败');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const handleTakedown = (report) => {
+    const reason = window.prompt(`下架影集「${report.albumTitle}」？访客将看到以下原因：`);
+    if (!reason) return;
+    run(() => adminAPI.takedownAlbum(report.albumId, { reason }));
+  };
+
+  const handleHidePhoto = (report) => {
+    const reason = window.prompt('隐藏这张照片？可填写原因（将通知影集所有者）：');
+    if (reason === null) return;
+    run(() => adminAPI.hidePhoto(report.albumId, report.photoId, { reason }));
+  };
+
+  const handleDeletePhoto = (report) => {
+    const reason = window.prompt('永久删除这张照片？此操作无法撤销，可填写原因：');
+    if (reason === null) return;
+    run(() => adminAPI.deletePhoto(report.albumId, report.photoId, { reason }));
+  };
+
+  const buttonClass = 'inline-flex items-center px-3 py-1.5 text-xs font-medium rounded-lg transition-colors disabled:opacity-50';
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 overflow-hidden">
+      <div className="flex items-center gap-2 p-4 border-b border-gray-100">
+        {['open', 'resolved', 'dismissed', 'all'].map((key) => (
+          <button
+            key={key}
+            onClick={() => setStatus(key)}
+            className={`px-3 py-1.5 text-xs font-medium rounded-lg ${
+              status === key ? 'bg-indigo-50 text-indigo-600' : 'text-gray-500 hover:bg-gray-50'
+            }`}
+          >
+            {STATUS_LABELS[key] || '全部'}
+          </button>
+        ))}
+        <select
+          value={category}
+          onChange={(e) => setCategory(e.target.value)}
+          disabled={byAlbum}
+          className="ml-auto px-2 py-1.5 text-xs border border-gray-200 rounded-lg disabled:opacity-50"
+        >
+          <option value="">全部类型</option>
+          {Object.entries(CATEGORY_LABELS).map(([key, label]) => (
+            <option key={key} value={key}>{label}</option>
+          ))}
+        </select>
+        <button
+          onClick={() => setByAlbum(!byAlbum)}
+          className={`px-3 py-1.5 text-xs font-medium rounded-lg ${
+            byAlbum ? 'bg-indigo-50 text-indigo-600' : 'text-gray-500 hover:bg-gray-50'
+          }`}
+        >
+          按影集统计
+        </button>
+      </div>
+
+      {byAlbum && (
+        <div className="divide-y divide-gray-50">
+          {reportedAlbums.map((album) => (
+            <div key={album.albumId} className="p-4 flex flex-wrap items-center gap-3">
+              <div className="flex-1 min-w-0">
+                <a
+                  href={`/s/${album.shareCode}`}
+                  target="_blank"
+                  rel="noreferrer"
+                  className="font-medium text-gray-900 text-sm hover:text-indigo-600"
+                >
+                  {album.albumTitle}
+                </a>
+                <div className="text-xs text-gray-400">
+                  {album.ownerName} · {album.ownerEmail} · 最近举报 {new Date(album.lastReportedAt).toLocaleString('zh-CN')}
+                </div>
+                <div className="flex flex-wrap gap-1 mt-1">
+                  {Object.entries(album.categories)
+                    .filter(([, count]) => count > 0)
+                    .map(([key, count]) => (
+                      <span key={key} className="text-xs px-2 py-0.5 rounded-full bg-gray-100 text-gray-600">
+                        {CATEGORY_LABELS[key]} {count}
+                      </span>
+                    ))}
+                </div>
+              </div>
+              <span className="text-sm text-gray-600">
+                待处理 <span className="font-semibold text-red-600">{album.openCount}</span> / 共 {album.totalCount}
+              </span>
+              {album.takenDown && (
+                <span className="text-xs px-2 py-0.5 rounded-full bg-red-50 text-red-600">
+                  {album.autoTakenDown ? '已自动下架' : '已下架'}
+                </span>
+              )}
+            </div>
+          ))}
+          {!loading && reportedAlbums.length === 0 && (
+            <div className="text-center py-10">
+              <Flag className="w-12 h-12 text-gray-300 mx-auto mb-3" />
+              <p className="text-gray-500">暂无被举报的影集</p>
+            </div>
+          )}
+        </div>
+      )}
+
+      {!byAlbum && (
+        <>
+          <div className="divide-y divide-gray-50">
+            {reports.map((report) => (
+              <div key={report.id} className="p-4 flex gap-4">
+                {report.photoThumbnail && (
+                  <img
+                    src={report.photoThumbnail}
+                    alt=""
+                    className="w-20 h-20 object-cover rounded-lg shrink-0"
+                  />
+                )}
+                <div className="flex-1 min-w-0">
+                  <div className="flex flex-wrap items-center gap-2">
+                    <a
+                      href={`/s/${report.shareCode}`}
+                      target="_blank"
+                      rel="noreferrer"
+                      className="font-medium text-gray-900 text-sm hover:text-indigo-600"
+                    >
+                      {report.albumTitle}
+                    </a>
+                    <span className="text-xs text-gray-400">
+                      {report.ownerName} · {report.ownerEmail}
+                    </span>
+                    {report.takenDown && (
+                      <span className="text-xs px-2 py-0.5 rounded-full bg-red-50 text-red-600">已下架</span>
+                    )}
+                    <span className="text-xs px-2 py-0.5 rounded-full bg-gray-100 text-gray-600">
+                      {STATUS_LABELS[report.status]}
+                    </span>
+                    <span className="text-xs px-2 py-0.5 rounded-full bg-amber-50 text-amber-700">
+                      {CATEGORY_LABELS[report.category] || report.category}
+                    </span>
+                  </div>
+                  <p className="text-sm text-gray-700 mt-1 break-words">
+                    {report.photoId ? '举报照片' : '举报影集'}
+                    {report.reason && `：${report.reason}`}
+                  </p>
+                  <p className="text-xs text-gray-400 mt-1">
+                    {new Date(report.createdAt).toLocaleString('zh-CN')} · {report.reporterId ? `用户 #${report.reporterId}` : '匿名'} · {report.reporterIp}
+                  </p>
+
+                  <div className="flex flex-wrap gap-2 mt-3">
+                    {report.takenDown ? (
+                      <button
+                        disabled={busy}
+                        onClick={() => run(() => adminAPI.restoreAlbum(report.albumId), '恢复该影集的公开访问？')}
+                        className={`${buttonClass} bg-green-50 text-green-600 hover:bg-green-100`}
+                      >
+                        <RotateCcw className="w-3.5 h-3.5 mr-1" />
+                        恢复影集
+                      </button>
+                    ) : (
+                      <button
+                        disabled={busy}
+                        onClick={() => handleTakedown(report)}
+                        className={`${buttonClass} bg-red-50 text-red-600 hover:bg-red-100`}
+                      >
+                        <Ban className="w-3.5 h-3.5 mr-1" />
+                        下架影集
+                      </button>
+                    )}
+                    {report.photoId && (
+                      <>
+                        <button
+                          disabled={busy}
+                          onClick={() => handleHidePhoto(report)}
+                          className={`${buttonClass} bg-amber-50 text-amber-600 hover:bg-amber-100`}
+                        >
+                          <EyeOff className="w-3.5 h-3.5 mr-1" />
+                          隐藏照片
+                        </button>
+                        <button
+                          disabled={busy}
+                          onClick={() => handleDeletePhoto(report)}
+                          className={`${buttonClass} bg-red-50 text-red-600 hover:bg-red-100`}
+                        >
+                          <Trash2 className="w-3.5 h-3.5 mr-1" />
+                          删除照片
+                        </button>
+                      </>
+                    )}
+                    {report.status === 'open' ? (
+                      <>
+                        <button
+                          disabled={busy}
+                          onClick={() => run(() => adminAPI.reviewReport(report.id, { status: 'resolved' }))}
+                          className={`${buttonClass} bg-indigo-50 text-indigo-600 hover:bg-indigo-100`}
+                        >
+                          <CheckCircle className="w-3.5 h-3.5 mr-1" />
+                          标记已处理
+                        </button>
+                        <button
+                          disabled={busy}
+                          onClick={() => run(() => adminAPI.reviewReport(report.id, { status: 'dismissed' }))}
+                          className={`${buttonClass} bg-gray-100 text-gray-600 hover:bg-gray-200`}
+                        >
+                          <XCircle className="w-3.5 h-3.5 mr-1" />
+                          忽略
+                        </button>
+                      </>
+                    ) : (
+                      <button
+                        disabled={busy}
+                        onClick={() => run(() => adminAPI.reviewReport(report.id, { status: 'open' }))}
+                        className={`${buttonClass} bg-gray-100 text-gray-600 hover:bg-gray-200`}
+                      >
+                        <RotateCcw className="w-3.5 h-3.5 mr-1" />
+                        重新打开
+                      </button>
+                    )}
+                  </div>
+                </div>
+              </div>
+            ))}
+          </div>
+
+          {!loading && reports.length === 0 && (
+            <div className="text-center py-10">
+              <Flag className="w-12 h-12 text-gray-300 mx-auto mb-3" />
+              <p className="text-gray-500">暂无举报</p>
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,120 @@
+import { useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { adminAPI } from '../utils/api';
+import { useAuth } from '../contexts/AuthContext';
+import { Ban, CheckCircle, Shield, KeyRound, Trash2, Save, UserCheck } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const MB = 1024 * 1024;
+
+export default function AdminUserActions({ user, onChanged }) {
+  const [quota, setQuota] = useState({
+    maxAlbums: user.quota?.maxAlbums ?? '',
+    maxPhotosPerAlbum: user.quota?.maxPhotosPerAlbum ?? '',
+    storageQuotaMb: user.quota?.storageQuotaBytes != null ? Math.round(user.quota.storageQuotaBytes / MB) : '',
+  });
+  const [busy, setBusy] = useState(false);
+  const { startImpersonation } = useAuth();
+  const navigate = useNavigate();
+
+  const run = async (action, confirmText) => {
+    if (confirmText && !window.confirm(confirmText)) return;
+    setBusy(true);
+    try {
+      const res = await action();
+      toast.success(res.data.message);
+      onChanged();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '操作失败');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const handleSuspend = () => {
+    const reason = window.prompt(`停用 ${user.email}？停用后该用户将无法登录，可填写原因：`);
+    if (reason === null) return;
+    run(() => adminAPI.suspendUser(user.id, { reason }));
+  };
+
+  const handleImpersonate = async () => {
+    const reason = window.prompt(`以 ${user.email} 的身份登录查看？此操作会记录在审计日志中，可填写原因：`);
+    if (reason === null) return;
+    setBusy(true);
+    try {
+      const res = await adminAPI.impersonateUser(user.id, { reason });
+      toast.success(res.data.message);
+      startImpersonation(res.data);
+      navigate('/dashboard');
+    } catch (err) {
+      toast.error(err.response?.data?.error || '模拟登录失败');
+      setBusy(false);
+    }
+  };
+
+  const toNumber = (value) => (value === '' ? null : Number(value));
+
+  const handleQuota = (e) => {
+    e.preventDefault();
+    run(() => adminAPI.setUserQuota(user.id, {
+      maxAlbums: toNumber(quota.maxAlbums),
+      maxPhotosPerAlbum: toNumber(quota.maxPhotosPerAlbum),
+      storageQuotaMb: toNumber(quota.storageQuotaMb),
+    }));
+  };
+
+  const buttonClass = 'inline-flex items-center px-3 py-1.5 text-xs font-medium rounded-lg transition-colors disabled:opacity-50';
+  const inputClass = 'w-24 px-2 py-1 text-xs border border-gray-200 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none';
+
+  return (
+    <div className="bg-white rounded-lg px-3 py-3 mb-3 space-y-3">
+      <div className="flex flex-wrap gap-2">
+        {user.suspended_at ? (
+          <button disabled={busy} onClick={() => run(() => adminAPI.unsuspendUser(user.id))}
+            className={`${buttonClass} text-green-700 bg-green-50 hover:bg-green-100`}>
+            <CheckCircle className="w-3.5 h-3.5 mr-1" />恢复账号
+          </button>
+        ) : (
+          <button disabled={busy} onClick={handleSuspend}
+            className={`${buttonClass} text-amber-700 bg-amber-50 hover:bg-amber-100`}>
+            <Ban className="w-3.5 h-3.5 mr-1" />停用账号
+          </button>
+        )}
+        <button disabled={busy}
+          onClick={() => run(() => adminAPI.changeUserRole(user.id, { role: 'admin' }), `确定将 ${user.email} 设为管理员？`)}
+          className={`${buttonClass} text-indigo-700 bg-indigo-50 hover:bg-indigo-100`}>
+          <Shield className="w-3.5 h-3.5 mr-1" />设为管理员
+        </button>
+        <button disabled={busy}
+          onClick={() => run(() => adminAPI.forcePasswordReset(user.id), `确定重置 ${user.email} 的密码？该用户所有设备将退出登录，并收到设置新密码的邮件。`)}
+          className={`${buttonClass} text-gray-700 bg-gray-100 hover:bg-gray-200`}>
+          <KeyRound className="w-3.5 h-3.5 mr-1" />强制重置密码
+        </button>
+        {user.role !== 'admin' && !user.suspended_at && (
+          <button disabled={busy} onClick={handleImpersonate}
+            className={`${buttonClass} text-sky-700 bg-sky-50 hover:bg-sky-100`}>
+            <UserCheck className="w-3.5 h-3.5 mr-1" />模拟登录
+          </button>
+        )}
+        <button disabled={busy}
+          onClick={() => run(() => adminAPI.deleteUser(user.id), `确定永久删除 ${user.email}？其全部个人影集、照片和文件都将被删除且无法恢复，工作区影集会转交给其他成员。`)}
+          className={`${buttonClass} text-red-600 bg-red-50 hover:bg-red-100`}>
+          <Trash2 className="w-3.5 h-3.5 mr-1" />删除用户
+        </button>
+      </div>
+
+      <form onSubmit={handleQuota} className="flex flex-wrap items-center gap-2 text-xs text-gray-600">
+        <span>配额（留空使用默认值）：</span>
+        <label>影集数 <input type="number" min="0" value={quota.maxAlbums}
+          onChange={(e) => setQuota({ ...quota, maxAlbums: e.target.value })} className={inputClass} /></label>
+        <label>每影集照片 <input type="number" min="0" value={quota.maxPhotosPerAlbum}
+          onChange={(e) => setQuota({ ...quota, maxPhotosPerAlbum: e.target.value })} className={inputClass} /></label>
+        <label>存储空间 (MB，0 为不限) <input type="number" min="0" value={quota.storageQuotaMb}
+          onChange={(e) => setQuota({ ...quota, storageQuotaMb: e.target.value })} className={inputClass} /></label>
+        <button type="submit" disabled={busy} className={`${buttonClass} text-indigo-700 bg-indigo-50 hover:bg-indigo-100`}>
+          <Save className="w-3.5 h-3.5 mr-1" />保存配额
+        </button>
+      </form>
+    </div>
+  );
+}
@@ -0,0 +1,185 @@
+import { useState, useEffect } from 'react';
+import { authAPI } from '../utils/api';
+import { Code, Copy, Trash2 } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const SCOPES = [
+  { value: 'albums:read', label: '查看相册' },
+  { value: 'albums:write', label: '创建和编辑相册' },
+  { value: 'photos:upload', label: '上传照片' },
+];
+
+const SCOPE_LABELS = Object.fromEntries(SCOPES.map((s) => [s.value, s.label]));
+
+export default function ApiKeySettings() {
+  const [apiKeys, setApiKeys] = useState([]);
+  const [name, setName] = useState('');
+  const [scopes, setScopes] = useState(['albums:read', 'photos:upload']);
+  const [expiresInDays, setExpiresInDays] = useState('90');
+  const [newKey, setNewKey] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const loadApiKeys = async () => {
+    try {
+      const res = await authAPI.getApiKeys();
+      setApiKeys(res.data.apiKeys);
+    } catch {
+      // Ignore
+    }
+  };
+
+  useEffect(() => {
+    loadApiKeys();
+  }, []);
+
+  const toggleScope = (scope) => {
+    setScopes((prev) =>
+      prev.includes(scope) ? prev.filter((s) => s !== scope) : [...prev, scope]
+    );
+  };
+
+  const handleCreate = async (e) => {
+    e.preventDefault();
+    setLoading(true);
+    try {
+      const res = await authAPI.createApiKey({
+        name,
+        scopes,
+        expiresInDays: expiresInDays ? Number(expiresInDays) : null,
+      });
+      toast.success(res.data.message);
+      setNewKey(res.data.key);
+      setName('');
+      loadApiKeys();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '创建 API 密钥失败');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleCopy = async () => {
+    try {
+      await navigator.clipboard.writeText(newKey);
+      toast.success('已复制到剪贴板');
+    } catch {
+      toast.error('复制失败，请手动复制');
+    }
+  };
+
+  const handleRevoke = async (id) => {
+    if (!window.confirm('撤销后使用该密钥的脚本将无法继续访问，确定撤销吗？')) return;
+    try {
+      await authAPI.revokeApiKey(id);
+      toast.success('API 密钥已撤销');
+      loadApiKeys();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '撤销失败');
+    }
+  };
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 p-6">
+      <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center">
+        <Code className="w-5 h-5 mr-2 text-indigo-600" />
+        API 密钥
+      </h2>
+      <p className="text-sm text-gray-600 mb-4">
+        供上传脚本、联机拍摄或 Lightroom 导出插件使用，请求时放在 <code className="text-xs bg-gray-100 px-1 rounded">X-API-Key</code> 请求头中。
+      </p>
+
+      {newKey && (
+        <div className="mb-4 p-4 bg-amber-50 border border-amber-200 rounded-xl">
+          <p className="text-sm text-amber-800 mb-2">请立即复制保存，关闭后将无法再次查看：</p>
+          <div className="flex items-center gap-2">
+            <code className="flex-1 text-xs break-all bg-white px-3 py-2 rounded-lg border border-amber-100">{newKey}</code>
+            <button
+              onClick={handleCopy}
+              className="p-2 text-amber-700 hover:text-amber-900 transition-colors"
+              title="复制"
+            >
+              <Copy className="w-4 h-4" />
+            </button>
+          </div>
+          <button onClick={() => setNewKey('')} className="mt-2 text-xs text-amber-700 hover:underline">
+            我已保存
+          </button>
+        </div>
+      )}
+
+      {apiKeys.length > 0 && (
+        <ul className="divide-y divide-gray-100 mb-4">
+          {apiKeys.map((k) => (
+            <li key={k.id} className="py-3 flex items-center justify-between">
+              <div>
+                <div className="text-sm font-medium text-gray-900">
+                  {k.name} <span className="text-xs text-gray-400 font-mono">{k.keyPrefix}…</span>
+                </div>
+                <div className="text-xs text-gray-500">
+                  {k.scopes.map((s) => SCOPE_LABELS[s] || s).join('、')}
+                </div>
+                <div className="text-xs text-gray-500">
+                  {k.expiresAt
+                    ? `${new Date(k.expiresAt) < new Date() ? '已过期' : '有效期至'} ${new Date(k.expiresAt).toLocaleDateString('zh-CN')}`
+                    : '永久有效'}
+                  {k.lastUsedAt ? ` · 上次使用 ${new Date(k.lastUsedAt).toLocaleString('zh-CN')}` : ' · 从未使用'}
+                </div>
+              </div>
+              <button
+                onClick={() => handleRevoke(k.id)}
+                className="p-2 text-gray-400 hover:text-red-600 transition-colors"
+                title="撤销"
+              >
+                <Trash2 className="w-4 h-4" />
+              </button>
+            </li>
+          ))}
+        </ul>
+      )}
+
+      <form onSubmit={handleCreate} className="space-y-3">
+        <input
+          type="text"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          placeholder="名称（如：Lightroom 导出）"
+          maxLength={50}
+          className="w-full px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+          style={{ fontSize: '16px' }}
+        />
+        <div className="flex flex-wrap gap-4">
+          {SCOPES.map((s) => (
+            <label key={s.value} className="flex items-center text-sm text-gray-700">
+              <input
+                type="checkbox"
+                checked={scopes.includes(s.value)}
+                onChange={() => toggleScope(s.value)}
+                className="mr-2 rounded text-indigo-600 focus:ring-indigo-500"
+              />
+              {s.label}
+            </label>
+          ))}
+        </div>
+        <div className="flex flex-col sm:flex-row gap-3">
+          <select
+            value={expiresInDays}
+            onChange={(e) => setExpiresInDays(e.target.value)}
+            className="flex-1 px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+          >
+            <option value="30">30 天后过期</option>
+            <option value="90">90 天后过期</option>
+            <option value="365">1 年后过期</option>
+            <option value="">永不过期</option>
+          </select>
+          <button
+            type="submit"
+            disabled={loading || !name.trim() || scopes.length === 0}
+            className="inline-flex items-center justify-center px-6 py-2.5 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:from-indigo-700 hover:to-purple-700 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+          >
+            {loading ? '处理中...' : '创建密钥'}
+          </button>
+        </div>
+      </form>
+    </div>
+  );
+}
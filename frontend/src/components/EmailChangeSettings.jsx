@@ -0,0 +1,98 @@
+import { useState } from 'react';
+import { useAuth } from '../contexts/AuthContext';
+import { authAPI } from '../utils/api';
+import { Mail } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+export default function EmailChangeSettings() {
+  const { user, refreshProfile } = useAuth();
+  const [newEmail, setNewEmail] = useState('');
+  const [password, setPassword] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    if (!newEmail || !password) {
+      toast.error('请填写完整');
+      return;
+    }
+
+    setLoading(true);
+    try {
+      const res = await authAPI.requestEmailChange({ newEmail, password });
+      toast.success(res.data.message, { duration: 5000 });
+      setNewEmail('');
+      setPassword('');
+      refreshProfile();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '更改邮箱失败');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleCancel = async () => {
+    try {
+      const res = await authAPI.cancelEmailChange();
+      toast.success(res.data.message);
+      refreshProfile();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '取消失败');
+    }
+  };
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 p-6">
+      <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center">
+        <Mail className="w-5 h-5 mr-2 text-indigo-600" />
+        更改邮箱
+      </h2>
+
+      {user.pendingEmail ? (
+        <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-3">
+          <p className="text-sm text-gray-600">
+            已向 <span className="font-medium text-gray-900">{user.pendingEmail}</span> 发送确认邮件，点击邮件中的链接后即可完成更改。
+          </p>
+          <button
+            onClick={handleCancel}
+            className="px-4 py-2 text-sm font-medium text-gray-700 bg-gray-100 rounded-xl hover:bg-gray-200 transition-colors whitespace-nowrap"
+          >
+            取消更改
+          </button>
+        </div>
+      ) : (
+        <form onSubmit={handleSubmit} className="space-y-3">
+          <p className="text-sm text-gray-600">
+            我们会向新邮箱发送确认链接，确认后需使用新邮箱登录，当前邮箱也会收到提醒。
+          </p>
+          <input
+            type="email"
+            value={newEmail}
+            onChange={(e) => setNewEmail(e.target.value)}
+            placeholder="新邮箱"
+            className="w-full px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+            style={{ fontSize: '16px' }}
+          />
+          <div className="flex flex-col sm:flex-row gap-3">
+            <input
+              type="password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              placeholder="当前密码"
+              autoComplete="current-password"
+              className="flex-1 px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+              style={{ fontSize: '16px' }}
+            />
+            <button
+              type="submit"
+              disabled={loading}
+              className="inline-flex items-center justify-center px-6 py-2.5 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:from-indigo-700 hover:to-purple-700 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+            >
+              {loading ? '发送中...' : '发送确认邮件'}
+            </button>
+          </div>
+        </form>
+      )}
+    </div>
+  );
+}
@@ -4,7 +4,7 @@ import { Camera, LogOut, Settings, LayoutDashboard, Menu, X, Shield, MessageSqua
 import { useState } from 'react';
 
 export default function Layout({ children }) {
-  const { user, logout } = useAuth();
+  const { user, logout, endImpersonation } = useAuth();
   const navigate = useNavigate();
   const location = useLocation();
   const [menuOpen, setMenuOpen] = useState(false);
@@ -14,6 +14,11 @@ export default function Layout({ children }) {
     navigate('/login');
   };
 
+  const handleEndImpersonation = async () => {
+    await endImpersonation();
+    navigate('/admin');
+  };
+
   const isActive = (path) => location.pathname === path;
 
   return (
@@ -151,6 +156,22 @@ export default function Layout({ children }) {
         )}
       </header>
 
+      {user?.impersonatedBy && (
+        <div className="bg-amber-500 text-white text-sm" style={{ width: '100%', maxWidth: '100%' }}>
+          <div className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-2 flex items-center justify-between gap-3">
+            <span>
+              您正在以 <strong>{user.name}</strong>（{user.email}）的身份查看，删除、修改密码等操作已被禁止
+            </span>
+            <button
+              onClick={handleEndImpersonation}
+              className="shrink-0 px-3 py-1 rounded-lg bg-white/20 hover:bg-white/30 font-medium"
+            >
+              结束模拟
+            </button>
+          </div>
+        </div>
+      )}
+
       <main className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-6" style={{ width: '100%', maxWidth: '100%' }}>
         <div style={{ width: '100%', maxWidth: '100%' }}>
           {children}
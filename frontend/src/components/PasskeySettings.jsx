@@ -0,0 +1,151 @@
+import { useState, useEffect } from 'react';
+import { authAPI } from '../utils/api';
+import { createPasskey, isPasskeySupported } from '../utils/webauthn';
+import { KeyRound, Trash2 } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+export default function PasskeySettings() {
+  const [credentials, setCredentials] = useState([]);
+  const [name, setName] = useState('');
+  const [password, setPassword] = useState('');
+  const [code, setCode] = useState('');
+  const [twoFactorEnabled, setTwoFactorEnabled] = useState(false);
+  const [loading, setLoading] = useState(false);
+
+  const loadCredentials = async () => {
+    try {
+      const res = await authAPI.getPasskeys();
+      setCredentials(res.data.credentials);
+    } catch {
+      // Ignore
+    }
+  };
+
+  useEffect(() => {
+    loadCredentials();
+    authAPI.getTwoFactorStatus()
+      .then((res) => setTwoFactorEnabled(res.data.enabled))
+      .catch(() => {});
+  }, []);
+
+  const handleAdd = async (e) => {
+    e.preventDefault();
+    setLoading(true);
+    try {
+      // 通行密钥登录不再需要两步验证，因此添加前需重新验证身份
+      const begin = await authAPI.beginPasskeyRegistration({ password, code });
+      const credential = await createPasskey(begin.data.options);
+      const res = await authAPI.finishPasskeyRegistration({
+        challengeToken: begin.data.challengeToken,
+        name,
+        credential,
+      });
+      toast.success(res.data.message);
+      setName('');
+      setPassword('');
+      setCode('');
+      loadCredentials();
+    } catch (err) {
+      if (err.name === 'InvalidStateError') {
+        toast.error('该设备已注册过通行密钥');
+      } else if (err.name !== 'NotAllowedError' && err.name !== 'AbortError') {
+        toast.error(err.response?.data?.error || '添加通行密钥失败');
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleDelete = async (id) => {
+    if (!window.confirm('删除后将无法使用该通行密钥登录，确定删除吗？')) return;
+    try {
+      await authAPI.deletePasskey(id);
+      toast.success('通行密钥已删除');
+      loadCredentials();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '删除失败');
+    }
+  };
+
+  if (!isPasskeySupported()) {
+    return null;
+  }
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 p-6">
+      <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center">
+        <KeyRound className="w-5 h-5 mr-2 text-indigo-600" />
+        通行密钥
+      </h2>
+      <p className="text-sm text-gray-600 mb-4">
+        使用手机或电脑的指纹、面容或锁屏密码登录，无需输入密码。
+      </p>
+
+      {credentials.length > 0 && (
+        <ul className="divide-y divide-gray-100 mb-4">
+          {credentials.map((c) => (
+            <li key={c.id} className="py-3 flex items-center justify-between">
+              <div>
+                <div className="text-sm font-medium text-gray-900">{c.name}</div>
+                <div className="text-xs text-gray-500">
+                  添加于 {new Date(c.createdAt).toLocaleDateString('zh-CN')}
+                  {c.lastUsedAt && ` · 上次使用 ${new Date(c.lastUsedAt).toLocaleString('zh-CN')}`}
+                </div>
+              </div>
+              <button
+                onClick={() => handleDelete(c.id)}
+                className="p-2 text-gray-400 hover:text-red-600 transition-colors"
+                title="删除"
+              >
+                <Trash2 className="w-4 h-4" />
+              </button>
+            </li>
+          ))}
+        </ul>
+      )}
+
+      <form onSubmit={handleAdd} className="flex flex-col gap-3">
+        <input
+          type="text"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          placeholder="名称（如：我的 iPhone）"
+          maxLength={50}
+          className="flex-1 px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+          style={{ fontSize: '16px' }}
+        />
+        <div className="flex flex-col sm:flex-row gap-3">
+          <input
+            type="password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+            placeholder="当前密码"
+            required
+            autoComplete="current-password"
+            className="flex-1 px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+            style={{ fontSize: '16px' }}
+          />
+          {twoFactorEnabled && (
+            <input
+              type="text"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              placeholder="两步验证码或恢复码"
+              required
+              autoComplete="one-time-code"
+              className="flex-1 px-4 py-2.5 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all"
+              style={{ fontSize: '16px' }}
+            />
+          )}
+        </div>
+        <button
+          type="submit"
+          disabled={loading}
+          className="inline-flex items-center justify-center px-6 py-2.5 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:from-indigo-700 hover:to-purple-700 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+        >
+          {loading ? '处理中...' : '添加通行密钥'}
+        </button>
+      </form>
+    </div>
+  );
+}
@@ -0,0 +1,106 @@
+import { useState } from 'react';
+import { publicAPI } from '../utils/api';
+import { Flag, X } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const CATEGORIES = [
+  { key: 'copyright', label: '侵犯版权或肖像权' },
+  { key: 'explicit', label: '色情或不适宜内容' },
+  { key: 'spam', label: '垃圾广告或诈骗' },
+  { key: 'other', label: '其他' },
+];
+
+// Lets a visitor report a shared album, or one photo of it, to the admins
+export default function ReportAlbumDialog({ shareCode, photo, onClose }) {
+  const [category, setCategory] = useState('');
+  const [reason, setReason] = useState('');
+  const [submitting, setSubmitting] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    if (!category) {
+      toast.error('请选择举报类型');
+      return;
+    }
+    if (category === 'other' && !reason.trim()) {
+      toast.error('请填写举报原因');
+      return;
+    }
+    setSubmitting(true);
+    try {
+      const res = await publicAPI.reportAlbum(shareCode, {
+        photoId: photo ? photo.id : undefined,
+        category,
+        reason: reason.trim(),
+      });
+      toast.success(res.data.message);
+      onClose();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '提交举报失败');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  return (
+    <div className="fixed inset-0 bg-black/50 flex items-center justify-center z-[60] p-4" onClick={onClose}>
+      <form
+        onSubmit={handleSubmit}
+        onClick={(e) => e.stopPropagation()}
+        className="bg-white rounded-2xl w-full max-w-md p-6"
+      >
+        <div className="flex items-center justify-between mb-4">
+          <h3 className="text-lg font-semibold text-gray-900 flex items-center">
+            <Flag className="w-5 h-5 mr-2 text-red-500" />
+            {photo ? '举报这张照片' : '举报这个影集'}
+          </h3>
+          <button type="button" onClick={onClose} className="p-1 text-gray-400 hover:text-gray-600">
+            <X className="w-5 h-5" />
+          </button>
+        </div>
+        <p className="text-sm text-gray-500 mb-3">
+          如果内容侵犯了您的权益或包含违规信息，请说明情况，管理员会尽快审核处理。
+        </p>
+        <div className="space-y-2 mb-3">
+          {CATEGORIES.map(({ key, label }) => (
+            <label key={key} className="flex items-center text-sm text-gray-700 cursor-pointer">
+              <input
+                type="radio"
+                name="category"
+                value={key}
+                checked={category === key}
+                onChange={() => setCategory(key)}
+                className="mr-2 text-red-600 focus:ring-red-500"
+              />
+              {label}
+            </label>
+          ))}
+        </div>
+        <textarea
+          value={reason}
+          onChange={(e) => setReason(e.target.value)}
+          maxLength={1000}
+          rows={4}
+          placeholder={category === 'other' ? '请描述举报原因' : '补充说明（选填）'}
+          className="w-full px-3 py-2 border border-gray-200 rounded-lg text-sm focus:outline-none focus:ring-2 focus:ring-red-500"
+        />
+        <div className="flex justify-end gap-2 mt-4">
+          <button
+            type="button"
+            onClick={onClose}
+            className="px-4 py-2 text-sm text-gray-600 hover:bg-gray-100 rounded-lg"
+          >
+            取消
+          </button>
+          <button
+            type="submit"
+            disabled={submitting}
+            className="px-4 py-2 text-sm text-white bg-red-600 hover:bg-red-700 rounded-lg disabled:opacity-50"
+          >
+            {submitting ? '提交中...' : '提交举报'}
+          </button>
+        </div>
+      </form>
+    </div>
+  );
+}
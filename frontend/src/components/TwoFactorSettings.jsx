@@ -0,0 +1,199 @@
+import { useState, useEffect } from 'react';
+import { authAPI } from '../utils/api';
+import { ShieldCheck } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+const inputClass = 'w-full px-4 py-3 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all';
+const primaryButtonClass = 'inline-flex items-center px-6 py-2.5 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:from-indigo-700 hover:to-purple-700 transition-all disabled:opacity-50 disabled:cursor-not-allowed shadow-lg shadow-indigo-200';
+const secondaryButtonClass = 'inline-flex items-center px-4 py-2 bg-white border border-gray-200 text-gray-700 rounded-lg text-sm font-medium hover:bg-gray-50 transition-all disabled:opacity-50';
+
+export default function TwoFactorSettings() {
+  const [status, setStatus] = useState(null);
+  const [setup, setSetup] = useState(null);
+  const [password, setPassword] = useState('');
+  const [code, setCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState(null);
+  const [loading, setLoading] = useState(false);
+
+  const loadStatus = async () => {
+    try {
+      const res = await authAPI.getTwoFactorStatus();
+      setStatus(res.data);
+    } catch {
+      // Ignore
+    }
+  };
+
+  useEffect(() => {
+    loadStatus();
+  }, []);
+
+  const run = async (action) => {
+    setLoading(true);
+    try {
+      await action();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '操作失败');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleSetup = (e) => {
+    e.preventDefault();
+    run(async () => {
+      const res = await authAPI.setupTwoFactor({ password });
+      setSetup(res.data);
+      setPassword('');
+    });
+  };
+
+  const handleEnable = (e) => {
+    e.preventDefault();
+    run(async () => {
+      const res = await authAPI.enableTwoFactor({ code });
+      toast.success(res.data.message);
+      setRecoveryCodes(res.data.recoveryCodes);
+      setSetup(null);
+      setCode('');
+      loadStatus();
+    });
+  };
+
+  const handleDisable = (e) => {
+    e.preventDefault();
+    run(async () => {
+      const res = await authAPI.disableTwoFactor({ password, code });
+      toast.success(res.data.message);
+      setPassword('');
+      setCode('');
+      setRecoveryCodes(null);
+      loadStatus();
+    });
+  };
+
+  const handleRegenerate = () => {
+    if (!code) {
+      toast.error('请输入验证码');
+      return;
+    }
+    run(async () => {
+      const res = await authAPI.regenerateRecoveryCodes({ code });
+      toast.success(res.data.message);
+      setRecoveryCodes(res.data.recoveryCodes);
+      setCode('');
+      loadStatus();
+    });
+  };
+
+  if (!status) {
+    return null;
+  }
+
+  return (
+    <div className="bg-white rounded-2xl border border-gray-100 p-6">
+      <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center">
+        <ShieldCheck className="w-5 h-5 mr-2 text-indigo-600" />
+        两步验证
+        <span className={`ml-2 text-xs px-2 py-0.5 rounded-full ${status.enabled ? 'bg-green-100 text-green-700' : 'bg-gray-100 text-gray-500'}`}>
+          {status.enabled ? '已启用' : '未启用'}
+        </span>
+      </h2>
+
+      {status.required && !status.enabled && (
+        <div className="mb-4 p-4 bg-amber-50 border border-amber-100 rounded-xl text-sm text-amber-800">
+          管理员账号必须启用两步验证后才能使用管理后台。
+        </div>
+      )}
+
+      {recoveryCodes && (
+        <div className="mb-4 p-4 bg-blue-50 border border-blue-100 rounded-xl">
+          <p className="text-sm text-blue-800 mb-2">
+            请将以下恢复码保存在安全的地方。手机丢失时可用恢复码登录，每个只能使用一次，且只显示这一次。
+          </p>
+          <div className="grid grid-cols-2 gap-2 font-mono text-sm text-gray-800">
+            {recoveryCodes.map((c) => <span key={c}>{c}</span>)}
+          </div>
+        </div>
+      )}
+
+      {!status.enabled && !setup && (
+        <form onSubmit={handleSetup} className="space-y-4">
+          <p className="text-sm text-gray-600">
+            启用后，登录时除密码外还需输入身份验证器 App（如 Google Authenticator、Microsoft Authenticator）中的验证码。
+          </p>
+          <input
+            type="password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+            placeholder="输入当前密码"
+            className={inputClass}
+            style={{ fontSize: '16px' }}
+            required
+          />
+          <button type="submit" disabled={loading} className={primaryButtonClass}>
+            {loading ? '处理中...' : '开始设置'}
+          </button>
+        </form>
+      )}
+
+      {!status.enabled && setup && (
+        <form onSubmit={handleEnable} className="space-y-4">
+          <p className="text-sm text-gray-600">使用身份验证器 App 扫描二维码，然后输入 App 显示的 6 位验证码。</p>
+          <img src={setup.qrCode} alt="两步验证二维码" className="w-48 h-48 border border-gray-100 rounded-xl" />
+          <p className="text-xs text-gray-500 break-all">无法扫码？手动输入密钥：<span className="font-mono">{setup.secret}</span></p>
+          <input
+            type="text"
+            inputMode="numeric"
+            autoComplete="one-time-code"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+            placeholder="6 位验证码"
+            className={inputClass}
+            style={{ fontSize: '16px' }}
+            required
+          />
+          <button type="submit" disabled={loading} className={primaryButtonClass}>
+            {loading ? '验证中...' : '启用两步验证'}
+          </button>
+        </form>
+      )}
+
+      {status.enabled && (
+        <div className="space-y-4">
+          <p className="text-sm text-gray-600">剩余恢复码：{status.recoveryCodesRemaining} 个</p>
+          <input
+            type="text"
+            autoComplete="one-time-code"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+            placeholder="验证码或恢复码"
+            className={inputClass}
+            style={{ fontSize: '16px' }}
+          />
+          <button type="button" onClick={handleRegenerate} disabled={loading} className={secondaryButtonClass}>
+            重新生成恢复码
+          </button>
+
+          {!status.required && (
+            <form onSubmit={handleDisable} className="border-t border-gray-100 pt-4 mt-4 space-y-4">
+              <p className="text-sm text-gray-600">关闭两步验证需要当前密码和上方的验证码。</p>
+              <input
+                type="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                placeholder="输入当前密码"
+                className={inputClass}
+                style={{ fontSize: '16px' }}
+                required
+              />
+              <button type="submit" disabled={loading} className={secondaryButtonClass}>
+                关闭两步验证
+              </button>
+            </form>
+          )}
+        </div>
+      )}
+    </div>
+  );
+}
@@ -1,5 +1,6 @@
 import { createContext, useContext, useState, useEffect, useCallback } from 'react';
 import { authAPI } from '../utils/api';
+import { getPasskey } from '../utils/webauthn';
 
 const AuthContext = createContext(null);
 
@@ -30,36 +31,122 @@ export function AuthProvider({ children }) {
         refreshUserInfo();
       } catch {
         localStorage.removeItem('token');
+        localStorage.removeItem('refreshToken');
         localStorage.removeItem('user');
       }
     }
     setLoading(false);
   }, []);
 
-  const login = useCallback(async (email, password) => {
-    const res = await authAPI.login({ email, password });
-    const { token, user: userData, requiresVerification, message } = res.data;
+  const completeLogin = useCallback((data) => {
+    const { token, refreshToken, user: userData, requiresVerification, twoFactorSetupRequired, message } = data;
     localStorage.setItem('token', token);
+    localStorage.setItem('refreshToken', refreshToken);
     localStorage.setItem('user', JSON.stringify(userData));
     setUser(userData);
-    return { user: userData, requiresVerification, message };
+    return { user: userData, requiresVerification, twoFactorSetupRequired, message };
   }, []);
 
+  const login = useCallback(async (email, password) => {
+    const res = await authAPI.login({ email, password });
+    // 开启两步验证的账号需要再提交一次验证码
+    if (res.data.requiresTwoFactor) {
+      return { requiresTwoFactor: true, twoFactorToken: res.data.twoFactorToken, message: res.data.message };
+    }
+    return completeLogin(res.data);
+  }, [completeLogin]);
+
+  const loginWithTwoFactor = useCallback(async (twoFactorToken, code) => {
+    const res = await authAPI.loginTwoFactor({ twoFactorToken, code });
+    return completeLogin(res.data);
+  }, [completeLogin]);
+
+  const loginWithPasskey = useCallback(async () => {
+    const begin = await authAPI.beginPasskeyLogin();
+    const credential = await getPasskey(begin.data.options);
+    const res = await authAPI.finishPasskeyLogin({ challengeToken: begin.data.challengeToken, credential });
+    return completeLogin(res.data);
+  }, [completeLogin]);
+
+  const loginWithMagicLink = useCallback(async (token) => {
+    const res = await authAPI.magicLinkLogin({ token });
+    if (res.data.requiresTwoFactor) {
+      return { requiresTwoFactor: true, twoFactorToken: res.data.twoFactorToken, message: res.data.message };
+    }
+    return completeLogin(res.data);
+  }, [completeLogin]);
+
+  // 在已登录当前账号的浏览器中确认时，服务端会签发新令牌
+  const confirmEmailChange = useCallback(async (token) => {
+    const res = await authAPI.confirmEmailChange({ token });
+    if (res.data.token) {
+      return { ...completeLogin(res.data), signedIn: true };
+    }
+    return { message: res.data.message, signedIn: false };
+  }, [completeLogin]);
+
+  const loginWithOIDC = useCallback(async (code, state) => {
+    const res = await authAPI.oidcCallback({ code, state });
+    if (res.data.requiresTwoFactor) {
+      return { requiresTwoFactor: true, twoFactorToken: res.data.twoFactorToken, message: res.data.message };
+    }
+    return completeLogin(res.data);
+  }, [completeLogin]);
+
   const register = useCallback(async (email, password, name) => {
     const res = await authAPI.register({ email, password, name });
-    const { token, user: userData, message } = res.data;
+    const { token, refreshToken, user: userData, message } = res.data;
     localStorage.setItem('token', token);
+    localStorage.setItem('refreshToken', refreshToken);
     localStorage.setItem('user', JSON.stringify(userData));
     setUser(userData);
     return { ...userData, message };
   }, []);
 
   const logout = useCallback(() => {
+    // 通知服务端注销当前会话，失败也不影响本地登出
+    const token = localStorage.getItem('token');
+    if (token) {
+      authAPI.logout(token).catch(() => {});
+    }
     localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
     localStorage.removeItem('user');
     setUser(null);
   }, []);
 
+  // 管理员模拟登录：暂存管理员自己的登录状态，结束模拟时恢复
+  const startImpersonation = useCallback((data) => {
+    localStorage.setItem('adminSession', JSON.stringify({
+      token: localStorage.getItem('token'),
+      refreshToken: localStorage.getItem('refreshToken'),
+      user: localStorage.getItem('user'),
+    }));
+    localStorage.setItem('token', data.token);
+    localStorage.removeItem('refreshToken');
+    localStorage.setItem('user', JSON.stringify(data.user));
+    setUser(data.user);
+  }, []);
+
+  const endImpersonation = useCallback(async () => {
+    const token = localStorage.getItem('token');
+    if (token) {
+      await authAPI.logout(token).catch(() => {});
+    }
+    const saved = JSON.parse(localStorage.getItem('adminSession') || 'null');
+    localStorage.removeItem('adminSession');
+    if (!saved) {
+      localStorage.removeItem('token');
+      localStorage.removeItem('user');
+      setUser(null);
+      return;
+    }
+    localStorage.setItem('token', saved.token);
+    localStorage.setItem('refreshToken', saved.refreshToken);
+    localStorage.setItem('user', saved.user);
+    setUser(JSON.parse(saved.user));
+  }, []);
+
   const refreshProfile = useCallback(async () => {
     try {
       const res = await authAPI.getProfile();
@@ -72,7 +159,7 @@ export function AuthProvider({ children }) {
   }, []);
 
   return (
-    <AuthContext.Provider value={{ user, loading, login, register, logout, refreshProfile }}>
+    <AuthContext.Provider value={{ user, loading, login, loginWithTwoFactor, loginWithPasskey, loginWithMagicLink, loginWithOIDC, confirmEmailChange, register, logout, refreshProfile, startImpersonation, endImpersonation }}>
       {children}
     </AuthContext.Provider>
   );
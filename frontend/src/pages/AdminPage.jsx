@@ -1,9 +1,13 @@
 import { useState, useEffect, useCallback } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { adminAPI } from '../utils/api';
+import AdminUserActions from '../components/AdminUserActions';
+import AdminModerationQueue from '../components/AdminModerationQueue';
+import AdminFeedbackInbox from '../components/AdminFeedbackInbox';
+import AdminAuditEvents from '../components/AdminAuditEvents';
 import {
   Users, Image, Eye, Download, TrendingUp, Clock,
-  ChevronRight, BarChart3, Activity, Camera
+  ChevronRight, BarChart3, Activity, Camera, Flag, MessageSquare, Fingerprint
 } from 'lucide-react';
 import toast from 'react-hot-toast';
 
@@ -52,11 +56,16 @@ export default function AdminPage() {
       setUsers(usersRes.data.users);
       setAlbums(albumsRes.data.albums);
     } catch (err) {
+      if (err.response?.data?.twoFactorSetupRequired) {
+        toast.error(err.response.data.error);
+        navigate('/profile');
+        return;
+      }
       toast.error('获取数据失败');
     } finally {
       setLoading(false);
     }
-  }, []);
+  }, [navigate]);
 
   useEffect(() => {
     fetchData();
@@ -94,6 +103,9 @@ export default function AdminPage() {
           { key: 'overview', label: '总览', icon: BarChart3 },
           { key: 'users', label: '用户', icon: Users },
           { key: 'albums', label: '影集', icon: Image },
+          { key: 'reports', label: '举报审核', icon: Flag },
+          { key: 'feedback', label: '意见反馈', icon: MessageSquare },
+          { key: 'events', label: '审计事件', icon: Fingerprint },
         ].map(({ key, label, icon: Icon }) => (
           <button
             key={key}
@@ -146,7 +158,14 @@ export default function AdminPage() {
                     <tr key={user.id} className="hover:bg-gray-50 transition-colors">
                       <td className="px-4 py-3">
                         <div>
-                          <div className="font-medium text-gray-900 text-sm">{user.name}</div>
+                          <div className="font-medium text-gray-900 text-sm">
+                            {user.name}
+                            {user.suspended_at && (
+                              <span className="ml-2 text-xs px-2 py-0.5 rounded-full bg-amber-50 text-amber-700" title={user.suspended_reason || ''}>
+                                已停用
+                              </span>
+                            )}
+                          </div>
                           <div className="text-xs text-gray-500">{user.email}</div>
                         </div>
                       </td>
@@ -169,6 +188,14 @@ export default function AdminPage() {
                     {selectedUser === user.id && (
                       <tr key={`${user.id}-albums`}>
                         <td colSpan="7" className="bg-gray-50 px-4 py-3">
+                          <AdminUserActions
+                            key={`${user.id}-${user.suspended_at}`}
+                            user={user}
+                            onChanged={() => {
+                              setSelectedUser(null);
+                              fetchData();
+                            }}
+                          />
                           {userAlbums.length === 0 ? (
                             <p className="text-sm text-gray-500 text-center py-2">暂无影集</p>
                           ) : (
@@ -259,6 +286,14 @@ export default function AdminPage() {
                       }`}>
                         {album.isExpired ? '已过期' : '活跃'}
                       </span>
+                      {album.taken_down && (
+                        <span className="ml-1 text-xs px-2 py-1 rounded-full font-medium bg-red-50 text-red-600">已下架</span>
+                      )}
+                      {album.open_reports > 0 && (
+                        <span className="ml-1 text-xs px-2 py-1 rounded-full font-medium bg-amber-50 text-amber-700">
+                          {album.open_reports} 条举报
+                        </span>
+                      )}
                     </td>
                   </tr>
                 ))}
@@ -273,6 +308,15 @@ export default function AdminPage() {
           )}
         </div>
       )}
+
+      {/* Moderation tab */}
+      {activeTab === 'reports' && <AdminModerationQueue />}
+
+      {/* Feedback tab */}
+      {activeTab === 'feedback' && <AdminFeedbackInbox />}
+
+      {/* Audit events tab */}
+      {activeTab === 'events' && <AdminAuditEvents />}
       </div>
     </div>
   );
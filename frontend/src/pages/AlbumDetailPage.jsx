@@ -5,7 +5,7 @@ import { formatFileSize } from '../utils/format';
 import { QRCodeSVG } from 'qrcode.react';
 import {
   ArrowLeft, Upload, QrCode, Clock, Eye, Download, Image, Trash2,
-  Share2, X, Settings, Copy, Check, Plus
+  Share2, X, Settings, Copy, Check, Plus, ShieldAlert, EyeOff
 } from 'lucide-react';
 import toast from 'react-hot-toast';
 
@@ -415,6 +415,21 @@ export default function AlbumDetailPage() {
         </div>
       )}
 
+      {/* Takedown notice */}
+      {album.takedown && (
+        <div className="bg-red-50 border border-red-200 rounded-2xl p-4 mb-6 flex items-start">
+          <ShieldAlert className="w-5 h-5 text-red-500 mr-3 mt-0.5 shrink-0" />
+          <div className="text-sm">
+            <p className="font-medium text-red-700">
+              {album.takedown.automatic
+                ? '该影集收到多次举报，已暂停公开访问，等待管理员审核'
+                : '该影集已被管理员下架，分享链接暂时无法访问'}
+            </p>
+            <p className="text-red-600 mt-1">原因：{album.takedown.reason}</p>
+          </div>
+        </div>
+      )}
+
       {/* Photos grid */}
       {photos.length === 0 ? (
         <div className="text-center py-20 bg-white rounded-2xl border border-gray-100">
@@ -449,6 +464,16 @@ export default function AlbumDetailPage() {
                   className="masonry-image"
                   loading="lazy"
                 />
+
+                {photo.hiddenAt && (
+                  <span
+                    className="absolute top-2 left-2 px-2 py-1 bg-red-600/90 text-white text-xs rounded-lg flex items-center"
+                    title={photo.hiddenReason || '已被管理员隐藏'}
+                  >
+                    <EyeOff className="w-3 h-3 mr-1" />
+                    访客不可见
+                  </span>
+                )}
                 
                 {/* Overlay actions */}
                 <div className="absolute inset-0 bg-black/0 group-hover:bg-black/20 transition-all flex items-end opacity-0 group-hover:opacity-100 rounded-xl">
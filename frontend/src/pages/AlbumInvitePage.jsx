@@ -0,0 +1,77 @@
+import { useState, useEffect } from 'react';
+import { useSearchParams, Link } from 'react-router-dom';
+import { albumAPI } from '../utils/api';
+import { CheckCircle, XCircle, Loader } from 'lucide-react';
+
+export default function AlbumInvitePage() {
+  const [searchParams] = useSearchParams();
+  const [status, setStatus] = useState('loading'); // loading, success, error
+  const [message, setMessage] = useState('');
+  const [title, setTitle] = useState('');
+
+  useEffect(() => {
+    const token = searchParams.get('token');
+    if (!token) {
+      setStatus('error');
+      setMessage('无效的链接');
+      return;
+    }
+    if (!localStorage.getItem('token')) {
+      setStatus('error');
+      setMessage('请先登录受邀账号，再重新打开邀请链接');
+      return;
+    }
+
+    albumAPI.acceptInvite(token)
+      .then((res) => {
+        setStatus('success');
+        setMessage(res.data.message);
+        setTitle(res.data.albumTitle);
+      })
+      .catch((err) => {
+        setStatus('error');
+        setMessage(err.response?.data?.error || '接受邀请失败');
+      });
+  }, [searchParams]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-indigo-50 via-white to-purple-50 px-4">
+      <div className="bg-white rounded-2xl shadow-xl border border-gray-100 p-6 sm:p-8 max-w-md w-full text-center" style={{ maxWidth: '28rem' }}>
+        {status === 'loading' && (
+          <>
+            <Loader className="w-12 h-12 text-indigo-600 mx-auto mb-4 animate-spin" />
+            <h2 className="text-xl font-semibold text-gray-900">处理中...</h2>
+          </>
+        )}
+        {status === 'success' && (
+          <>
+            <CheckCircle className="w-12 h-12 text-green-500 mx-auto mb-4" />
+            <h2 className="text-xl font-semibold text-gray-900 mb-2">{message}</h2>
+            {title && (
+              <p className="text-gray-500 mb-6">您现在可以协作影集「{title}」</p>
+            )}
+            <Link
+              to="/dashboard"
+              className="inline-block px-6 py-3 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:opacity-90 transition-opacity"
+            >
+              查看我的影集
+            </Link>
+          </>
+        )}
+        {status === 'error' && (
+          <>
+            <XCircle className="w-12 h-12 text-red-500 mx-auto mb-4" />
+            <h2 className="text-xl font-semibold text-gray-900 mb-2">加入失败</h2>
+            <p className="text-gray-500 mb-6">{message}</p>
+            <Link
+              to="/dashboard"
+              className="inline-block w-full px-6 py-3 bg-gray-100 text-gray-700 rounded-xl font-medium hover:bg-gray-200 transition-colors"
+            >
+              返回我的影集
+            </Link>
+          </>
+        )}
+      </div>
+    </div>
+  );
+}
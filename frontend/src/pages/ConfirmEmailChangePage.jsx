@@ -0,0 +1,78 @@
+import { useState, useEffect, useRef } from 'react';
+import { useSearchParams, Link } from 'react-router-dom';
+import { useAuth } from '../contexts/AuthContext';
+import { CheckCircle, XCircle, Loader } from 'lucide-react';
+
+export default function ConfirmEmailChangePage() {
+  const [searchParams] = useSearchParams();
+  const [status, setStatus] = useState('loading');
+  const [message, setMessage] = useState('');
+  const [signedIn, setSignedIn] = useState(false);
+  const { confirmEmailChange } = useAuth();
+  // 确认链接只能使用一次，避免开发模式下重复提交
+  const submitted = useRef(false);
+
+  useEffect(() => {
+    if (submitted.current) return;
+    submitted.current = true;
+
+    const token = searchParams.get('token');
+    if (!token) {
+      setStatus('error');
+      setMessage('确认链接无效');
+      return;
+    }
+
+    confirmEmailChange(token)
+      .then((result) => {
+        setStatus('success');
+        setMessage(result.message);
+        setSignedIn(result.signedIn);
+      })
+      .catch((err) => {
+        setStatus('error');
+        setMessage(err.response?.data?.error || '更改邮箱失败');
+      });
+  }, [searchParams, confirmEmailChange]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-indigo-50 via-white to-purple-50 px-4">
+      <div className="bg-white rounded-2xl shadow-xl border border-gray-100 p-6 sm:p-8 max-w-md w-full text-center" style={{ maxWidth: '28rem' }}>
+        {status === 'loading' && (
+          <>
+            <Loader className="w-12 h-12 text-indigo-600 mx-auto mb-4 animate-spin" />
+            <h2 className="text-xl font-semibold text-gray-900">正在确认...</h2>
+          </>
+        )}
+
+        {status === 'success' && (
+          <>
+            <CheckCircle className="w-12 h-12 text-green-500 mx-auto mb-4" />
+            <h2 className="text-xl font-semibold text-gray-900 mb-2">邮箱已更改</h2>
+            <p className="text-gray-500 mb-6">{message}</p>
+            <Link
+              to={signedIn ? '/profile' : '/login'}
+              className="inline-block w-full px-6 py-3 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:from-indigo-700 hover:to-purple-700 transition-all"
+            >
+              {signedIn ? '返回个人设置' : '前往登录'}
+            </Link>
+          </>
+        )}
+
+        {status === 'error' && (
+          <>
+            <XCircle className="w-12 h-12 text-red-500 mx-auto mb-4" />
+            <h2 className="text-xl font-semibold text-gray-900 mb-2">确认失败</h2>
+            <p className="text-gray-500 mb-6">{message}</p>
+            <Link
+              to="/"
+              className="inline-block w-full px-6 py-3 bg-gray-100 text-gray-700 rounded-xl font-medium hover:bg-gray-200 transition-colors"
+            >
+              返回首页
+            </Link>
+          </>
+        )}
+      </div>
+    </div>
+  );
+}
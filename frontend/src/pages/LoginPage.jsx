@@ -1,7 +1,9 @@
-import { useState } from 'react';
-import { Link, useNavigate } from 'react-router-dom';
+import { useState, useEffect } from 'react';
+import { Link, useNavigate, useLocation } from 'react-router-dom';
 import { useAuth } from '../contexts/AuthContext';
-import { Camera, Mail, Lock, Eye, EyeOff } from 'lucide-react';
+import { Camera, Mail, Lock, Eye, EyeOff, ShieldCheck, KeyRound } from 'lucide-react';
+import { isPasskeySupported } from '../utils/webauthn';
+import { authAPI } from '../utils/api';
 import toast from 'react-hot-toast';
 
 export default function LoginPage() {
@@ -9,9 +11,47 @@ export default function LoginPage() {
   const [password, setPassword] = useState('');
   const [showPassword, setShowPassword] = useState(false);
   const [loading, setLoading] = useState(false);
-  const { login } = useAuth();
+  const location = useLocation();
+  // 通过登录链接进入两步验证时，由链接页面带入 twoFactorToken
+  const [twoFactorToken, setTwoFactorToken] = useState(location.state?.twoFactorToken || '');
+  const [twoFactorCode, setTwoFactorCode] = useState('');
+  const [oidc, setOidc] = useState(null);
+  const { login, loginWithTwoFactor, loginWithPasskey } = useAuth();
   const navigate = useNavigate();
 
+  useEffect(() => {
+    authAPI.getOIDCConfig()
+      .then((res) => setOidc(res.data.enabled ? res.data : null))
+      .catch(() => {});
+  }, []);
+
+  const handleOIDCLogin = async () => {
+    setLoading(true);
+    try {
+      const res = await authAPI.beginOIDCLogin();
+      window.location.href = res.data.authUrl;
+    } catch (err) {
+      toast.error(err.response?.data?.error || '无法跳转到单点登录');
+      setLoading(false);
+    }
+  };
+
+  const finishLogin = (result) => {
+    const user = result.user || result;
+    if (result.requiresVerification || result.message?.includes('验证')) {
+      toast.success(result.message || '登录成功！请尽快验证您的邮箱', { duration: 5000 });
+    } else {
+      toast.success(result.message || '登录成功');
+    }
+    // 要求管理员启用两步验证时，先引导到个人设置完成绑定
+    if (result.twoFactorSetupRequired) {
+      toast('管理员账号需先启用两步验证', { duration: 5000 });
+      navigate('/profile');
+      return;
+    }
+    navigate(user.role === 'admin' ? '/admin' : '/dashboard');
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     if (!email || !password) {
@@ -22,13 +62,11 @@ export default function LoginPage() {
     setLoading(true);
     try {
       const result = await login(email, password);
-      const user = result.user || result;
-      if (result.requiresVerification || result.message?.includes('验证')) {
-        toast.success(result.message || '登录成功！请尽快验证您的邮箱', { duration: 5000 });
-      } else {
-        toast.success(result.message || '登录成功');
+      if (result.requiresTwoFactor) {
+        setTwoFactorToken(result.twoFactorToken);
+        return;
       }
-      navigate(user.role === 'admin' ? '/admin' : '/dashboard');
+      finishLogin(result);
     } catch (err) {
       toast.error(err.response?.data?.error || '登录失败');
     } finally {
@@ -36,6 +74,61 @@ export default function LoginPage() {
     }
   };
 
+  const handleMagicLink = async () => {
+    if (!email) {
+      toast.error('请先填写邮箱');
+      return;
+    }
+
+    setLoading(true);
+    try {
+      const res = await authAPI.requestMagicLink({ email });
+      toast.success(res.data.message, { duration: 5000 });
+    } catch (err) {
+      toast.error(err.response?.data?.error || '发送失败');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handlePasskeyLogin = async () => {
+    setLoading(true);
+    try {
+      const result = await loginWithPasskey();
+      finishLogin(result);
+    } catch (err) {
+      // 用户取消系统弹窗时不提示错误
+      if (err.name !== 'NotAllowedError' && err.name !== 'AbortError') {
+        toast.error(err.response?.data?.error || '通行密钥登录失败');
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleTwoFactorSubmit = async (e) => {
+    e.preventDefault();
+    if (!twoFactorCode) {
+      toast.error('请输入验证码');
+      return;
+    }
+
+    setLoading(true);
+    try {
+      const result = await loginWithTwoFactor(twoFactorToken, twoFactorCode);
+      finishLogin(result);
+    } catch (err) {
+      toast.error(err.response?.data?.error || '验证失败');
+      // 验证窗口已过期，需要重新输入密码
+      if (err.response?.status === 401 && err.response?.data?.error?.includes('重新登录')) {
+        setTwoFactorToken('');
+        setTwoFactorCode('');
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
   return (
     <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-indigo-50 via-white to-purple-50 px-4" style={{ width: '100%', maxWidth: '100vw', overflowX: 'hidden' }}>
       <div className="w-full max-w-md mx-auto" style={{ width: '100%', maxWidth: '28rem' }}>
@@ -48,8 +141,46 @@ export default function LoginPage() {
         </div>
 
         <div className="bg-white rounded-2xl shadow-xl border border-gray-100 p-4 sm:p-8" style={{ width: '100%', maxWidth: '100%' }}>
-          <h2 className="text-xl font-semibold text-gray-900 mb-6">登录</h2>
+          <h2 className="text-xl font-semibold text-gray-900 mb-6">{twoFactorToken ? '两步验证' : '登录'}</h2>
+
+          {twoFactorToken ? (
+          <form onSubmit={handleTwoFactorSubmit} className="space-y-5">
+            <p className="text-sm text-gray-500">请输入身份验证器 App 中的 6 位验证码，或使用一个恢复码。</p>
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1.5">验证码</label>
+              <div className="relative">
+                <ShieldCheck className="absolute left-3 top-1/2 -translate-y-1/2 w-5 h-5 text-gray-400" />
+                <input
+                  type="text"
+                  inputMode="numeric"
+                  autoComplete="one-time-code"
+                  autoFocus
+                  value={twoFactorCode}
+                  onChange={(e) => setTwoFactorCode(e.target.value)}
+                  placeholder="123456"
+                  className="w-full pl-11 pr-4 py-3 border border-gray-200 rounded-xl focus:ring-2 focus:ring-indigo-500 focus:border-transparent outline-none transition-all tracking-widest"
+                  style={{ fontSize: '16px' }}
+                  required
+                />
+              </div>
+            </div>
 
+            <button
+              type="submit"
+              disabled={loading}
+              className="w-full py-3 bg-gradient-to-r from-indigo-600 to-purple-600 text-white rounded-xl font-medium hover:from-indigo-700 hover:to-purple-700 transition-all disabled:opacity-50 disabled:cursor-not-allowed shadow-lg shadow-indigo-200"
+            >
+              {loading ? '验证中...' : '验证'}
+            </button>
+            <button
+              type="button"
+              onClick={() => { setTwoFactorToken(''); setTwoFactorCode(''); }}
+              className="w-full text-sm text-gray-500 hover:text-gray-700"
+            >
+              返回重新登录
+            </button>
+          </form>
+          ) : (
           <form onSubmit={handleSubmit} className="space-y-5">
             <div>
               <label className="block text-sm font-medium text-gray-700 mb-1.5">邮箱</label>
@@ -97,7 +228,41 @@ export default function LoginPage() {
             >
               {loading ? '登录中...' : '登录'}
             </button>
+
+            <button
+              type="button"
+              onClick={handleMagicLink}
+              disabled={loading}
+              className="w-full py-3 flex items-center justify-center border border-gray-200 text-gray-700 rounded-xl font-medium hover:bg-gray-50 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+            >
+              <Mail className="w-5 h-5 mr-2" />
+              发送登录链接到邮箱
+            </button>
+
+            {isPasskeySupported() && (
+              <button
+                type="button"
+                onClick={handlePasskeyLogin}
+                disabled={loading}
+                className="w-full py-3 flex items-center justify-center border border-gray-200 text-gray-700 rounded-xl font-medium hover:bg-gray-50 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                <KeyRound className="w-5 h-5 mr-2" />
+                使用通行密钥登录
+              </button>
+            )}
+
+            {oidc && (
+              <button
+                type="button"
+                onClick={handleOIDCLogin}
+                disabled={loading}
+                className="w-full py-3 flex items-center justify-center border border-gray-200 text-gray-700 rounded-xl font-medium hover:bg-gray-50 transition-all disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                使用{oidc.providerName}登录
+              </button>
+            )}
           </form>
+          )}
 
           <div className="mt-6 space-y-3">
             <div className="text-center">
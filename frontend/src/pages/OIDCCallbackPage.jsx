@@ -0,0 +1,70 @@
+import { useState, useEffect, useRef } from 'react';
+import { useSearchParams, useNavigate, Link } from 'react-router-dom';
+import { useAuth } from '../contexts/AuthContext';
+import { XCircle, Loader } from 'lucide-react';
+import toast from 'react-hot-toast';
+
+export default function OIDCCallbackPage() {
+  const [searchParams] = useSearchParams();
+  const [error, setError] = useState('');
+  const { loginWithOIDC } = useAuth();
+  const navigate = useNavigate();
+  // state 只能使用一次，避免开发模式下重复提交
+  const submitted = useRef(false);
+
+  useEffect(() => {
+    if (submitted.current) return;
+    submitted.current = true;
+
+    const code = searchParams.get('code');
+    const state = searchParams.get('state');
+    if (searchParams.get('error') || !code || !state) {
+      setError(searchParams.get('error_description') || '登录已取消或链接无效');
+      return;
+    }
+
+    loginWithOIDC(code, state)
+      .then((result) => {
+        // 开启两步验证的账号回到登录页输入验证码
+        if (result.requiresTwoFactor) {
+          navigate('/login', { replace: true, state: { twoFactorToken: result.twoFactorToken } });
+          return;
+        }
+        toast.success(result.message || '登录成功');
+        if (result.twoFactorSetupRequired) {
+          navigate('/profile', { replace: true });
+          return;
+        }
+        navigate(result.user.role === 'admin' ? '/admin' : '/dashboard', { replace: true });
+      })
+      .catch((err) => {
+        setError(err.response?.data?.error || '登录失败');
+      });
+  }, [searchParams, loginWithOIDC, navigate]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-indigo-50 via-white to-purple-50 px-4">
+      <div className="bg-white rounded-2xl shadow-xl border border-gray-100 p-6 sm:p-8 max-w-md w-full text-center" style={{ maxWidth: '28rem' }}>
+        {!error && (
+          <>
+            <Loader className="w-12 h-12 text-indigo-600 mx-auto mb-4 animate-spin" />
+            <h2 className="text-xl font-semibold text-gray-900">登录中...</h2>
+          </>
+        )}
+        {error && (
+          <>
+            <XCircle className="w-12 h-12 text-red-500 mx-auto mb-4" />
+            <h2 className="text-xl font-semibold text-gray-900 mb-2">登录失败</h2>
+            <p className="text-gray-500 mb-6">{error}</p>
+            <Link
+              to="/login"
+              className="inline-block w-full px-6 py-3 bg-gray-100 text-gray-700 rounded-xl font-medium hover:bg-gray-200 transition-colors"
+            >
+              返回登录
+            </Link>
+          </>
+        )}
+      </div>
+    </div>
+  );
+}
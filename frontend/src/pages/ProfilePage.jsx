@@ -1,7 +1,12 @@
-import { useState, useEffect } from 'react';
+import { useState, useEffect, useRef } from 'react';
 import { useAuth } from '../contexts/AuthContext';
 import { authAPI } from '../utils/api';
-import { User, Mail, Lock, Save, Key, Send } from 'lucide-react';
+import TwoFactorSettings from '../components/TwoFactorSettings';
+import PasskeySettings from '../components/PasskeySettings';
+import ApiKeySettings from '../components/ApiKeySettings';
+import EmailChangeSettings from '../components/EmailChangeSettings';
+import AccountDataSettings from '../components/AccountDataSettings';
+import { User, Mail, Lock, Save, Key, Send, Camera } from 'lucide-react';
 import toast from 'react-hot-toast';
 
 export default function ProfilePage() {
@@ -17,6 +22,8 @@ export default function ProfilePage() {
   const [passwordLoading, setPasswordLoading] = useState(false);
   const [sendingResetEmail, setSendingResetEmail] = useState(false);
   const [sendingVerificationEmail, setSendingVerificationEmail] = useState(false);
+  const [avatarUploading, setAvatarUploading] = useState(false);
+  const avatarInputRef = useRef(null);
 
   // 页面加载时刷新一次用户信息
   useEffect(() => {
@@ -80,6 +87,33 @@ export default function ProfilePage() {
     }
   };
 
+  const handleAvatarChange = async (e) => {
+    const file = e.target.files?.[0];
+    e.target.value = '';
+    if (!file) return;
+
+    setAvatarUploading(true);
+    try {
+      const res = await authAPI.uploadAvatar(file);
+      toast.success(res.data.message || '头像已更新');
+      refreshProfile();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '头像上传失败');
+    } finally {
+      setAvatarUploading(false);
+    }
+  };
+
+  const handleDeleteAvatar = async () => {
+    try {
+      const res = await authAPI.deleteAvatar();
+      toast.success(res.data.message || '头像已移除');
+      refreshProfile();
+    } catch (err) {
+      toast.error(err.response?.data?.error || '移除头像失败');
+    }
+  };
+
   const handleChangePassword = async (e) => {
     e.preventDefault();
     if (!currentPassword || !newPassword) {
@@ -97,8 +131,13 @@ export default function ProfilePage() {
 
     setPasswordLoading(true);
     try {
-      await authAPI.changePassword({ currentPassword, newPassword });
-      toast.success('密码修改成功');
+      const res = await authAPI.changePassword({ currentPassword, newPassword });
+      // 修改密码会注销所有会话，使用服务端签发的新令牌保持当前登录
+      if (res.data.token) {
+        localStorage.setItem('token', res.data.token);
+        localStorage.setItem('refreshToken', res.data.refreshToken);
+      }
+      toast.success(res.data.message || '密码修改成功');
       setCurrentPassword('');
       setNewPassword('');
       setConfirmPassword('');
@@ -162,6 +201,46 @@ export default function ProfilePage() {
             个人信息
           </h2>
 
+          <div className="flex items-center gap-4 mb-6">
+            <button
+              type="button"
+              onClick={() => avatarInputRef.current?.click()}
+              disabled={avatarUploading}
+              className="relative w-20 h-20 rounded-full overflow-hidden bg-gray-100 flex items-center justify-center group disabled:opacity-50"
+              title="更换头像"
+            >
+              {user.avatarUrl ? (
+                <img src={user.avatarUrl} alt={user.name} className="w-full h-full object-cover" />
+              ) : (
+                <User className="w-8 h-8 text-gray-400" />
+              )}
+              <span className="absolute inset-0 bg-black/40 flex items-center justify-center opacity-0 group-hover:opacity-100 transition-opacity">
+                <Camera className="w-6 h-6 text-white" />
+              </span>
+            </button>
+            <div className="space-y-1">
+              <p className="text-sm text-gray-600">
+                {avatarUploading ? '上传中...' : '头像会显示在您分享的影集页面上'}
+              </p>
+              {user.avatarUrl && (
+                <button
+                  type="button"
+                  onClick={handleDeleteAvatar}
+                  className="text-xs text-gray-500 hover:text-red-600 transition-colors"
+                >
+                  移除头像
+                </button>
+              )}
+            </div>
+            <input
+              ref={avatarInputRef}
+              type="file"
+              accept="image/jpeg,image/png,image/gif"
+              onChange={handleAvatarChange}
+              className="hidden"
+            />
+          </div>
+
           <form onSubmit={handleUpdateProfile} className="space-y-4">
             <div>
               <label className="block text-sm font-medium text-gray-700 mb-1.5">邮箱</label>
@@ -180,7 +259,14 @@ export default function ProfilePage() {
                   {user.emailVerified ? (
                     <span className="text-green-600">✓ 邮箱已验证</span>
                   ) : (
-                    <span className="text-amber-600">⚠ 邮箱未验证</span>
+                    <span className="text-amber-600">
+                      ⚠ 邮箱未验证
+                      {user.verificationDeadline && (
+                        new Date(user.verificationDeadline) > new Date()
+                          ? `，请在 ${new Date(user.verificationDeadline).toLocaleString('zh-CN')} 前完成验证，否则将无法创建影集、上传和分享`
+                          : '，验证后才能创建影集、上传和分享'
+                      )}
+                    </span>
                   )}
                 </p>
                 {!user.emailVerified && (
@@ -333,6 +419,21 @@ export default function ProfilePage() {
             </form>
           </div>
         </div>
+
+        {/* Change email */}
+        <EmailChangeSettings />
+
+        {/* Two-factor authentication */}
+        <TwoFactorSettings />
+
+        {/* Passkeys */}
+        <PasskeySettings />
+
+        {/* API keys */}
+        <ApiKeySettings />
+
+        {/* Data export and account deletion */}
+        <AccountDataSettings />
       </div>
       </div>
     </div>
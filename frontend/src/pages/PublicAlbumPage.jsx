@@ -2,7 +2,8 @@ import { useState, useEffect, useCallback } from 'react';
 import { useParams, useNavigate, Link } from 'react-router-dom';
 import { publicAPI } from '../utils/api';
 import { formatFileSize } from '../utils/format';
-import { Camera, Download, Image, Clock, AlertCircle, X, User, LogIn, MessageSquare } from 'lucide-react';
+import ReportAlbumDialog from '../components/ReportAlbumDialog';
+import { Camera, Download, Image, Clock, AlertCircle, X, User, LogIn, MessageSquare, Flag } from 'lucide-react';
 import toast from 'react-hot-toast';
 
 export default function PublicAlbumPage() {
@@ -14,6 +15,9 @@ export default function PublicAlbumPage() {
   const [error, setError] = useState(null);
   const [previewImg, setPreviewImg] = useState(null);
   const [downloading, setDownloading] = useState({});
+  const [takedownReason, setTakedownReason] = useState('');
+  // null when closed, { photo } to report a photo or the whole album
+  const [reporting, setReporting] = useState(null);
 
   useEffect(() => {
     async function fetchAlbum() {
@@ -25,6 +29,9 @@ export default function PublicAlbumPage() {
         const status = err.response?.status;
         if (status === 410) {
           setError('expired');
+        } else if (status === 403 && err.response?.data?.takenDown) {
+          setTakedownReason(err.response.data.reason);
+          setError('takendown');
         } else if (status === 404) {
           setError('notfound');
         } else {
@@ -75,11 +82,19 @@ export default function PublicAlbumPage() {
             <AlertCircle className="w-8 h-8 text-gray-400" />
           </div>
           <h2 className="text-xl font-semibold text-gray-900 mb-2">
-            {error === 'expired' ? '影集已过期' : error === 'notfound' ? '影集不存在' : '加载失败'}
+            {error === 'expired'
+              ? '影集已过期'
+              : error === 'takendown'
+              ? '影集已被下架'
+              : error === 'notfound'
+              ? '影集不存在'
+              : '加载失败'}
           </h2>
           <p className="text-gray-500">
             {error === 'expired'
               ? '这个影集的有效期已到，照片已被清理。'
+              : error === 'takendown'
+              ? `该影集经管理员审核已停止公开访问。原因：${takedownReason}`
               : error === 'notfound'
               ? '请检查链接是否正确。'
               : '请稍后重试。'}
@@ -130,7 +145,15 @@ export default function PublicAlbumPage() {
           )}
           <div className="flex flex-wrap items-center gap-4 mt-3 text-sm text-gray-500">
             <span className="flex items-center">
-              <User className="w-3.5 h-3.5 mr-1" />
+              {album.photographerAvatar ? (
+                <img
+                  src={album.photographerAvatar['64']}
+                  alt={album.photographerName}
+                  className="w-5 h-5 mr-1.5 rounded-full object-cover"
+                />
+              ) : (
+                <User className="w-3.5 h-3.5 mr-1" />
+              )}
               {album.photographerName}
             </span>
             <span className="flex items-center">
@@ -209,6 +232,13 @@ export default function PublicAlbumPage() {
             <MessageSquare className="w-4 h-4 mr-2" />
             意见反馈
           </Link>
+          <button
+            onClick={() => setReporting({ photo: null })}
+            className="inline-flex items-center ml-3 px-6 py-3 text-sm text-gray-600 hover:text-red-600 hover:bg-red-50 rounded-xl transition-colors border border-gray-200 hover:border-red-200"
+          >
+            <Flag className="w-4 h-4 mr-2" />
+            举报
+          </button>
         </div>
       </div>
 
@@ -227,7 +257,14 @@ export default function PublicAlbumPage() {
             className="max-w-full max-h-[85vh] object-contain rounded-lg"
             onClick={(e) => e.stopPropagation()}
           />
-          <div className="absolute bottom-6 left-1/2 -translate-x-1/2">
+          <div className="absolute bottom-6 left-1/2 -translate-x-1/2 flex items-center gap-3">
+            <button
+              onClick={(e) => { e.stopPropagation(); setReporting({ photo: previewImg }); }}
+              className="px-4 py-3 bg-white/10 text-white rounded-xl font-medium hover:bg-white/20 flex items-center"
+            >
+              <Flag className="w-5 h-5 mr-2" />
+              举报
+            </button>
             <button
               onClick={(e) => { e.stopPropagation(); handleDownload(previewImg.id, previewImg.original_name); }}
               className="px-6 py-3 bg-white text-gray-900 rounded-xl font-medium hover:bg-gray-100 flex items-center shadow-xl"
@@ -238,6 +275,14 @@ export default function PublicAlbumPage() {
           </div>
         </div>
       )}
+
+      {reporting && (
+        <ReportAlbumDialog
+          shareCode={shareCode}
+          photo={reporting.photo}
+          onClose={() => setReporting(null)}
+        />
+      )}
     </div>
   );
 }
@@ -17,21 +17,74 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// 刷新 access token（并发的401请求共用同一次刷新）
+let refreshPromise = null;
+const refreshAccessToken = () => {
+  if (!refreshPromise) {
+    const refreshToken = localStorage.getItem('refreshToken');
+    refreshPromise = (refreshToken
+      ? axios.post(`${API_BASE}/auth/refresh`, { refreshToken }).then((res) => {
+          localStorage.setItem('token', res.data.token);
+          localStorage.setItem('refreshToken', res.data.refreshToken);
+          return res.data.token;
+        })
+      : Promise.reject(new Error('no refresh token'))
+    ).finally(() => {
+      refreshPromise = null;
+    });
+  }
+  return refreshPromise;
+};
+
 // Handle auth errors
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
     // 对于不需要认证的API（如resend-verification, forgot-password），即使返回401也不应该登出
-    const noAuthRequiredPaths = ['/auth/resend-verification', '/auth/forgot-password', '/auth/reset-password', '/auth/register', '/auth/login', '/auth/verify-email'];
+    const noAuthRequiredPaths = ['/auth/resend-verification', '/auth/forgot-password', '/auth/reset-password', '/auth/magic-link', '/auth/change-email/confirm', '/auth/register', '/auth/login', '/auth/refresh', '/auth/logout', '/auth/webauthn/login', '/auth/oidc/', '/auth/verify-email', '/extend-album'];
     const isNoAuthPath = noAuthRequiredPaths.some(path => error.config?.url?.includes(path));
     
     if (error.response?.status === 401 && !isNoAuthPath) {
+      // access token 过期时先尝试用 refresh token 续期一次
+      if (!error.config._retried) {
+        try {
+          const token = await refreshAccessToken();
+          error.config._retried = true;
+          error.config.headers.Authorization = `Bearer ${token}`;
+          return api(error.config);
+        } catch {
+          // 刷新失败，按登出处理
+        }
+      }
+
+      // 模拟登录已过期（无法续期），恢复管理员自己的登录状态
+      const adminSession = localStorage.getItem('adminSession');
+      if (adminSession) {
+        const saved = JSON.parse(adminSession);
+        localStorage.removeItem('adminSession');
+        localStorage.setItem('token', saved.token);
+        localStorage.setItem('refreshToken', saved.refreshToken);
+        localStorage.setItem('user', saved.user);
+        window.location.href = '/admin';
+        return Promise.reject(error);
+      }
+
       localStorage.removeItem('token');
+      localStorage.removeItem('refreshToken');
       localStorage.removeItem('user');
       if (!window.location.pathname.startsWith('/login') && !window.location.pathname.startsWith('/s/')) {
         window.location.href = '/login';
       }
     }
+    // 账号被管理员停用，清除本地登录状态
+    if (error.response?.status === 403 && error.response?.data?.accountSuspended && !isNoAuthPath) {
+      localStorage.removeItem('token');
+      localStorage.removeItem('refreshToken');
+      localStorage.removeItem('user');
+      if (!window.location.pathname.startsWith('/login')) {
+        window.location.href = '/login';
+      }
+    }
     return Promise.reject(error);
   }
 );
@@ -40,13 +93,52 @@ api.interceptors.response.use(
 export const authAPI = {
   register: (data) => api.post('/auth/register', data),
   login: (data) => api.post('/auth/login', data),
+  loginTwoFactor: (data) => api.post('/auth/login/2fa', data),
   verifyEmail: (token) => api.get(`/auth/verify-email?token=${token}`),
   resendVerification: (data) => api.post('/auth/resend-verification', data),
   forgotPassword: (data) => api.post('/auth/forgot-password', data),
   resetPassword: (data) => api.post('/auth/reset-password', data),
+  requestEmailChange: (data) => api.post('/auth/change-email', data),
+  cancelEmailChange: () => api.delete('/auth/change-email'),
+  confirmEmailChange: (data) => api.post('/auth/change-email/confirm', data),
+  requestMagicLink: (data) => api.post('/auth/magic-link', data),
+  magicLinkLogin: (data) => api.post('/auth/magic-link/verify', data),
   getProfile: () => api.get('/auth/profile'),
   updateProfile: (data) => api.put('/auth/profile', data),
   changePassword: (data) => api.put('/auth/change-password', data),
+  logout: (token) => api.post('/auth/logout', null, { headers: { Authorization: `Bearer ${token}` } }),
+  getSessions: () => api.get('/auth/sessions'),
+  revokeSession: (id) => api.delete(`/auth/sessions/${id}`),
+  revokeOtherSessions: () => api.delete('/auth/sessions'),
+  getTwoFactorStatus: () => api.get('/auth/2fa'),
+  setupTwoFactor: (data) => api.post('/auth/2fa/setup', data),
+  enableTwoFactor: (data) => api.post('/auth/2fa/enable', data),
+  disableTwoFactor: (data) => api.post('/auth/2fa/disable', data),
+  regenerateRecoveryCodes: (data) => api.post('/auth/2fa/recovery-codes', data),
+  getOIDCConfig: () => api.get('/auth/oidc/config'),
+  // state 通过 Cookie 与发起登录的浏览器绑定
+  beginOIDCLogin: () => api.post('/auth/oidc/begin', null, { withCredentials: true }),
+  oidcCallback: (data) => api.post('/auth/oidc/callback', data, { withCredentials: true }),
+  beginPasskeyLogin: () => api.post('/auth/webauthn/login/begin'),
+  finishPasskeyLogin: (data) => api.post('/auth/webauthn/login/finish', data),
+  beginPasskeyRegistration: (data) => api.post('/auth/webauthn/register/begin', data),
+  finishPasskeyRegistration: (data) => api.post('/auth/webauthn/register/finish', data),
+  getPasskeys: () => api.get('/auth/webauthn/credentials'),
+  deletePasskey: (id) => api.delete(`/auth/webauthn/credentials/${id}`),
+  uploadAvatar: (file) => {
+    const formData = new FormData();
+    formData.append('avatar', file);
+    return api.put('/auth/profile/avatar', formData, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+  },
+  deleteAvatar: () => api.delete('/auth/profile/avatar'),
+  getApiKeys: () => api.get('/auth/profile/api-keys'),
+  createApiKey: (data) => api.post('/auth/profile/api-keys', data),
+  revokeApiKey: (id) => api.delete(`/auth/profile/api-keys/${id}`),
+  exportData: () => api.get('/auth/export'),
+  deleteAccount: (data) => api.delete('/auth/account', { data }),
+  restoreAccount: () => api.post('/auth/account/restore'),
 };
 
 // Album APIs
@@ -65,12 +157,21 @@ export const albumAPI = {
     }),
   deletePhoto: (albumId, photoId) => api.delete(`/albums/${albumId}/photos/${photoId}`),
   getPhotoOriginal: (albumId, photoId) => api.get(`/albums/${albumId}/photos/${photoId}/original`),
+  extendByToken: (token) => api.post('/extend-album', { token }),
+  acceptTransferByToken: (token) => api.post('/accept-album-transfer', { token }),
+  acceptInvite: (token) => api.post('/album-invites/accept', { token }),
+};
+
+// Workspace APIs
+export const workspaceAPI = {
+  acceptInvite: (token) => api.post('/workspace-invites/accept', { token }),
 };
 
 // Public APIs
 export const publicAPI = {
   viewAlbum: (shareCode) => api.get(`/s/${shareCode}`),
   downloadPhoto: (shareCode, photoId) => api.get(`/s/${shareCode}/photos/${photoId}/download`),
+  reportAlbum: (shareCode, data) => api.post(`/s/${shareCode}/report`, data),
 };
 
 // Admin APIs
@@ -80,6 +181,30 @@ export const adminAPI = {
   getUserAlbums: (userId, page = 1) => api.get(`/admin/users/${userId}/albums?page=${page}`),
   getAllAlbums: (page = 1, status = 'all') => api.get(`/admin/albums?page=${page}&status=${status}`),
   getAlbumLogs: (albumId) => api.get(`/admin/albums/${albumId}/logs`),
+  suspendUser: (userId, data) => api.post(`/admin/users/${userId}/suspend`, data),
+  unsuspendUser: (userId) => api.post(`/admin/users/${userId}/unsuspend`),
+  changeUserRole: (userId, data) => api.put(`/admin/users/${userId}/role`, data),
+  setUserQuota: (userId, data) => api.put(`/admin/users/${userId}/quota`, data),
+  forcePasswordReset: (userId) => api.post(`/admin/users/${userId}/force-password-reset`),
+  impersonateUser: (userId, data) => api.post(`/admin/users/${userId}/impersonate`, data),
+  deleteUser: (userId) => api.delete(`/admin/users/${userId}`),
+  getAuditEvents: (page = 1, filters = {}) => api.get('/admin/audit-events', { params: { page, ...filters } }),
+  exportAuditEvents: (filters = {}) => api.get('/admin/audit-events/export', { params: filters, responseType: 'blob', timeout: 120000 }),
+  verifyAuditEvents: () => api.get('/admin/audit-events/verify', { timeout: 120000 }),
+  getReports: (page = 1, status = 'open', category = '') => api.get(`/admin/reports?page=${page}&status=${status}&category=${category}`),
+  getReportedAlbums: (page = 1, status = 'open') => api.get(`/admin/reports/albums?page=${page}&status=${status}`),
+  reviewReport: (reportId, data) => api.put(`/admin/reports/${reportId}`, data),
+  getAlbumPhotos: (albumId) => api.get(`/admin/albums/${albumId}/photos`),
+  takedownAlbum: (albumId, data) => api.post(`/admin/albums/${albumId}/takedown`, data),
+  restoreAlbum: (albumId) => api.delete(`/admin/albums/${albumId}/takedown`),
+  hidePhoto: (albumId, photoId, data) => api.post(`/admin/albums/${albumId}/photos/${photoId}/hide`, data),
+  unhidePhoto: (albumId, photoId) => api.post(`/admin/albums/${albumId}/photos/${photoId}/unhide`),
+  deletePhoto: (albumId, photoId, data) => api.delete(`/admin/albums/${albumId}/photos/${photoId}`, { data }),
+  getFeedback: (page = 1, status = '') => api.get(`/admin/feedback?page=${page}&status=${status}`),
+  getFeedbackDetail: (feedbackId) => api.get(`/admin/feedback/${feedbackId}`),
+  updateFeedback: (feedbackId, data) => api.put(`/admin/feedback/${feedbackId}`, data),
+  replyFeedback: (feedbackId, data) => api.post(`/admin/feedback/${feedbackId}/reply`, data),
+  deleteFeedback: (feedbackId) => api.delete(`/admin/feedback/${feedbackId}`),
 };
 
 // Feedback APIs
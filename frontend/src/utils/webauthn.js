@@ -0,0 +1,64 @@
+// 通行密钥（WebAuthn）浏览器端辅助函数：服务端以 base64url 传输二进制字段
+
+const toBuffer = (value) => {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const padded = base64 + '='.repeat((4 - (base64.length % 4)) % 4);
+  return Uint8Array.from(atob(padded), (c) => c.charCodeAt(0)).buffer;
+};
+
+const toBase64url = (buffer) => {
+  if (!buffer) return undefined;
+  const bytes = new Uint8Array(buffer);
+  let binary = '';
+  bytes.forEach((b) => { binary += String.fromCharCode(b); });
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+};
+
+export const isPasskeySupported = () =>
+  typeof window !== 'undefined' && !!window.PublicKeyCredential && !!navigator.credentials;
+
+// 创建通行密钥，options 为服务端 register/begin 返回的 options
+export async function createPasskey(options) {
+  const publicKey = {
+    ...options.publicKey,
+    challenge: toBuffer(options.publicKey.challenge),
+    user: { ...options.publicKey.user, id: toBuffer(options.publicKey.user.id) },
+    excludeCredentials: (options.publicKey.excludeCredentials || []).map((c) => ({ ...c, id: toBuffer(c.id) })),
+  };
+
+  const credential = await navigator.credentials.create({ publicKey });
+  return {
+    id: credential.id,
+    rawId: toBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    response: {
+      clientDataJSON: toBase64url(credential.response.clientDataJSON),
+      attestationObject: toBase64url(credential.response.attestationObject),
+      transports: credential.response.getTransports ? credential.response.getTransports() : [],
+    },
+  };
+}
+
+// 使用通行密钥签名，options 为服务端 login/begin 返回的 options
+export async function getPasskey(options) {
+  const publicKey = {
+    ...options.publicKey,
+    challenge: toBuffer(options.publicKey.challenge),
+    allowCredentials: (options.publicKey.allowCredentials || []).map((c) => ({ ...c, id: toBuffer(c.id) })),
+  };
+
+  const credential = await navigator.credentials.get({ publicKey });
+  return {
+    id: credential.id,
+    rawId: toBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    response: {
+      clientDataJSON: toBase64url(credential.response.clientDataJSON),
+      authenticatorData: toBase64url(credential.response.authenticatorData),
+      signature: toBase64url(credential.response.signature),
+      userHandle: toBase64url(credential.response.userHandle),
+    },
+  };
+}